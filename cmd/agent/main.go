@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/agent"
+	"github.com/antgubarev/jobs/internal/cliflag"
+	"github.com/antgubarev/jobs/internal/executor"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+)
+
+var version = "dev"
+
+var errInvalidArgument = errors.New("invalid argument")
+
+func runAction(ctx *cli.Context) error {
+	client := restapi.NewClientHTTP(ctx.String("server-url"),
+		restapi.WithActor(restapi.DefaultActor()),
+		restapi.WithToken(ctx.String("token")),
+	)
+	shim, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("job-agent: %w", err)
+	}
+
+	hostName := ctx.String("host-name")
+	if hostName == "" {
+		if hostName, err = os.Hostname(); err != nil {
+			return fmt.Errorf("job-agent: %w", err)
+		}
+	}
+
+	jobAgent := agent.NewAgent(client,
+		agent.WithSocket(ctx.String("socket")),
+		agent.WithLogDir(ctx.String("log-dir")),
+		agent.WithHost(hostName, cliflag.Map(ctx, "label")),
+		agent.WithVersion(version),
+		agent.WithCapacity(ctx.Int("capacity")),
+		agent.WithRegistration(ctx.Duration("register-interval"), agent.DefaultReconnectBackoff),
+		agent.WithExecutorOptions(
+			executor.WithGracePeriod(ctx.Duration("grace-period")),
+			executor.WithShim(shim),
+			executor.WithServerURL(ctx.String("server-url")),
+			executor.WithCgroupRoot(ctx.String("cgroup-root")),
+			executor.WithStopCheck(ctx.Duration("stop-check-interval")),
+		),
+	)
+
+	// The socket API is for local tools, its requests aren't logged.
+	gin.SetMode(gin.ReleaseMode)
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := jobAgent.Run(runCtx); err != nil {
+		return fmt.Errorf("job-agent: %w", err)
+	}
+
+	return nil
+}
+
+func listAction(ctx *cli.Context) error {
+	executions, err := agent.NewClient(ctx.String("socket")).List(ctx.Context)
+	if err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+
+	writer := tabwriter.NewWriter(ctx.App.Writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tSERVER ID\tJOB\tSTATUS\tPID\tSTARTED\tEXIT CODE\tCOMMAND")
+	for _, execution := range executions {
+		serverID, pid, exitCode := "-", "-", "-"
+		if execution.ServerID != nil {
+			serverID = execution.ServerID.String()
+		}
+		if execution.Pid != nil {
+			pid = fmt.Sprint(*execution.Pid)
+		}
+		if execution.ExitCode != nil {
+			exitCode = fmt.Sprint(*execution.ExitCode)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", execution.ID, serverID, execution.Job, execution.Status,
+			pid, execution.StartedAt.Format(time.RFC3339), exitCode, strings.Join(execution.Command, " "))
+	}
+
+	return writer.Flush()
+}
+
+func execAction(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return fmt.Errorf("%w: `command` is required", errInvalidArgument)
+	}
+
+	execution, err := agent.NewClient(ctx.String("socket")).Start(ctx.Context, &agent.StartIn{
+		Job:     ctx.String("job-name"),
+		Command: ctx.Args().Slice(),
+		Params:  cliflag.Map(ctx, "param"),
+	})
+	if err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+	fmt.Fprintln(ctx.App.Writer, execution.ID)
+
+	return nil
+}
+
+func stopAction(ctx *cli.Context) error {
+	id, err := uuid.Parse(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("%w: execution id: %v", errInvalidArgument, err)
+	}
+
+	execution, err := agent.NewClient(ctx.String("socket")).Stop(ctx.Context, id, ctx.Bool("wait"))
+	if err != nil {
+		return fmt.Errorf("stop: %w", err)
+	}
+	if execution.ExitCode != nil {
+		fmt.Fprintf(ctx.App.Writer, "%s %s, exit code %d\n", execution.ID, execution.Status, *execution.ExitCode)
+	}
+
+	return nil
+}
+
+func logsAction(ctx *cli.Context) error {
+	id, err := uuid.Parse(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("%w: execution id: %v", errInvalidArgument, err)
+	}
+
+	if err := agent.NewClient(ctx.String("socket")).Logs(ctx.Context, id, ctx.Int64("tail"), ctx.App.Writer); err != nil {
+		return fmt.Errorf("logs: %w", err)
+	}
+
+	return nil
+}
+
+func main() {
+	// The agent re-executes itself as the shim to apply the job's sandbox right before exec of the command.
+	if len(os.Args) > 1 && os.Args[1] == executor.ShimCommand {
+		os.Exit(executor.RunShim(os.Args[2:]))
+	}
+
+	// glog is used by the agent and executor packages, job-agent logs only to stderr.
+	_ = flag.Set("logtostderr", "true")
+	_ = flag.CommandLine.Parse(nil)
+
+	socketFlag := &cli.StringFlag{
+		Name:    "socket",
+		Value:   agent.DefaultSocket(),
+		EnvVars: []string{"JOBS_AGENT_SOCKET"},
+		Usage:   "Unix socket of the agent control API",
+	}
+
+	app := &cli.App{
+		Name:    "job-agent",
+		Usage:   "Long-running executor: supervises executions of jobs on the host and registers the host to the server.",
+		Version: version,
+		Commands: []*cli.Command{
+			{
+				Name:   "run",
+				Usage:  "Run the agent",
+				Action: runAction,
+				Flags: []cli.Flag{
+					socketFlag,
+					&cli.StringFlag{
+						Name:    "server-url",
+						Aliases: []string{"s"},
+						Value:   "http://localhost:8080",
+						Usage:   "Address of api server",
+					},
+					&cli.StringFlag{
+						Name:    "token",
+						EnvVars: []string{"JOBS_TOKEN"},
+						Usage:   "Api bearer token, required if server auth is enabled",
+					},
+					&cli.StringFlag{
+						Name:  "host-name",
+						Usage: "Name of the host registered to the server, the hostname by default",
+					},
+					&cli.GenericFlag{
+						Name:  "label",
+						Value: &cliflag.MapValue{},
+						Usage: "Label `name=value` of the host, can be repeated",
+					},
+					&cli.IntFlag{
+						Name:  "capacity",
+						Usage: "Max number of executions run at once, 0 means no limit",
+					},
+					&cli.DurationFlag{
+						Name:  "register-interval",
+						Value: agent.DefaultRegisterInterval,
+						Usage: "How often the host is registered to the server (heartbeat)",
+					},
+					&cli.StringFlag{
+						Name:  "log-dir",
+						Value: agent.DefaultLogDir(),
+						Usage: "Where outputs of executions are written",
+					},
+					&cli.DurationFlag{
+						Name:  "grace-period",
+						Value: 10 * time.Second,
+						Usage: "How long the command may shut down after stop before its process group is killed",
+					},
+					&cli.DurationFlag{
+						Name:  "stop-check-interval",
+						Value: 10 * time.Second,
+						Usage: "Poll the server for stop requests of executions with the interval, 0 disables polling",
+					},
+					&cli.StringFlag{
+						Name:  "cgroup-root",
+						Value: executor.DefaultCgroupRoot,
+						Usage: "cgroup v2 directory for cgroups of executions with cgroup limits in the job's sandbox",
+					},
+				},
+			},
+			{
+				Name:   "list",
+				Usage:  "List executions supervised by the agent",
+				Action: listAction,
+				Flags:  []cli.Flag{socketFlag},
+			},
+			{
+				Name:      "exec",
+				Usage:     "Start the command of the job by the agent, prints the execution id",
+				ArgsUsage: "[command] [args]",
+				Action:    execAction,
+				Flags: []cli.Flag{
+					socketFlag,
+					&cli.StringFlag{
+						Name:     "job-name",
+						Aliases:  []string{"j"},
+						Usage:    "Unique name of job to start",
+						Required: true,
+					},
+					&cli.GenericFlag{
+						Name:    "param",
+						Aliases: []string{"p"},
+						Value:   &cliflag.MapValue{},
+						Usage:   "Override the job's param `name=value`, can be repeated",
+					},
+				},
+			},
+			{
+				Name:      "stop",
+				Usage:     "Stop the execution",
+				ArgsUsage: "[execution id]",
+				Action:    stopAction,
+				Flags: []cli.Flag{
+					socketFlag,
+					&cli.BoolFlag{
+						Name:  "wait",
+						Usage: "Wait until the execution is finished",
+					},
+				},
+			},
+			{
+				Name:      "logs",
+				Usage:     "Print the output of the execution",
+				ArgsUsage: "[execution id]",
+				Action:    logsAction,
+				Flags: []cli.Flag{
+					socketFlag,
+					&cli.Int64Flag{
+						Name:  "tail",
+						Usage: "Print only the last `bytes` of the output",
+					},
+				},
+			},
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
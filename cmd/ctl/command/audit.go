@@ -0,0 +1,87 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+func (b *CmdBuilder) auditCommand() *cobra.Command {
+	var (
+		filter audit.Filter
+		since  time.Duration
+	)
+
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Audit log of mutating API calls",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if since > 0 {
+				sinceTime := time.Now().Add(-since)
+				filter.Since = &sinceTime
+			}
+
+			records, err := b.client().AuditList(context.Background(), filter)
+			if err != nil {
+				return fmt.Errorf("audit action: %w", err)
+			}
+
+			return b.print(cmd, records, func() *table {
+				t := &table{columns: []column{
+					{name: "Time"}, {name: "Actor"}, {name: "Source IP"}, {name: "Action"}, {name: "Target"},
+					{name: "Status"}, {name: "Changes"},
+				}}
+				for _, record := range records {
+					t.append(
+						record.Time.Format(time.RFC3339),
+						record.Actor,
+						record.SourceIP,
+						record.Action,
+						record.Target,
+						strconv.Itoa(record.Status),
+						formatChanges(record.Changes),
+					)
+				}
+
+				return t
+			})
+		},
+	}
+
+	auditCmd.Flags().StringVar(&filter.Actor, "actor", "", "Filter by actor")
+	auditCmd.Flags().StringVar(&filter.Action, "action", "", "Filter by action, e.g. `job.pause`")
+	auditCmd.Flags().StringVar(&filter.Target, "target", "", "Filter by target (job name or execution id)")
+	auditCmd.Flags().DurationVar(&since, "since", 0, "Show records not older than duration, e.g. `24h`")
+	auditCmd.Flags().IntVar(&filter.Limit, "limit", 100, "Max records count")
+
+	return auditCmd
+}
+
+func formatChanges(changes []audit.Change) string {
+	result := ""
+	for i, change := range changes {
+		if i > 0 {
+			result += "\n"
+		}
+		result += change.Path + ": " + formatValue(change.From) + " -> " + formatValue(change.To)
+	}
+
+	return result
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "<nil>"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "?"
+	}
+
+	return string(data)
+}
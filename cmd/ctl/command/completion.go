@@ -0,0 +1,188 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/ctlconfig"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout limits requests of dynamic completion, the shell waits for them on each tab.
+const completionTimeout = 2 * time.Second
+
+// completionRecent is the number of recent executions which IDs are completed.
+const completionRecent = 50
+
+type completeFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// registerFlagCompletion sets the completion of the flag, the flag must exist.
+func registerFlagCompletion(cmd *cobra.Command, flag string, complete completeFunc) {
+	if err := cmd.RegisterFlagCompletionFunc(flag, complete); err != nil {
+		glog.Fatalf("config completion of flag `%s`: %v", flag, err)
+	}
+}
+
+// completionClient returns the client of the server given by flags of the completed command line.
+func (b *CmdBuilder) completionClient() (*restapi.ClientHTTP, error) {
+	if err := b.loadContext(); err != nil {
+		return nil, err
+	}
+
+	return b.client(), nil
+}
+
+// completeFromServer calls list with the completion timeout and filters its values by the prefix.
+// Values may have descriptions after a tab. Errors are written to the completion debug log only.
+func (b *CmdBuilder) completeFromServer(
+	list func(ctx context.Context, client *restapi.ClientHTTP) ([]string, error),
+) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		client, err := b.completionClient()
+		if err != nil {
+			cobra.CompErrorln(err.Error())
+
+			return nil, cobra.ShellCompDirectiveError
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+		defer cancel()
+
+		values, err := list(ctx, client)
+		if err != nil {
+			cobra.CompErrorln(err.Error())
+
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		return filterCompletions(values, toComplete, args), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func (b *CmdBuilder) completeJobNames() completeFunc {
+	return b.completeFromServer(func(ctx context.Context, client *restapi.ClientHTTP) ([]string, error) {
+		jobs, err := client.JobsList(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("complete job names: %w", err)
+		}
+		names := make([]string, 0, len(jobs))
+		for _, listed := range jobs {
+			names = append(names, listed.Name+"\t"+string(listed.Status))
+		}
+
+		return names, nil
+	})
+}
+
+// completeExecutionIDs completes IDs of recent executions with the status, any status if it's empty.
+func (b *CmdBuilder) completeExecutionIDs(status job.ExecutionStatus) completeFunc {
+	return b.completeFromServer(func(ctx context.Context, client *restapi.ClientHTTP) ([]string, error) {
+		executions, err := client.ExecutionsList(ctx, job.ExecutionFilter{Status: status, Limit: completionRecent})
+		if err != nil {
+			return nil, fmt.Errorf("complete execution ids: %w", err)
+		}
+		ids := make([]string, 0, len(executions))
+		for i := range executions {
+			execution := &executions[i]
+			ids = append(ids, fmt.Sprintf("%s\t%s on %s, %s", execution.ID, execution.Job,
+				stringOrDash(execution.Host), execution.Status))
+		}
+
+		return ids, nil
+	})
+}
+
+func (b *CmdBuilder) completeHostNames() completeFunc {
+	return b.completeFromServer(func(ctx context.Context, client *restapi.ClientHTTP) ([]string, error) {
+		hosts, err := client.HostsList(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("complete host names: %w", err)
+		}
+		names := make([]string, 0, len(hosts))
+		for _, registered := range hosts {
+			names = append(names, registered.Name+"\t"+string(registered.Status))
+		}
+
+		return names, nil
+	})
+}
+
+func (b *CmdBuilder) completeResourceNames() completeFunc {
+	return b.completeFromServer(func(ctx context.Context, client *restapi.ClientHTTP) ([]string, error) {
+		resources, err := client.ResourcesList(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("complete resource names: %w", err)
+		}
+		names := make([]string, 0, len(resources))
+		for _, resource := range resources {
+			names = append(names, resource.Name)
+		}
+
+		return names, nil
+	})
+}
+
+// completeContextNames completes contexts of the config file, the server isn't requested.
+func (b *CmdBuilder) completeContextNames() completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		cfg, err := ctlconfig.Load(b.globalFlags.config)
+		if err != nil {
+			cobra.CompErrorln(err.Error())
+
+			return nil, cobra.ShellCompDirectiveError
+		}
+		names := make([]string, 0, len(cfg.Contexts))
+		for _, context := range cfg.Contexts {
+			names = append(names, context.Name+"\t"+ctlconfig.NormalizeServer(context.Server))
+		}
+
+		return filterCompletions(names, toComplete, args), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeValues completes the fixed values, the space isn't added after the value ending with `=`.
+func completeValues(values ...string) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		completions := filterCompletions(values, toComplete, nil)
+		if len(completions) == 1 && strings.HasSuffix(completions[0], "=") {
+			return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+		}
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeArgs completes positional arguments up to max of them, 0 means any number.
+func completeArgs(max int, complete completeFunc) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if max > 0 && len(args) >= max {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return complete(cmd, args, toComplete)
+	}
+}
+
+// filterCompletions keeps values with the prefix which aren't given in args yet.
+func filterCompletions(values []string, prefix string, args []string) []string {
+	given := make(map[string]bool, len(args))
+	for _, arg := range args {
+		given[arg] = true
+	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		name := value
+		if i := strings.Index(value, "\t"); i >= 0 {
+			name = value[:i]
+		}
+		if strings.HasPrefix(name, prefix) && !given[name] {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,165 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterCompletions(t *testing.T) {
+	t.Parallel()
+	values := []string{"backup\tactive", "billing\tpaused", "cleanup"}
+	testCases := []struct {
+		name     string
+		prefix   string
+		args     []string
+		expected []string
+	}{
+		{name: "all", expected: values},
+		{name: "prefix", prefix: "b", expected: []string{"backup\tactive", "billing\tpaused"}},
+		{name: "prefix without description", prefix: "c", expected: []string{"cleanup"}},
+		{name: "description isn't matched", prefix: "backup\ta", expected: []string{}},
+		{name: "given args", prefix: "b", args: []string{"backup"}, expected: []string{"billing\tpaused"}},
+		{name: "nothing", prefix: "x", expected: []string{}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, filterCompletions(values, testCase.prefix, testCase.args))
+		})
+	}
+}
+
+func TestCompleteValues(t *testing.T) {
+	t.Parallel()
+	complete := completeValues("json", "jsonpath=", "yaml")
+	testCases := []struct {
+		name      string
+		prefix    string
+		expected  []string
+		directive cobra.ShellCompDirective
+	}{
+		{
+			name:      "all",
+			expected:  []string{"json", "jsonpath=", "yaml"},
+			directive: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:      "several",
+			prefix:    "js",
+			expected:  []string{"json", "jsonpath="},
+			directive: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:      "no space after `=`",
+			prefix:    "jsonp",
+			expected:  []string{"jsonpath="},
+			directive: cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace,
+		},
+		{
+			name:      "single",
+			prefix:    "y",
+			expected:  []string{"yaml"},
+			directive: cobra.ShellCompDirectiveNoFileComp,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			completions, directive := complete(&cobra.Command{}, nil, testCase.prefix)
+			assert.Equal(t, testCase.expected, completions)
+			assert.Equal(t, testCase.directive, directive)
+		})
+	}
+}
+
+func TestCompleteArgs(t *testing.T) {
+	t.Parallel()
+	complete := completeValues("first", "second", "third")
+	testCases := []struct {
+		name     string
+		max      int
+		args     []string
+		expected []string
+	}{
+		{name: "first arg", max: 1, expected: []string{"first", "second", "third"}},
+		{name: "max reached", max: 1, args: []string{"first"}, expected: nil},
+		{name: "any number", args: []string{"first", "second"}, expected: []string{"first", "second", "third"}},
+		{name: "below max", max: 3, args: []string{"first"}, expected: []string{"first", "second", "third"}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			completions, directive := completeArgs(testCase.max, complete)(&cobra.Command{}, testCase.args, "")
+			assert.Equal(t, testCase.expected, completions)
+			assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+		})
+	}
+}
+
+// completionBuilder returns the builder which completes from the server without the config file.
+func completionBuilder(t *testing.T, server string) *CmdBuilder {
+	t.Helper()
+	b := NewBuilder()
+	b.globalFlags.config = filepath.Join(t.TempDir(), "config.yaml")
+	b.globalFlags.serverURL = server
+
+	return b
+}
+
+func TestCompleteFromServer(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jobs":[{"name":"backup","status":"active"},{"name":"cleanup","status":"paused"}]}`))
+	}))
+	defer server.Close()
+
+	completions, directive := completionBuilder(t, server.URL).completeJobNames()(&cobra.Command{}, nil, "b")
+	assert.Equal(t, []string{"backup\tactive"}, completions)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+}
+
+func TestCompleteFromServerError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	completions, directive := completionBuilder(t, server.URL).completeJobNames()(&cobra.Command{}, nil, "")
+	assert.Empty(t, completions)
+	assert.Equal(t, cobra.ShellCompDirectiveError, directive)
+}
+
+func TestCompleteFromServerTimeout(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	started := time.Now()
+	completions, directive := completionBuilder(t, server.URL).completeJobNames()(&cobra.Command{}, nil, "")
+	elapsed := time.Since(started)
+	assert.Empty(t, completions)
+	assert.Equal(t, cobra.ShellCompDirectiveError, directive)
+	assert.GreaterOrEqual(t, elapsed, completionTimeout)
+	assert.Less(t, elapsed, completionTimeout+time.Second)
+}
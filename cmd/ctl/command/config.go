@@ -0,0 +1,264 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/antgubarev/jobs/internal/ctlconfig"
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+func (b *CmdBuilder) configCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Contexts of servers in the config file",
+		Long: "Contexts are named connection settings of servers kept in the config file (--config, $JOBSCTL_CONFIG, " +
+			"~/.config/jobsctl/config.yaml by default). Commands use the current context, --context or $JOBSCTL_CONTEXT " +
+			"selects another one. Its settings are overridden by " + formatEnvNames() + " and flags.",
+	}
+
+	configCmd.AddCommand(b.configViewCommand())
+	configCmd.AddCommand(b.configGetContextsCommand())
+	configCmd.AddCommand(b.configCurrentContextCommand())
+	configCmd.AddCommand(b.configUseContextCommand())
+	configCmd.AddCommand(b.configSetContextCommand())
+	configCmd.AddCommand(b.configDeleteContextCommand())
+
+	return configCmd
+}
+
+func (b *CmdBuilder) configViewCommand() *cobra.Command {
+	var raw bool
+
+	viewCmd := &cobra.Command{
+		Use:   "view",
+		Short: "Print the config file, tokens are redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			view := *b.config
+			if !raw {
+				view.Contexts = redactTokens(view.Contexts)
+			}
+			if b.printer.format == outputTable || b.printer.format == outputWide {
+				if err := yaml.NewEncoder(cmd.OutOrStdout()).Encode(&view); err != nil {
+					return fmt.Errorf("config view action: %w", err)
+				}
+
+				return nil
+			}
+
+			return b.print(cmd, &view, nil)
+		},
+	}
+
+	viewCmd.Flags().BoolVar(&raw, "raw", false, "Print tokens")
+
+	return viewCmd
+}
+
+func (b *CmdBuilder) configGetContextsCommand() *cobra.Command {
+	getContextsCmd := &cobra.Command{
+		Use:   "get-contexts",
+		Short: "Contexts list",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contexts := redactTokens(b.config.Contexts)
+			sort.Slice(contexts, func(i, j int) bool {
+				return contexts[i].Name < contexts[j].Name
+			})
+
+			return b.print(cmd, contexts, func() *table {
+				t := &table{columns: []column{
+					{name: "Current"}, {name: "Name"}, {name: "Server"}, {name: "Namespace"}, {name: "Auth"}, {name: "TLS"},
+				}}
+				for _, context := range contexts {
+					current, server, namespace, auth, tls := "", context.Server, "-", "-", "-"
+					if context.Name == b.config.CurrentContext {
+						current = "*"
+					}
+					if server == "" {
+						server = ctlconfig.DefaultServer
+					}
+					if context.Namespace != "" {
+						namespace = context.Namespace
+					}
+					if context.Token != "" {
+						auth = "token"
+					}
+					if context.TLS.Enabled() {
+						tls = formatTLS(context.TLS)
+					}
+					t.append(current, context.Name, server, namespace, auth, tls)
+				}
+
+				return t
+			})
+		},
+	}
+
+	return getContextsCmd
+}
+
+func (b *CmdBuilder) configCurrentContextCommand() *cobra.Command {
+	currentContextCmd := &cobra.Command{
+		Use:   "current-context",
+		Short: "Print the name of the current context",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if b.config.CurrentContext == "" {
+				return fmt.Errorf("current context action: %w: current context isn't set", ctlconfig.ErrContextNotFound)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), b.config.CurrentContext)
+
+			return nil
+		},
+	}
+
+	return currentContextCmd
+}
+
+func (b *CmdBuilder) configUseContextCommand() *cobra.Command {
+	useContextCmd := &cobra.Command{
+		Use:               "use-context NAME",
+		Short:             "Make the context current",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(1, b.completeContextNames()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := b.config.UseContext(args[0]); err != nil {
+				return fmt.Errorf("use context action: %w", err)
+			}
+			if err := b.config.Save(b.globalFlags.config); err != nil {
+				return fmt.Errorf("use context action: %w", err)
+			}
+			glog.Infof("switched to context `%s`", args[0])
+
+			return nil
+		},
+	}
+
+	return useContextCmd
+}
+
+func (b *CmdBuilder) configSetContextCommand() *cobra.Command {
+	var (
+		update ctlconfig.Context
+		use    bool
+	)
+
+	setContextCmd := &cobra.Command{
+		Use:               "set-context NAME",
+		Short:             "Create the context or update the given settings of the existing one",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(1, b.completeContextNames()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			context := ctlconfig.Context{Name: args[0]}
+			if existing, err := b.config.Context(args[0]); err == nil {
+				context = *existing
+			}
+
+			flags := cmd.Flags()
+			if flags.Changed("server") {
+				context.Server = ctlconfig.NormalizeServer(update.Server)
+			}
+			if flags.Changed("token") {
+				context.Token = update.Token
+			}
+			if flags.Changed("namespace") {
+				context.Namespace = update.Namespace
+			}
+			if flags.Changed("tls-ca-file") {
+				context.TLS.CAFile = update.TLS.CAFile
+			}
+			if flags.Changed("tls-cert-file") {
+				context.TLS.CertFile = update.TLS.CertFile
+			}
+			if flags.Changed("tls-key-file") {
+				context.TLS.KeyFile = update.TLS.KeyFile
+			}
+			if flags.Changed("tls-insecure-skip-verify") {
+				context.TLS.InsecureSkipVerify = update.TLS.InsecureSkipVerify
+			}
+
+			b.config.SetContext(context)
+			if use || b.config.CurrentContext == "" {
+				b.config.CurrentContext = context.Name
+			}
+			if err := b.config.Save(b.globalFlags.config); err != nil {
+				return fmt.Errorf("set context action: %w", err)
+			}
+			glog.Infof("context `%s` saved", context.Name)
+
+			return nil
+		},
+	}
+
+	setContextCmd.Flags().StringVar(&update.Server, "server", "", "Server URL, e.g. `https://jobs.example.com`")
+	setContextCmd.Flags().StringVar(&update.Token, "token", "", "Bearer token")
+	setContextCmd.Flags().StringVar(&update.Namespace, "namespace", "", "Default namespace of jobs")
+	setContextCmd.Flags().StringVar(&update.TLS.CAFile, "tls-ca-file", "", "CA certificate of the server")
+	setContextCmd.Flags().StringVar(&update.TLS.CertFile, "tls-cert-file", "", "Client certificate")
+	setContextCmd.Flags().StringVar(&update.TLS.KeyFile, "tls-key-file", "", "Key of the client certificate")
+	setContextCmd.Flags().BoolVar(&update.TLS.InsecureSkipVerify, "tls-insecure-skip-verify", false,
+		"Don't verify the server certificate")
+	setContextCmd.Flags().BoolVar(&use, "use", false, "Make the context current")
+
+	return setContextCmd
+}
+
+func (b *CmdBuilder) configDeleteContextCommand() *cobra.Command {
+	deleteContextCmd := &cobra.Command{
+		Use:               "delete-context NAME",
+		Short:             "Delete the context",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(1, b.completeContextNames()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := b.config.DeleteContext(args[0]); err != nil {
+				return fmt.Errorf("delete context action: %w", err)
+			}
+			if err := b.config.Save(b.globalFlags.config); err != nil {
+				return fmt.Errorf("delete context action: %w", err)
+			}
+			glog.Infof("context `%s` deleted", args[0])
+
+			return nil
+		},
+	}
+
+	return deleteContextCmd
+}
+
+func redactTokens(contexts []ctlconfig.Context) []ctlconfig.Context {
+	result := make([]ctlconfig.Context, 0, len(contexts))
+	for _, context := range contexts {
+		if context.Token != "" {
+			context.Token = redacted
+		}
+		result = append(result, context)
+	}
+
+	return result
+}
+
+func formatTLS(settings ctlconfig.TLS) string {
+	var parts []string
+	if settings.CAFile != "" {
+		parts = append(parts, "ca")
+	}
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		parts = append(parts, "client cert")
+	}
+	if settings.InsecureSkipVerify {
+		parts = append(parts, "insecure")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func formatEnvNames() string {
+	return "$" + strings.Join(ctlconfig.EnvNames(), ", $")
+}
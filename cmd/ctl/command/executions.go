@@ -0,0 +1,286 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+var errInvalidStatus = errors.New("invalid status")
+
+func (b *CmdBuilder) executionsCommand() *cobra.Command {
+	executionsCmd := &cobra.Command{
+		Use:     "execution",
+		Short:   "Executions of jobs",
+		Aliases: []string{"ex", "executions"},
+	}
+
+	executionsCmd.AddCommand(b.executionsListCommand())
+	executionsCmd.AddCommand(b.executionsGetCommand())
+	executionsCmd.AddCommand(b.executionsFinishCommand())
+	executionsCmd.AddCommand(b.executionsStopCommand())
+
+	return executionsCmd
+}
+
+func (b *CmdBuilder) executionsListCommand() *cobra.Command {
+	var (
+		filter job.ExecutionFilter
+		status string
+		since  string
+		until  string
+	)
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Executions list, newest first",
+		Aliases: []string{"l", "ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if filter.Status, err = parseExecutionStatus(status, true); err != nil {
+				return fmt.Errorf("execution list action: %w", err)
+			}
+			if filter.Since, err = parseTimeFlag(since); err != nil {
+				return fmt.Errorf("execution list action: since: %w", err)
+			}
+			if filter.Until, err = parseTimeFlag(until); err != nil {
+				return fmt.Errorf("execution list action: until: %w", err)
+			}
+
+			executions, err := b.client().ExecutionsList(context.Background(), filter)
+			if err != nil {
+				return fmt.Errorf("execution list action: %w", err)
+			}
+
+			return b.print(cmd, executions, func() *table {
+				return executionsTable(executions)
+			})
+		},
+	}
+
+	listCmd.Flags().StringVarP(&filter.Job, "job", "j", "", "Only executions of the job")
+	listCmd.Flags().StringVar(&filter.Host, "host", "", "Only executions on the host")
+	listCmd.Flags().StringVar(&status, "status", "", "Only executions with the status: running, successed, failed or lost")
+	listCmd.Flags().StringVar(&since, "since", "",
+		"Only executions started after the time, RFC3339 or a duration before now like `1h`")
+	listCmd.Flags().StringVar(&until, "until", "",
+		"Only executions started before the time, RFC3339 or a duration before now like `1h`")
+	listCmd.Flags().IntVar(&filter.Limit, "limit", 0, "Max number of executions, the server's default is 100")
+	registerFlagCompletion(listCmd, "job", b.completeJobNames())
+	registerFlagCompletion(listCmd, "host", b.completeHostNames())
+	registerFlagCompletion(listCmd, "status", completeValues(string(job.StatusRunning), string(job.StatusSuccessed),
+		string(job.StatusFailed), string(job.StatusLost)))
+
+	return listCmd
+}
+
+func (b *CmdBuilder) executionsGetCommand() *cobra.Command {
+	getCmd := &cobra.Command{
+		Use:               "get ID",
+		Short:             "Execution details",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(1, b.completeExecutionIDs("")),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("execution get action: %w: id: %v", errInvalidArgument, err)
+			}
+			execution, err := b.client().ExecutionGet(context.Background(), id)
+			if err != nil {
+				return fmt.Errorf("execution get action: %w", err)
+			}
+
+			return b.print(cmd, execution, func() *table {
+				return executionsTable([]job.Execution{*execution})
+			})
+		},
+	}
+
+	return getCmd
+}
+
+func executionsTable(executions []job.Execution) *table {
+	t := &table{columns: []column{
+		{name: "ID"}, {name: "Job"}, {name: "Host"}, {name: "Status"}, {name: "Started"}, {name: "Duration"},
+		{name: "Exit code"}, {name: "Command", wide: true}, {name: "Pid", wide: true}, {name: "Finished", wide: true},
+		{name: "Message", wide: true}, {name: "Params", wide: true}, {name: "Labels", wide: true},
+		{name: "Resources", wide: true}, {name: "Stop requested", wide: true},
+	}}
+	for i := range executions {
+		execution := &executions[i]
+		t.append(
+			execution.ID.String(),
+			execution.Job,
+			stringOrDash(execution.Host),
+			string(execution.Status),
+			execution.StartedAt.Format(time.RFC3339),
+			formatDuration(execution),
+			intOrDash(execution.ExitCode),
+			stringOrDash(execution.Command),
+			intOrDash(execution.Pid),
+			timeOrDash(execution.FinishedAt),
+			stringOrDash(execution.Msg),
+			formatLabels(execution.Params),
+			formatLabels(execution.Labels),
+			strings.Join(execution.Resources, ","),
+			timeOrDash(execution.StopRequestedAt),
+		)
+	}
+
+	return t
+}
+
+func (b *CmdBuilder) executionsFinishCommand() *cobra.Command {
+	var (
+		status   string
+		exitCode int
+		msg      string
+	)
+
+	finishCmd := &cobra.Command{
+		Use:               "finish ID",
+		Short:             "Finish the execution manually, e.g. if its executor is lost",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(1, b.completeExecutionIDs(job.StatusRunning)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("execution finish action: %w: id: %v", errInvalidArgument, err)
+			}
+			finishStatus, err := parseExecutionStatus(status, false)
+			if err != nil {
+				return fmt.Errorf("execution finish action: %w", err)
+			}
+
+			in := &restapi.JobFinishIn{Status: &finishStatus}
+			if cmd.Flags().Changed("exit-code") {
+				in.ExitCode = &exitCode
+			}
+			if msg != "" {
+				in.Msg = &msg
+			}
+			if err := b.client().JobFinish(context.Background(), id, in); err != nil {
+				if errors.Is(err, restapi.ErrNotRunning) {
+					return fmt.Errorf("execution finish action: execution `%s` has been finished", id)
+				}
+
+				return fmt.Errorf("execution finish action: %w", err)
+			}
+
+			glog.Infof("execution `%s` finished as %s", id, finishStatus)
+
+			return nil
+		},
+	}
+
+	finishCmd.Flags().StringVar(&status, "status", "", "Status of the execution: successed or failed")
+	finishCmd.Flags().IntVar(&exitCode, "exit-code", 0, "Exit code of the execution")
+	finishCmd.Flags().StringVar(&msg, "msg", "", "Message of the execution")
+	if err := finishCmd.MarkFlagRequired("status"); err != nil {
+		glog.Fatalf("config required flag `status`: %v", err)
+	}
+	registerFlagCompletion(finishCmd, "status", completeValues(string(job.StatusSuccessed), string(job.StatusFailed)))
+
+	return finishCmd
+}
+
+func (b *CmdBuilder) executionsStopCommand() *cobra.Command {
+	stopCmd := &cobra.Command{
+		Use:               "stop ID",
+		Short:             "Request the executor of the running execution to stop the command",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(1, b.completeExecutionIDs(job.StatusRunning)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("execution stop action: %w: id: %v", errInvalidArgument, err)
+			}
+			if _, err := b.client().ExecutionStop(context.Background(), id); err != nil {
+				if errors.Is(err, restapi.ErrNotRunning) {
+					return fmt.Errorf("execution stop action: execution `%s` isn't running", id)
+				}
+
+				return fmt.Errorf("execution stop action: %w", err)
+			}
+
+			glog.Infof("stop of execution `%s` is requested", id)
+
+			return nil
+		},
+	}
+
+	return stopCmd
+}
+
+// parseExecutionStatus parses the status flag, running and empty statuses are allowed only for filters.
+func parseExecutionStatus(value string, filter bool) (job.ExecutionStatus, error) {
+	status := job.ExecutionStatus(value)
+	switch {
+	case value == "" && filter:
+		return "", nil
+	case status == job.StatusSuccessed, status == job.StatusFailed:
+		return status, nil
+	case (status == job.StatusRunning || status == job.StatusLost) && filter:
+		return status, nil
+	}
+
+	return "", fmt.Errorf("%w: `%s`", errInvalidStatus, value)
+}
+
+// parseTimeFlag parses RFC3339 time or the duration before now.
+func parseTimeFlag(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return &at, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("must be RFC3339 time or duration: %w", err)
+	}
+	at := time.Now().Add(-duration)
+
+	return &at, nil
+}
+
+func formatDuration(execution *job.Execution) string {
+	if execution.FinishedAt == nil {
+		return time.Since(execution.StartedAt).Round(time.Second).String()
+	}
+
+	return execution.FinishedAt.Sub(execution.StartedAt).Round(time.Millisecond).String()
+}
+
+func stringOrDash(value *string) string {
+	if value == nil || *value == "" {
+		return "-"
+	}
+
+	return *value
+}
+
+func intOrDash(value *int) string {
+	if value == nil {
+		return "-"
+	}
+
+	return strconv.Itoa(*value)
+}
+
+func timeOrDash(value *time.Time) string {
+	if value == nil {
+		return "-"
+	}
+
+	return value.Format(time.RFC3339)
+}
@@ -0,0 +1,73 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func (b *CmdBuilder) hostsCommand() *cobra.Command {
+	hostsCmd := &cobra.Command{
+		Use:   "host",
+		Short: "Hosts registered by agents",
+	}
+
+	hostsCmd.AddCommand(b.hostsListCommand())
+
+	return hostsCmd
+}
+
+func (b *CmdBuilder) hostsListCommand() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Hosts list",
+		Aliases: []string{"l", "ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hosts, err := b.client().HostsList(context.Background())
+			if err != nil {
+				return fmt.Errorf("host list action: %w", err)
+			}
+
+			return b.print(cmd, hosts, func() *table {
+				t := &table{columns: []column{
+					{name: "Name"}, {name: "Status"}, {name: "Labels"}, {name: "Agent"}, {name: "Capacity"},
+					{name: "Last seen"}, {name: "Registered", wide: true},
+				}}
+				for _, registered := range hosts {
+					capacity := "-"
+					if registered.Capacity > 0 {
+						capacity = strconv.Itoa(registered.Capacity)
+					}
+					t.append(
+						registered.Name,
+						string(registered.Status),
+						formatLabels(registered.Labels),
+						registered.AgentVersion,
+						capacity,
+						registered.LastSeen.Format(time.RFC3339),
+						registered.RegisteredAt.Format(time.RFC3339),
+					)
+				}
+
+				return t
+			})
+		},
+	}
+
+	return listCmd
+}
+
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
@@ -2,13 +2,20 @@ package command
 
 import (
 	"context"
-	"os"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/antgubarev/jobs/internal/ctlconfig"
+	"github.com/antgubarev/jobs/internal/job"
 	"github.com/antgubarev/jobs/internal/restapi"
 	"github.com/golang/glog"
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 func (b *CmdBuilder) jobsCommand() *cobra.Command {
@@ -21,83 +28,550 @@ func (b *CmdBuilder) jobsCommand() *cobra.Command {
 	jobsCmd.AddCommand(b.jobsCreateCommand())
 	jobsCmd.AddCommand(b.jobsListCommand())
 	jobsCmd.AddCommand(b.jobsDeleteCommand())
+	jobsCmd.AddCommand(b.jobsPauseCommand())
+	jobsCmd.AddCommand(b.jobsResumeCommand())
+	jobsCmd.AddCommand(b.jobsStatsCommand())
 
 	return jobsCmd
 }
 
 func (b *CmdBuilder) jobsCreateCommand() *cobra.Command {
 	var (
-		jobName  string
-		lockMode string
+		jobName       string
+		lockMode      string
+		lockLabel     string
+		keepLast      int
+		keepFor       time.Duration
+		keepFailedFor time.Duration
+		sandboxFile   string
+		env           []string
+		params        []string
+		selector      []string
+		resources     []string
+		labels        []string
+		schedule      string
+		maxInterval   time.Duration
+		grace         time.Duration
 	)
 
 	createCmd := &cobra.Command{
 		Use:     "create",
 		Short:   "Create a new job",
 		Aliases: []string{"c"},
-		Run: func(cmd *cobra.Command, args []string) {
-			client := restapi.NewClientHTTP(b.globalFlags.serverURL)
-			if err := client.JobCreate(context.Background(), &restapi.CreateJobIn{
-				Name:     jobName,
-				LockMode: lockMode,
-			}); err != nil {
-				glog.Errorf("create action: %v", err)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			createJobIn := &restapi.CreateJobIn{
+				Name:      jobName,
+				LockMode:  lockMode,
+				LockLabel: lockLabel,
+				Resources: resources,
+			}
+			retention := &job.RetentionPolicy{
+				KeepLast:      keepLast,
+				KeepFor:       job.Duration(keepFor),
+				KeepFailedFor: job.Duration(keepFailedFor),
+			}
+			if err := retention.Validate(); err != nil {
+				return fmt.Errorf("create action: %w: %v", errInvalidArgument, err)
+			}
+			if !retention.IsEmpty() {
+				createJobIn.Retention = retention
+			}
+			if schedule != "" || maxInterval != 0 || grace != 0 {
+				createJobIn.Cadence = &job.Cadence{
+					Schedule:    schedule,
+					MaxInterval: job.Duration(maxInterval),
+					Grace:       job.Duration(grace),
+				}
+			}
+			var err error
+			if createJobIn.Env, err = parseEnv(env); err != nil {
+				return fmt.Errorf("create action: %w", err)
+			}
+			createJobIn.Params = parseParams(params)
+			if createJobIn.HostSelector, err = parseSelector(selector); err != nil {
+				return fmt.Errorf("create action: %w", err)
+			}
+			if createJobIn.Labels, err = parseLabels(labels); err != nil {
+				return fmt.Errorf("create action: %w", err)
+			}
+			createJobIn.Labels = b.withNamespace(createJobIn.Labels)
+			if sandboxFile != "" {
+				sandbox, err := readSandbox(sandboxFile)
+				if err != nil {
+					return fmt.Errorf("create action: %w", err)
+				}
+				createJobIn.Sandbox = sandbox
+			}
+
+			client := b.client()
+			if err := client.JobCreate(context.Background(), createJobIn); err != nil {
+				return fmt.Errorf("create action: %w", err)
 			}
 
 			glog.Infof("job `%s` created \n", jobName)
+
+			return nil
 		},
 	}
 
 	createCmd.Flags().StringVarP(&jobName, "name", "n", "", "Unique job name")
 	createCmd.Flags().StringVarP(&lockMode, "lock-mode", "l", "free",
-		"Lock mode. Available value: `free`(default), `host`, `cluster`")
+		"Lock mode. Available value: `free`(default), `host`, `cluster`, `label`")
+	createCmd.Flags().StringVar(&lockLabel, "lock-label", "",
+		"Host label `name` for `label` lock mode, one execution runs at once per value of the label")
+	createCmd.Flags().IntVar(&keepLast, "keep-last", 0, "Keep last N finished executions, overrides server retention")
+	createCmd.Flags().DurationVar(&keepFor, "keep-for", 0, "Keep finished executions for duration, overrides server retention")
+	createCmd.Flags().DurationVar(&keepFailedFor, "keep-failed-for", 0,
+		"Keep failed executions for duration, overrides server retention")
+	createCmd.Flags().StringVar(&sandboxFile, "sandbox", "",
+		"YAML or JSON file with the sandbox of the job's command: limits, cgroup, nice, ionice, workDir, user, group, envAllow")
+	createCmd.Flags().StringArrayVarP(&env, "env", "e", nil, "Environment variable `NAME=value` of the job, can be repeated")
+	createCmd.Flags().StringArrayVarP(&params, "param", "p", nil,
+		"Param `name[=default]` of the job, the param without default is required at start, can be repeated")
+	createCmd.Flags().StringArrayVar(&selector, "selector", nil,
+		"Host label `name=value[,value]` required to run the job, one of values must match, can be repeated")
+	createCmd.Flags().StringArrayVar(&resources, "resource", nil,
+		"Shared resource `name` held by each execution of the job, can be repeated")
+	createCmd.Flags().StringArrayVar(&labels, "label", nil,
+		"Label `name=value` of the job to select it in pause and resume, can be repeated")
+	createCmd.Flags().StringVar(&schedule, "schedule", "",
+		"Cron `expression` of expected runs, e.g. `0 3 * * *` or `@hourly`, the job is overdue if a run is missed")
+	createCmd.Flags().DurationVar(&maxInterval, "max-interval", 0,
+		"Longest expected time between runs, the job is overdue after it")
+	createCmd.Flags().DurationVar(&grace, "grace", 0, "How late the expected run may be before the job is overdue")
 	if err := createCmd.MarkFlagRequired("name"); err != nil {
 		glog.Fatalf("config required flag `name`: %v", err)
 	}
+	registerFlagCompletion(createCmd, "lock-mode", completeValues(
+		string(job.FreeLockMode), string(job.HostLockMode), string(job.ClusterLockMode), string(job.LabelLockMode)))
+	registerFlagCompletion(createCmd, "resource", b.completeResourceNames())
 
 	return createCmd
 }
 
 func (b *CmdBuilder) jobsListCommand() *cobra.Command {
+	var allNamespaces bool
+
 	listCmd := &cobra.Command{
 		Use:     "list",
 		Short:   "Jobs list",
 		Aliases: []string{"l", "ls"},
-		Run: func(cmd *cobra.Command, args []string) {
-			client := restapi.NewClientHTTP(b.globalFlags.serverURL)
-			jobs, err := client.JobsList(context.Background())
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobs, err := b.client().JobsList(context.Background())
 			if err != nil {
-				glog.Errorf("job list action: %v", err)
+				return fmt.Errorf("job list action: %w", err)
 			}
-			table := tablewriter.NewWriter(os.Stdout)
-			table.SetHeader([]string{"Name", "Lock mode", "Created"})
-
-			for _, jb := range jobs {
-				table.Append([]string{jb.Name, string(jb.LockMode), jb.CreatedAt.Format(time.RFC3339)})
+			if !allNamespaces {
+				jobs = b.inNamespace(jobs)
 			}
 
-			table.Render()
+			return b.print(cmd, jobs, func() *table {
+				return jobsTable(jobs)
+			})
 		},
 	}
 
+	listCmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List jobs of all namespaces")
+
 	return listCmd
 }
 
+// inNamespace returns jobs of the namespace of the context, all jobs if the namespace isn't set.
+func (b *CmdBuilder) inNamespace(jobs []job.Job) []job.Job {
+	if b.context.Namespace == "" {
+		return jobs
+	}
+	filtered := make([]job.Job, 0, len(jobs))
+	for _, jb := range jobs {
+		if jb.Labels[ctlconfig.NamespaceLabel] == b.context.Namespace {
+			filtered = append(filtered, jb)
+		}
+	}
+
+	return filtered
+}
+
+// withNamespace adds the namespace of the context to labels unless they have it.
+func (b *CmdBuilder) withNamespace(labels map[string]string) map[string]string {
+	if b.context.Namespace == "" {
+		return labels
+	}
+	if _, ok := labels[ctlconfig.NamespaceLabel]; ok {
+		return labels
+	}
+	if labels == nil {
+		labels = make(map[string]string, 1)
+	}
+	labels[ctlconfig.NamespaceLabel] = b.context.Namespace
+
+	return labels
+}
+
+func jobsTable(jobs []job.Job) *table {
+	t := &table{columns: []column{
+		{name: "Name"}, {name: "Status"}, {name: "Lock mode"}, {name: "Labels"}, {name: "Created"},
+		{name: "Missed run"}, {name: "Host selector", wide: true}, {name: "Resources", wide: true},
+		{name: "Params", wide: true}, {name: "Cadence", wide: true},
+	}}
+	for _, jb := range jobs {
+		lockMode := string(jb.LockMode)
+		if jb.LockLabel != "" {
+			lockMode += " (" + jb.LockLabel + ")"
+		}
+		overdue := "-"
+		if jb.Overdue != nil {
+			overdue = jb.Overdue.DueAt.Format(time.RFC3339)
+		}
+		t.append(
+			jb.Name, string(jb.Status), lockMode, formatLabels(jb.Labels), jb.CreatedAt.Format(time.RFC3339),
+			overdue, formatSelector(jb.HostSelector), strings.Join(jb.Resources, ","), formatParams(jb.Params),
+			formatCadence(jb.Cadence),
+		)
+	}
+
+	return t
+}
+
 func (b *CmdBuilder) jobsDeleteCommand() *cobra.Command {
+	var (
+		jobName string
+		force   bool
+	)
+
 	deleteCmd := &cobra.Command{
 		Use:     "delete",
 		Short:   "Delete job",
 		Aliases: []string{"d", "del"},
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := b.client().JobDelete(context.Background(), jobName, force); err != nil {
+				if errors.Is(err, restapi.ErrLocked) {
+					return fmt.Errorf("delete action: job `%s` has running executions, use --force to delete them", jobName)
+				}
+
+				return fmt.Errorf("delete action: %w", err)
+			}
+
+			glog.Infof("job `%s` deleted \n", jobName)
+
+			return nil
 		},
 	}
 
-	var jobName string
-
 	deleteCmd.Flags().StringVarP(&jobName, "name", "n", "", "Unique job name")
+	deleteCmd.Flags().BoolVar(&force, "force", false,
+		"Delete the job with running executions, their executors stop the commands")
 	if err := deleteCmd.MarkFlagRequired("name"); err != nil {
 		glog.Fatalf("config required flag `name`: %v", err)
 	}
+	registerFlagCompletion(deleteCmd, "name", b.completeJobNames())
 
 	return deleteCmd
 }
+
+func (b *CmdBuilder) jobsPauseCommand() *cobra.Command {
+	return b.jobsStatusCommand("pause", "Pause jobs, their executions can't be started", "paused",
+		func(ctx context.Context, client *restapi.ClientHTTP, name string) error {
+			return client.JobPause(ctx, name)
+		})
+}
+
+func (b *CmdBuilder) jobsResumeCommand() *cobra.Command {
+	return b.jobsStatusCommand("resume", "Resume paused jobs", "resumed",
+		func(ctx context.Context, client *restapi.ClientHTTP, name string) error {
+			return client.JobResume(ctx, name)
+		})
+}
+
+func (b *CmdBuilder) jobsStatsCommand() *cobra.Command {
+	var windows []string
+
+	statsCmd := &cobra.Command{
+		Use:               "stats NAME",
+		Short:             "Run statistics of the job: success rate, durations, failure streaks and lock rejections",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeArgs(1, b.completeJobNames()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, window := range windows {
+				if _, err := job.ParseStatsWindow(window); err != nil {
+					return fmt.Errorf("job stats action: %w: %v", errInvalidArgument, err)
+				}
+			}
+			stats, err := b.client().JobStats(context.Background(), args[0], windows)
+			if err != nil {
+				return fmt.Errorf("job stats action: %w", err)
+			}
+
+			return b.print(cmd, stats, func() *table {
+				t := &table{columns: []column{
+					{name: "Window"}, {name: "Executions"}, {name: "Running", wide: true},
+					{name: "Successed", wide: true}, {name: "Failed", wide: true}, {name: "Lost", wide: true},
+					{name: "Success rate"}, {name: "P50"}, {name: "P95"}, {name: "Max"},
+					{name: "Failure streak"}, {name: "Longest streak"}, {name: "Lock rejections"}, {name: "Last success"},
+				}}
+				for _, window := range stats.Windows {
+					rate := "-"
+					if window.SuccessRate != nil {
+						rate = strconv.FormatFloat(*window.SuccessRate*100, 'f', 1, 64) + "%"
+					}
+					t.append(
+						formatWindow(time.Duration(window.Window)),
+						strconv.Itoa(window.Executions),
+						strconv.Itoa(window.Running),
+						strconv.Itoa(window.Successed),
+						strconv.Itoa(window.Failed),
+						strconv.Itoa(window.Lost),
+						rate,
+						formatStatsDuration(window.P50Duration),
+						formatStatsDuration(window.P95Duration),
+						formatStatsDuration(window.MaxDuration),
+						strconv.Itoa(window.FailureStreak),
+						strconv.Itoa(window.LongestFailureStreak),
+						strconv.Itoa(window.LockRejections),
+						timeOrDash(window.LastSuccessAt),
+					)
+				}
+
+				return t
+			})
+		},
+	}
+
+	statsCmd.Flags().StringArrayVarP(&windows, "window", "w", nil,
+		"Window `24h`, `7d` or `all` for the whole history, can be repeated. Default 24h, 7d and 30d")
+	registerFlagCompletion(statsCmd, "window", completeValues("1h", "24h", "7d", "30d", job.AllHistory))
+
+	return statsCmd
+}
+
+// formatWindow formats windows of whole days in days and drops zero minutes and seconds, e.g. `1h`.
+func formatWindow(window time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case window == 0:
+		return job.AllHistory
+	case window%day == 0:
+		return strconv.Itoa(int(window/day)) + "d"
+	}
+	formatted := window.String()
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = strings.TrimSuffix(formatted, "0s")
+	}
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+
+	return formatted
+}
+
+func formatCadence(cadence *job.Cadence) string {
+	if cadence == nil {
+		return "-"
+	}
+	var parts []string
+	if cadence.Schedule != "" {
+		parts = append(parts, cadence.Schedule)
+	}
+	if cadence.MaxInterval > 0 {
+		parts = append(parts, "every "+formatWindow(time.Duration(cadence.MaxInterval)))
+	}
+	if cadence.Grace > 0 {
+		parts = append(parts, "grace "+formatWindow(time.Duration(cadence.Grace)))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func formatStatsDuration(duration job.Duration) string {
+	if duration == 0 {
+		return "-"
+	}
+
+	return time.Duration(duration).Round(time.Millisecond).String()
+}
+
+// jobsStatusCommand changes status of jobs given by names or selected by labels.
+func (b *CmdBuilder) jobsStatusCommand(use, short, done string,
+	action func(ctx context.Context, client *restapi.ClientHTTP, name string) error,
+) *cobra.Command {
+	var selector []string
+
+	statusCmd := &cobra.Command{
+		Use:               use + " [NAME...]",
+		Short:             short,
+		ValidArgsFunction: completeArgs(0, b.completeJobNames()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := b.client()
+			names, err := b.selectJobs(client, args, selector)
+			if err != nil {
+				return fmt.Errorf("%s action: %w", use, err)
+			}
+
+			// All jobs are tried, the command fails if any of them has failed.
+			failed := 0
+			for _, name := range names {
+				err := action(context.Background(), client, name)
+				switch {
+				case errors.Is(err, restapi.ErrStatusUnchanged):
+					glog.Infof("job `%s` is already %s", name, done)
+				case err != nil:
+					failed++
+					glog.Errorf("%s action: job `%s`: %v", use, name, err)
+				default:
+					glog.Infof("job `%s` %s", name, done)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%s action: %w: %d of %d jobs", use, errActionFailed, failed, len(names))
+			}
+
+			return nil
+		},
+	}
+
+	statusCmd.Flags().StringArrayVarP(&selector, "selector", "l", nil,
+		"Select jobs by label `name=value[,value]`, one of values must match, can be repeated")
+
+	return statusCmd
+}
+
+// selectJobs returns names or names of jobs which labels match the selector.
+func (b *CmdBuilder) selectJobs(client *restapi.ClientHTTP, names []string, selector []string) ([]string, error) {
+	switch {
+	case len(names) > 0 && len(selector) > 0:
+		return nil, fmt.Errorf("%w: job names and selector can't be used together", errInvalidArgument)
+	case len(names) > 0:
+		return names, nil
+	case len(selector) == 0:
+		return nil, fmt.Errorf("%w: job names or selector are required", errInvalidArgument)
+	}
+
+	parsed, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := client.JobsList(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("select jobs: %w", err)
+	}
+	selected := make([]string, 0, len(jobs))
+	for _, jb := range b.inNamespace(jobs) {
+		if parsed.Match(jb.Labels) == nil {
+			selected = append(selected, jb.Name)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: no jobs match the selector", errInvalidArgument)
+	}
+
+	return selected, nil
+}
+
+func parseEnv(env []string) (map[string]string, error) {
+	if len(env) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]string, len(env))
+	for _, variable := range env {
+		i := strings.IndexByte(variable, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("%w: env must be `NAME=value`: %s", errInvalidArgument, variable)
+		}
+		parsed[variable[:i]] = variable[i+1:]
+	}
+
+	return parsed, nil
+}
+
+func parseParams(params []string) []job.Param {
+	parsed := make([]job.Param, 0, len(params))
+	for _, param := range params {
+		i := strings.IndexByte(param, '=')
+		if i < 0 {
+			parsed = append(parsed, job.Param{Name: param})
+
+			continue
+		}
+		value := param[i+1:]
+		parsed = append(parsed, job.Param{Name: param[:i], Default: &value})
+	}
+
+	return parsed
+}
+
+// formatParams returns `name=default` of params with defaults and names of required ones.
+func formatParams(params []job.Param) string {
+	formatted := make([]string, 0, len(params))
+	for _, param := range params {
+		if param.Default == nil {
+			formatted = append(formatted, param.Name)
+
+			continue
+		}
+		formatted = append(formatted, param.Name+"="+*param.Default)
+	}
+
+	return strings.Join(formatted, ",")
+}
+
+// parseSelector parses `zone=eu,us` into the label and its allowed values, repeated labels are merged.
+func parseSelector(selector []string) (job.HostSelector, error) {
+	if len(selector) == 0 {
+		return nil, nil
+	}
+	parsed := make(job.HostSelector, len(selector))
+	for _, requirement := range selector {
+		i := strings.IndexByte(requirement, '=')
+		if i <= 0 || i == len(requirement)-1 {
+			return nil, fmt.Errorf("%w: selector must be `name=value[,value]`: %s", errInvalidArgument, requirement)
+		}
+		name := requirement[:i]
+		parsed[name] = append(parsed[name], strings.Split(requirement[i+1:], ",")...)
+	}
+
+	return parsed, nil
+}
+
+// formatSelector returns `name=value|value` requirements of the selector sorted by labels.
+func formatSelector(selector job.HostSelector) string {
+	requirements := make([]string, 0, len(selector))
+	for name, values := range selector {
+		requirements = append(requirements, name+"="+strings.Join(values, "|"))
+	}
+	sort.Strings(requirements)
+
+	return strings.Join(requirements, ",")
+}
+
+func parseLabels(labels []string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]string, len(labels))
+	for _, label := range labels {
+		i := strings.IndexByte(label, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("%w: label must be `name=value`: %s", errInvalidArgument, label)
+		}
+		parsed[label[:i]] = label[i+1:]
+	}
+
+	return parsed, nil
+}
+
+// readSandbox reads the sandbox from YAML or JSON file, field names are the same as in the API.
+func readSandbox(path string) (*job.Sandbox, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read sandbox: %w", err)
+	}
+	var generic interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("decode sandbox: %w", err)
+	}
+	sandbox := &job.Sandbox{}
+	if err := convertJSON(generic, sandbox); err != nil {
+		return nil, fmt.Errorf("decode sandbox: %w", err)
+	}
+
+	return sandbox, nil
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectJobsInNamespace(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jobs":[` +
+			`{"name":"backup","labels":{"namespace":"team","tier":"db"}},` +
+			`{"name":"other-backup","labels":{"namespace":"other","tier":"db"}},` +
+			`{"name":"cleanup","labels":{"namespace":"team"}}]}`))
+	}))
+	defer server.Close()
+
+	b := NewBuilder()
+	b.context.Namespace = "team"
+	client := restapi.NewClientHTTP(server.URL)
+
+	selected, err := b.selectJobs(client, nil, []string{"tier=db"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"backup"}, selected)
+
+	b.context.Namespace = "empty"
+	_, err = b.selectJobs(client, nil, []string{"tier=db"})
+	assert.True(t, errors.Is(err, errInvalidArgument), "unexpected error: %v", err)
+}
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	outputTable    = "table"
+	outputWide     = "wide"
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+	outputJSONPath = "jsonpath="
+)
+
+var errUndefinedOutput = errors.New("undefined output format")
+
+// column of the table output, wide columns are shown only with `-o wide`.
+type column struct {
+	name string
+	wide bool
+}
+
+// table is the table output of the command, cells of rows are in the order of columns.
+type table struct {
+	columns []column
+	rows    [][]string
+}
+
+func (t *table) append(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+// printer writes results of commands in the format given by the global `-o` flag.
+type printer struct {
+	format    string
+	template  *jsonpath.JSONPath
+	noHeaders bool
+}
+
+func newPrinter(output string, noHeaders bool) (*printer, error) {
+	p := &printer{format: output, noHeaders: noHeaders}
+	switch {
+	case output == outputTable, output == outputWide, output == outputJSON, output == outputYAML:
+	case strings.HasPrefix(output, outputJSONPath):
+		template := jsonpath.New("output")
+		if err := template.Parse(strings.TrimPrefix(output, outputJSONPath)); err != nil {
+			return nil, fmt.Errorf("output: %w", err)
+		}
+		p.format = outputJSONPath
+		p.template = template
+	default:
+		return nil, fmt.Errorf("%w: `%s`, available: table, wide, json, yaml, jsonpath=TEMPLATE", errUndefinedOutput, output)
+	}
+
+	return p, nil
+}
+
+// print writes data as JSON, YAML or by the JSONPath template, the table is built only for table outputs.
+func (p *printer) print(out io.Writer, data interface{}, buildTable func() *table) error {
+	switch p.format {
+	case outputJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(data); err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+	case outputYAML:
+		// Converting through json keeps field names the same as in the API.
+		var generic interface{}
+		if err := convertJSON(data, &generic); err != nil {
+			return err
+		}
+		if err := yaml.NewEncoder(out).Encode(generic); err != nil {
+			return fmt.Errorf("encode yaml: %w", err)
+		}
+	case outputJSONPath:
+		// Templates address fields by the names of the API, not of the go structs.
+		var generic interface{}
+		if err := convertJSON(data, &generic); err != nil {
+			return err
+		}
+		if err := p.template.Execute(out, generic); err != nil {
+			return fmt.Errorf("output: %w", err)
+		}
+	default:
+		p.render(out, buildTable())
+	}
+
+	return nil
+}
+
+func (p *printer) render(out io.Writer, t *table) {
+	wide := p.format == outputWide
+	header := make([]string, 0, len(t.columns))
+	for _, col := range t.columns {
+		if wide || !col.wide {
+			header = append(header, col.name)
+		}
+	}
+
+	writer := tablewriter.NewWriter(out)
+	if !p.noHeaders {
+		writer.SetHeader(header)
+	}
+	for _, row := range t.rows {
+		cells := make([]string, 0, len(header))
+		for i, col := range t.columns {
+			if wide || !col.wide {
+				cells = append(cells, row[i])
+			}
+		}
+		writer.Append(cells)
+	}
+
+	writer.Render()
+}
@@ -0,0 +1,108 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type printed struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func printedTable() *table {
+	t := &table{columns: []column{{name: "NAME"}, {name: "COUNT", wide: true}}}
+	t.append("first", "1")
+	t.append("second", "2")
+
+	return t
+}
+
+func TestNewPrinterInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := newPrinter("xml", false)
+	assert.True(t, errors.Is(err, errUndefinedOutput))
+
+	_, err = newPrinter("jsonpath={.name", false)
+	assert.Error(t, err)
+}
+
+func TestPrinter(t *testing.T) {
+	t.Parallel()
+	data := []printed{{Name: "first", Count: 1}, {Name: "second", Count: 2}}
+	testCases := []struct {
+		name      string
+		output    string
+		noHeaders bool
+		expected  []string
+		absent    []string
+	}{
+		{
+			name:     "table",
+			output:   outputTable,
+			expected: []string{"NAME", "first", "second"},
+			absent:   []string{"COUNT"},
+		},
+		{
+			name:     "wide",
+			output:   outputWide,
+			expected: []string{"NAME", "COUNT", "first", "2"},
+		},
+		{
+			name:      "no headers",
+			output:    outputTable,
+			noHeaders: true,
+			expected:  []string{"first", "second"},
+			absent:    []string{"NAME"},
+		},
+		{
+			name:     "json",
+			output:   outputJSON,
+			expected: []string{"[\n  {\n    \"name\": \"first\",\n    \"count\": 1\n  },"},
+		},
+		{
+			name:     "yaml",
+			output:   outputYAML,
+			expected: []string{"- count: 1\n  name: first\n- count: 2\n  name: second\n"},
+		},
+		{
+			name:     "jsonpath",
+			output:   `jsonpath={range [*]}{.name}{"\t"}{.count}{"\n"}{end}`,
+			expected: []string{"first\t1\nsecond\t2\n"},
+		},
+		{
+			name:     "jsonpath index",
+			output:   "jsonpath={[1].name}",
+			expected: []string{"second"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := newPrinter(testCase.output, testCase.noHeaders)
+			require.NoError(t, err)
+			out := &bytes.Buffer{}
+			require.NoError(t, p.print(out, data, printedTable))
+			for _, expected := range testCase.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+			for _, absent := range testCase.absent {
+				assert.False(t, strings.Contains(out.String(), absent), "unexpected %s in %s", absent, out.String())
+			}
+		})
+	}
+}
+
+func TestPrinterJSONPathMissing(t *testing.T) {
+	t.Parallel()
+	p, err := newPrinter("jsonpath={.unknown}", false)
+	require.NoError(t, err)
+	assert.Error(t, p.print(&bytes.Buffer{}, printed{Name: "first"}, printedTable))
+}
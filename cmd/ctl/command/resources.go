@@ -0,0 +1,64 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/spf13/cobra"
+)
+
+func (b *CmdBuilder) resourcesCommand() *cobra.Command {
+	resourcesCmd := &cobra.Command{
+		Use:   "resource",
+		Short: "Resources shared by jobs",
+	}
+
+	resourcesCmd.AddCommand(b.resourcesListCommand())
+
+	return resourcesCmd
+}
+
+func (b *CmdBuilder) resourcesListCommand() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Resources list with executions which hold them",
+		Aliases: []string{"l", "ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resources, err := b.client().ResourcesList(context.Background())
+			if err != nil {
+				return fmt.Errorf("resource list action: %w", err)
+			}
+
+			return b.print(cmd, resources, func() *table {
+				t := &table{columns: []column{{name: "Name"}, {name: "Held"}, {name: "Holders"}}}
+				for _, resource := range resources {
+					t.append(
+						resource.Name,
+						fmt.Sprintf("%d/%d", len(resource.Holders), resource.Capacity),
+						formatHolders(resource.Holders),
+					)
+				}
+
+				return t
+			})
+		},
+	}
+
+	return listCmd
+}
+
+// formatHolders returns `job@host (execution id)` of holders.
+func formatHolders(holders []job.ResourceHolder) string {
+	formatted := make([]string, 0, len(holders))
+	for _, holder := range holders {
+		name := holder.Job
+		if holder.Host != nil {
+			name += "@" + *holder.Host
+		}
+		formatted = append(formatted, name+" ("+holder.Execution.String()+")")
+	}
+
+	return strings.Join(formatted, ", ")
+}
@@ -1,11 +1,28 @@
 package command
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"os"
+
+	"github.com/antgubarev/jobs/internal/ctlconfig"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/spf13/cobra"
+)
 
 type CmdBuilder struct {
 	globalFlags struct {
+		config    string
+		context   string
 		serverURL string
+		token     string
+		namespace string
+		output    string
+		noHeaders bool
 	}
+	printer *printer
+	config  *ctlconfig.Config
+	// context is the resolved context of the server, it's empty for config commands.
+	context ctlconfig.Context
 }
 
 func NewBuilder() *CmdBuilder {
@@ -16,12 +33,119 @@ func (b *CmdBuilder) GetCommand() *cobra.Command {
 	rootCommand := &cobra.Command{
 		Use:   "jobsctl",
 		Short: "Jobs CLI",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Arguments are valid at this point, usage isn't helpful for errors of actions.
+			cmd.SilenceUsage = true
+
+			var err error
+			b.printer, err = newPrinter(b.globalFlags.output, b.globalFlags.noHeaders)
+			if err != nil {
+				return err
+			}
+			switch topLevelName(cmd) {
+			case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+				// Flags aren't parsed yet for completion, completion functions load the config themselves.
+				return nil
+			case "config":
+				b.config, err = ctlconfig.Load(b.globalFlags.config)
+
+				return err
+			}
+
+			return b.loadContext()
+		},
 	}
 
-	rootCommand.PersistentFlags().StringVarP(&b.globalFlags.serverURL, "server-url", "s",
-		"localhost:8080", "Api server addr. Default `http://localhost:8080`")
+	rootCommand.PersistentFlags().StringVar(&b.globalFlags.config, "config", ctlconfig.DefaultPath(),
+		"Config file with contexts of servers, $JOBSCTL_CONFIG")
+	rootCommand.PersistentFlags().StringVar(&b.globalFlags.context, "context", "",
+		"Context of the config file to use instead of the current one, $JOBSCTL_CONTEXT")
+	rootCommand.PersistentFlags().StringVarP(&b.globalFlags.serverURL, "server-url", "s", "",
+		"Api server URL, overrides the context's server, $JOBSCTL_SERVER. Default "+ctlconfig.DefaultServer)
+	rootCommand.PersistentFlags().StringVar(&b.globalFlags.token, "token", "",
+		"Api bearer token, required if server auth is enabled, overrides the context's token, $JOBSCTL_TOKEN")
+	rootCommand.PersistentFlags().StringVar(&b.globalFlags.namespace, "namespace", "",
+		"Namespace of jobs, overrides the context's namespace, $JOBSCTL_NAMESPACE")
+	rootCommand.PersistentFlags().StringVarP(&b.globalFlags.output, "output", "o", outputTable,
+		"Output format: `table`, `wide` (table with more columns), `json`, `yaml` or `jsonpath=TEMPLATE`")
+	rootCommand.PersistentFlags().BoolVar(&b.globalFlags.noHeaders, "no-headers", false,
+		"Don't print headers of table outputs")
+	registerFlagCompletion(rootCommand, "context", b.completeContextNames())
+	registerFlagCompletion(rootCommand, "output",
+		completeValues(outputTable, outputWide, outputJSON, outputYAML, outputJSONPath))
 
 	rootCommand.AddCommand(b.jobsCommand())
+	rootCommand.AddCommand(b.exportCommand())
+	rootCommand.AddCommand(b.importCommand())
+	rootCommand.AddCommand(b.auditCommand())
+	rootCommand.AddCommand(b.hostsCommand())
+	rootCommand.AddCommand(b.resourcesCommand())
+	rootCommand.AddCommand(b.executionsCommand())
+	rootCommand.AddCommand(b.topCommand())
+	rootCommand.AddCommand(b.configCommand())
 
 	return rootCommand
 }
+
+// topLevelName returns the name of the child of the root command which the command belongs to.
+func topLevelName(cmd *cobra.Command) string {
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+
+	return cmd.Name()
+}
+
+// loadContext reads the config file and resolves the context of the server.
+func (b *CmdBuilder) loadContext() error {
+	var err error
+	b.config, err = ctlconfig.Load(b.globalFlags.config)
+	if err != nil {
+		return err
+	}
+
+	return b.resolveContext()
+}
+
+// resolveContext applies the config file, JOBSCTL_* variables and flags to settings of the server in this order.
+// JOBS_TOKEN is used as before if the token isn't set otherwise.
+func (b *CmdBuilder) resolveContext() error {
+	context, err := b.config.Resolve(b.globalFlags.context, os.Environ())
+	if err != nil {
+		return fmt.Errorf("config %s: %w", b.globalFlags.config, err)
+	}
+	if b.globalFlags.serverURL != "" {
+		context.Server = ctlconfig.NormalizeServer(b.globalFlags.serverURL)
+	}
+	if b.globalFlags.token != "" {
+		context.Token = b.globalFlags.token
+	}
+	if b.globalFlags.namespace != "" {
+		context.Namespace = b.globalFlags.namespace
+	}
+	if context.Token == "" {
+		context.Token = os.Getenv("JOBS_TOKEN")
+	}
+	if _, err := context.TLS.Config(); err != nil {
+		return fmt.Errorf("connection settings: %w", err)
+	}
+	b.context = context
+
+	return nil
+}
+
+func (b *CmdBuilder) client() *restapi.ClientHTTP {
+	// The TLS config is checked when the context is resolved.
+	tlsConfig, _ := b.context.TLS.Config()
+
+	return restapi.NewClientHTTP(b.context.Server,
+		restapi.WithActor(restapi.DefaultActor()),
+		restapi.WithToken(b.context.Token),
+		restapi.WithTLSConfig(tlsConfig),
+	)
+}
+
+// print writes the result of the command to its stdout in the output format.
+func (b *CmdBuilder) print(cmd *cobra.Command, data interface{}, buildTable func() *table) error {
+	return b.printer.print(cmd.OutOrStdout(), data, buildTable)
+}
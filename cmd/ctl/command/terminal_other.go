@@ -0,0 +1,25 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package command
+
+import (
+	"errors"
+	"os"
+)
+
+var errTerminalUnsupported = errors.New("terminal dashboard isn't supported on this platform")
+
+type terminal struct{}
+
+func openTerminal() (*terminal, error) {
+	return nil, errTerminalUnsupported
+}
+
+func (t *terminal) size() (int, int) {
+	return 80, 24
+}
+
+func (t *terminal) restore() {}
+
+func notifyResize(chan<- os.Signal) {}
@@ -0,0 +1,61 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminal is stdin in raw mode and stdout switched to the alternate screen.
+type terminal struct {
+	fd    int
+	state unix.Termios
+}
+
+func openTerminal() (*terminal, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, fmt.Errorf("stdin isn't a terminal: %w", err)
+	}
+
+	raw := *state
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, fmt.Errorf("terminal raw mode: %w", err)
+	}
+	// The alternate screen keeps the shell's screen, the cursor is hidden.
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+
+	return &terminal{fd: fd, state: *state}, nil
+}
+
+func (t *terminal) size() (int, int) {
+	winsize, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || winsize.Col == 0 || winsize.Row == 0 {
+		return 80, 24
+	}
+
+	return int(winsize.Col), int(winsize.Row)
+}
+
+func (t *terminal) restore() {
+	fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+	_ = unix.IoctlSetTermios(t.fd, ioctlSetTermios, &t.state)
+}
+
+// notifyResize subscribes to changes of the terminal size.
+func notifyResize(resize chan<- os.Signal) {
+	signal.Notify(resize, syscall.SIGWINCH)
+}
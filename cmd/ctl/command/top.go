@@ -0,0 +1,244 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/agent"
+	"github.com/antgubarev/jobs/internal/dashboard"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/spf13/cobra"
+)
+
+// topActionTimeout limits polls and actions of the dashboard, so a slow server doesn't freeze it.
+const topActionTimeout = 5 * time.Second
+
+type topOptions struct {
+	interval    time.Duration
+	recent      int
+	agentSocket string
+	tail        int64
+}
+
+// topPoll is the result of the poll of the server and the local agent.
+type topPoll struct {
+	snapshot *dashboard.Snapshot
+	logs     string
+	err      error
+}
+
+func (b *CmdBuilder) topCommand() *cobra.Command {
+	var opts topOptions
+
+	topCmd := &cobra.Command{
+		Use:   "top",
+		Short: "Live dashboard of jobs and their running executions",
+		Long: "Live dashboard of jobs with their status, lock mode, running executions by hosts and the last result.\n" +
+			"Keys: ↑↓/jk select, tab switches between jobs and running executions of the selected job, " +
+			"p pauses or resumes the job, s stops the execution, l tails its logs, r refreshes, q quits.\n" +
+			"Logs are read from the agent on this host, so only logs of executions supervised by it are available.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.interval <= 0 {
+				return fmt.Errorf("top action: %w: interval must be positive", errInvalidArgument)
+			}
+			term, err := openTerminal()
+			if err != nil {
+				return fmt.Errorf("top action: %w", err)
+			}
+			defer term.restore()
+
+			return b.runTop(term, opts)
+		},
+	}
+
+	topCmd.Flags().DurationVar(&opts.interval, "interval", 2*time.Second, "Refresh interval")
+	topCmd.Flags().IntVar(&opts.recent, "recent", 500,
+		"Number of recent executions to find the last results of jobs in")
+	topCmd.Flags().StringVar(&opts.agentSocket, "agent-socket", agent.DefaultSocket(),
+		"Socket of the agent on this host to tail logs of executions")
+	topCmd.Flags().Int64Var(&opts.tail, "tail", 16*1024, "Bytes of logs to tail")
+
+	return topCmd
+}
+
+func (b *CmdBuilder) runTop(term *terminal, opts topOptions) error {
+	client := b.client()
+	board := dashboard.New("jobsctl top - " + b.context.Server)
+
+	keys := make(chan []string)
+	go readKeys(keys)
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	// One poll runs at once, the poll requested while it runs is done after it.
+	polls := make(chan topPoll, 1)
+	polling, pending := false, false
+	poll := func() {
+		if polling {
+			pending = true
+
+			return
+		}
+		polling, pending = true, false
+		logsOf := board.LogsOf()
+		go func() {
+			polls <- b.pollTop(client, opts, logsOf)
+		}()
+	}
+
+	poll()
+	for {
+		width, height := term.size()
+		drawTop(board.Render(width, height))
+
+		select {
+		case <-ticker.C:
+			poll()
+		case <-resize:
+		case result := <-polls:
+			polling = false
+			board.Update(result.snapshot, result.err)
+			if board.LogsOf() != nil {
+				board.SetLogs(result.logs)
+			}
+			if pending {
+				poll()
+			}
+		case pressed, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, key := range pressed {
+				action := board.HandleKey(key)
+				switch action.Kind {
+				case dashboard.Quit:
+					return nil
+				case dashboard.NoAction:
+					continue
+				case dashboard.Pause, dashboard.Resume, dashboard.Stop:
+					board.SetMessage(b.topAction(client, action))
+				case dashboard.Logs, dashboard.Refresh:
+				}
+				poll()
+			}
+		}
+	}
+}
+
+// pollTop reads jobs, running and recent executions and the tail of logs if logsOf isn't nil.
+func (b *CmdBuilder) pollTop(client *restapi.ClientHTTP, opts topOptions, logsOf *job.Execution) topPoll {
+	ctx, cancel := context.WithTimeout(context.Background(), topActionTimeout)
+	defer cancel()
+
+	jobs, err := client.JobsList(ctx)
+	if err != nil {
+		return topPoll{err: err}
+	}
+	running, err := client.ExecutionsList(ctx, job.ExecutionFilter{Status: job.StatusRunning})
+	if err != nil {
+		return topPoll{err: err}
+	}
+	recent, err := client.ExecutionsList(ctx, job.ExecutionFilter{Limit: opts.recent})
+	if err != nil {
+		return topPoll{err: err}
+	}
+	result := topPoll{snapshot: dashboard.NewSnapshot(jobs, running, recent, time.Now())}
+	if logsOf != nil {
+		result.logs, err = tailAgentLogs(ctx, opts, logsOf)
+		if err != nil {
+			result.logs = "Error: " + err.Error()
+		}
+	}
+
+	return result
+}
+
+// tailAgentLogs finds the execution in the local agent by its server id and returns the tail of its output.
+func tailAgentLogs(ctx context.Context, opts topOptions, execution *job.Execution) (string, error) {
+	agentClient := agent.NewClient(opts.agentSocket)
+	supervised, err := agentClient.List(ctx)
+	if err != nil {
+		return "", fmt.Errorf("agent on this host: %w", err)
+	}
+	for _, agentExecution := range supervised {
+		if agentExecution.ServerID == nil || *agentExecution.ServerID != execution.ID {
+			continue
+		}
+		var logs bytes.Buffer
+		if err := agentClient.Logs(ctx, agentExecution.ID, opts.tail, &logs); err != nil {
+			return "", fmt.Errorf("agent on this host: %w", err)
+		}
+
+		return logs.String(), nil
+	}
+
+	return "", fmt.Errorf("%w: the execution isn't supervised by the agent on this host", agent.ErrExecutionNotFound)
+}
+
+// topAction pauses, resumes the job or stops the execution, the result is shown in the status line.
+func (b *CmdBuilder) topAction(client *restapi.ClientHTTP, action dashboard.Action) string {
+	ctx, cancel := context.WithTimeout(context.Background(), topActionTimeout)
+	defer cancel()
+
+	var err error
+	switch action.Kind {
+	case dashboard.Pause:
+		err = client.JobPause(ctx, action.Job)
+	case dashboard.Resume:
+		err = client.JobResume(ctx, action.Job)
+	case dashboard.Stop:
+		_, err = client.ExecutionStop(ctx, action.Execution)
+	default:
+		return ""
+	}
+
+	switch {
+	case errors.Is(err, restapi.ErrStatusUnchanged):
+		return fmt.Sprintf("job `%s` is unchanged", action.Job)
+	case err != nil:
+		return "Error: " + err.Error()
+	case action.Kind == dashboard.Pause:
+		return fmt.Sprintf("job `%s` paused", action.Job)
+	case action.Kind == dashboard.Resume:
+		return fmt.Sprintf("job `%s` resumed", action.Job)
+	default:
+		return fmt.Sprintf("stop of execution `%s` is requested", action.Execution)
+	}
+}
+
+// readKeys sends keys read from stdin until it's closed.
+func readKeys(keys chan<- []string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		keys <- dashboard.ParseKeys(buf[:n])
+	}
+}
+
+// drawTop redraws the screen from the top left corner, the rest of each line and the screen is cleared.
+func drawTop(lines []string) {
+	var screen strings.Builder
+	screen.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			screen.WriteString("\r\n")
+		}
+		screen.WriteString(line)
+		screen.WriteString("\x1b[K")
+	}
+	screen.WriteString("\x1b[J")
+	fmt.Fprint(os.Stdout, screen.String())
+}
@@ -0,0 +1,212 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatJSON   = "json"
+	formatYAML   = "yaml"
+	formatNDJSON = "ndjson"
+)
+
+var (
+	errUndefinedFormat = errors.New("undefined format")
+	errInvalidArgument = errors.New("invalid argument")
+	errActionFailed    = errors.New("action failed")
+)
+
+func (b *CmdBuilder) exportCommand() *cobra.Command {
+	var (
+		format      string
+		file        string
+		withHistory bool
+	)
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export jobs and execution history",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// `-o json|yaml` is accepted instead of `--format`.
+			if !cmd.Flags().Changed("format") && (b.printer.format == outputJSON || b.printer.format == outputYAML) {
+				format = b.printer.format
+			}
+
+			client := b.client()
+			dump, err := client.Export(context.Background(), withHistory || format == formatNDJSON)
+			if err != nil {
+				return fmt.Errorf("export action: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if file != "" {
+				fileOut, err := os.Create(file)
+				if err != nil {
+					return fmt.Errorf("export action: %w", err)
+				}
+				defer fileOut.Close()
+				out = fileOut
+			}
+
+			if err := writeDump(out, dump, format); err != nil {
+				return fmt.Errorf("export action: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&format, "format", "f", formatJSON,
+		"Output format. Available value: `json`(default), `yaml`, `ndjson` (history only, one execution per line)")
+	exportCmd.Flags().StringVar(&file, "file", "", "Output file. Default stdout")
+	exportCmd.Flags().BoolVar(&withHistory, "history", false, "Include execution history")
+	registerFlagCompletion(exportCmd, "format", completeValues(formatJSON, formatYAML, formatNDJSON))
+
+	return exportCmd
+}
+
+func (b *CmdBuilder) importCommand() *cobra.Command {
+	var (
+		format   string
+		strategy string
+	)
+
+	importCmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import jobs and execution history",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conflictStrategy, err := job.ParseConflictStrategy(strategy)
+			if err != nil {
+				return fmt.Errorf("import action: %w", err)
+			}
+
+			in := os.Stdin
+			if len(args) == 1 && args[0] != "-" {
+				in, err = os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("import action: %w", err)
+				}
+				defer in.Close()
+			}
+
+			dump, err := readDump(in, format)
+			if err != nil {
+				return fmt.Errorf("import action: %w", err)
+			}
+
+			client := b.client()
+			result, err := client.Import(context.Background(), dump, conflictStrategy)
+			if err != nil {
+				return fmt.Errorf("import action: %w", err)
+			}
+
+			glog.Infof("imported: created %d, overwritten %d, skipped %d \n",
+				result.Created, result.Overwritten, result.Skipped)
+
+			return nil
+		},
+	}
+
+	importCmd.Flags().StringVarP(&format, "format", "f", formatJSON,
+		"Input format. Available value: `json`(default), `yaml`, `ndjson` (history only, one execution per line)")
+	importCmd.Flags().StringVar(&strategy, "strategy", string(job.ConflictFail),
+		"Conflict strategy. Available value: `fail`(default), `skip`, `overwrite`")
+	registerFlagCompletion(importCmd, "format", completeValues(formatJSON, formatYAML, formatNDJSON))
+	registerFlagCompletion(importCmd, "strategy", completeValues(
+		string(job.ConflictFail), string(job.ConflictSkip), string(job.ConflictOverwrite)))
+
+	return importCmd
+}
+
+func writeDump(out io.Writer, dump *job.Dump, format string) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(dump); err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+	case formatYAML:
+		// Converting through json keeps field names the same as in the API.
+		var generic interface{}
+		if err := convertJSON(dump, &generic); err != nil {
+			return err
+		}
+		if err := yaml.NewEncoder(out).Encode(generic); err != nil {
+			return fmt.Errorf("encode yaml: %w", err)
+		}
+	case formatNDJSON:
+		encoder := json.NewEncoder(out)
+		for i := range dump.Executions {
+			if err := encoder.Encode(dump.Executions[i]); err != nil {
+				return fmt.Errorf("encode ndjson: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUndefinedFormat, format)
+	}
+
+	return nil
+}
+
+func readDump(in io.Reader, format string) (*job.Dump, error) {
+	dump := &job.Dump{}
+
+	switch format {
+	case formatJSON:
+		if err := json.NewDecoder(in).Decode(dump); err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+	case formatYAML:
+		data, err := ioutil.ReadAll(in)
+		if err != nil {
+			return nil, fmt.Errorf("read yaml: %w", err)
+		}
+		var generic interface{}
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, fmt.Errorf("decode yaml: %w", err)
+		}
+		if err := convertJSON(generic, dump); err != nil {
+			return nil, err
+		}
+	case formatNDJSON:
+		// The decoder isn't limited by the line length, commands and messages may be long.
+		decoder := json.NewDecoder(in)
+		for decoder.More() {
+			var execution job.Execution
+			if err := decoder.Decode(&execution); err != nil {
+				return nil, fmt.Errorf("decode ndjson: %w", err)
+			}
+			dump.Executions = append(dump.Executions, execution)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", errUndefinedFormat, format)
+	}
+
+	return dump, nil
+}
+
+func convertJSON(from interface{}, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return fmt.Errorf("convert marshal: %w", err)
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		return fmt.Errorf("convert unmarshal: %w", err)
+	}
+
+	return nil
+}
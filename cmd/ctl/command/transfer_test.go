@@ -0,0 +1,35 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDumpNDJSON(t *testing.T) {
+	t.Parallel()
+	// The line is longer than the default limit of bufio.Scanner.
+	long := job.NewRunningExecution("backup")
+	long.SetCommand(strings.Repeat("x", 100*1024))
+	short := job.NewRunningExecution("cleanup")
+
+	in := &bytes.Buffer{}
+	encoder := json.NewEncoder(in)
+	require.NoError(t, encoder.Encode(long))
+	in.WriteString("\n")
+	require.NoError(t, encoder.Encode(short))
+
+	dump, err := readDump(in, formatNDJSON)
+	require.NoError(t, err)
+	require.Len(t, dump.Executions, 2)
+	assert.Equal(t, long.Command, dump.Executions[0].Command)
+	assert.Equal(t, short.ID, dump.Executions[1].ID)
+
+	_, err = readDump(strings.NewReader("{\"job\":"), formatNDJSON)
+	assert.Error(t, err)
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
+	"os"
+
 	"github.com/antgubarev/jobs/cmd/ctl/command"
-	"github.com/golang/glog"
 )
 
 func main() {
 	rootCmd := command.NewBuilder().GetCommand()
 
+	// The error is printed by the command.
 	if err := rootCmd.Execute(); err != nil {
-		glog.Fatalf("run command: %v", err)
+		os.Exit(1)
 	}
 }
@@ -3,10 +3,13 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
+	"github.com/antgubarev/jobs/internal/cliflag"
 	"github.com/antgubarev/jobs/internal/executor"
 	"github.com/antgubarev/jobs/internal/restapi"
 	"github.com/urfave/cli/v2"
@@ -16,6 +19,40 @@ const usageText = "job-exec [global options] -- [command] [args]"
 
 var errInvalidArgument = errors.New("invalid argument")
 
+// waitValue is `--wait` (no limit) or `--wait=timeout` flag.
+type waitValue struct {
+	lockWait executor.LockWait
+}
+
+func (w *waitValue) IsBoolFlag() bool {
+	return true
+}
+
+func (w *waitValue) Set(value string) error {
+	switch value {
+	case "true":
+		w.lockWait = executor.LockWait{Enabled: true}
+	case "false":
+		w.lockWait = executor.LockWait{}
+	default:
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: wait: %v", errInvalidArgument, err)
+		}
+		w.lockWait = executor.LockWait{Enabled: true, Timeout: timeout}
+	}
+
+	return nil
+}
+
+func (w *waitValue) String() string {
+	if !w.lockWait.Enabled {
+		return ""
+	}
+
+	return w.lockWait.Timeout.String()
+}
+
 func action(ctx *cli.Context) error {
 	var commandArgs []string
 	for i, arg := range os.Args {
@@ -24,26 +61,81 @@ func action(ctx *cli.Context) error {
 		}
 	}
 	if len(commandArgs) == 0 {
-		return fmt.Errorf("%w: `command` is required, usage: %s", errInvalidArgument, usageText)
+		return cli.Exit(fmt.Errorf("%w: `command` is required, usage: %s", errInvalidArgument, usageText),
+			executor.ExitUsage)
+	}
+
+	client := restapi.NewClientHTTP(ctx.String("server-url"),
+		restapi.WithActor(restapi.DefaultActor()),
+		restapi.WithToken(ctx.String("token")),
+	)
+	policy, err := executor.ParseUnavailablePolicy(ctx.String("on-server-unavailable"))
+	if err != nil {
+		return cli.Exit(fmt.Errorf("%w: %v", errInvalidArgument, err), executor.ExitUsage)
+	}
+	backoff := executor.DefaultBackoff
+	backoff.Initial = ctx.Duration("retry-backoff")
+	backoff.Budget = ctx.Duration("retry-budget")
+	if backoff.Initial <= 0 || backoff.Budget < 0 {
+		return cli.Exit(fmt.Errorf("%w: retry-backoff must be positive and retry-budget must not be negative",
+			errInvalidArgument), executor.ExitUsage)
+	}
+
+	var lockWait executor.LockWait
+	if wait, ok := ctx.Generic("wait").(*waitValue); ok {
+		lockWait = wait.lockWait
+	}
+
+	shim, err := os.Executable()
+	if err != nil {
+		return cli.Exit(fmt.Errorf("job-exec: %w", err), executor.ExitError)
 	}
 
-	client := restapi.NewClientHTTP(ctx.String("server-url"))
-	exectr := executor.NewExecutor(client, executor.WithOutFile(os.Stdout), executor.WithErrFile(os.Stderr))
+	exectr := executor.NewExecutor(client,
+		executor.WithOutFile(os.Stdout),
+		executor.WithErrFile(os.Stderr),
+		executor.WithGracePeriod(ctx.Duration("grace-period")),
+		executor.WithUnavailablePolicy(policy, backoff),
+		executor.WithBuffer(executor.NewBuffer(ctx.String("buffer-dir"))),
+		executor.WithLockWait(lockWait),
+		executor.WithUsageSampling(ctx.Duration("sample-interval")),
+		executor.WithShim(shim),
+		executor.WithServerURL(ctx.String("server-url")),
+		executor.WithParams(cliflag.Map(ctx, "param")),
+		executor.WithHostLabels(cliflag.Map(ctx, "label")),
+		executor.WithCgroupRoot(ctx.String("cgroup-root")),
+		executor.WithStopCheck(ctx.Duration("stop-check-interval")),
+	)
 
+	// The exit code is the command's one if it has been run, see executor.Exit* for other codes.
 	code, err := exectr.StartAndWatch(context.Background(), ctx.String("job-name"), commandArgs)
 	if err != nil {
-		return fmt.Errorf("process hasn't started: %w", err)
+		return cli.Exit(fmt.Errorf("job-exec: %w", err), code)
 	}
 	if code != executor.ExitOK {
-		return fmt.Errorf("process is corrupted: %w", err)
+		return cli.Exit("", code)
 	}
 
 	return nil
 }
 
 func main() {
+	// job-exec re-executes itself as the shim to apply the job's sandbox right before exec of the command.
+	if len(os.Args) > 1 && os.Args[1] == executor.ShimCommand {
+		os.Exit(executor.RunShim(os.Args[2:]))
+	}
+
+	// glog is used by the executor package, job-exec logs only to stderr.
+	_ = flag.Set("logtostderr", "true")
+	_ = flag.CommandLine.Parse(nil)
+
 	app := &cli.App{
-		Usage:     "Starts new process (command after `--`) and register to the server.",
+		Usage: "Starts new process (command after `--`) and register to the server.",
+		Description: "Exits with the command's exit code (128+signal if it was killed by a signal). " +
+			"If the command hasn't been run: 64 - invalid arguments, 69 - server is unavailable, " +
+			"75 - job is locked (already running), 77 - host doesn't match the job's host selector, " +
+			"125 - refused by server, 126 - command can't be executed, " +
+			"127 - command not found.",
 		Name:      "job-exec",
 		UsageText: usageText,
 		Flags: []cli.Flag{
@@ -59,6 +151,71 @@ func main() {
 				Value:   "http://localhost:8080",
 				Usage:   "Address of api server. Default `http://localhost:8080`",
 			},
+			&cli.GenericFlag{
+				Name:    "param",
+				Aliases: []string{"p"},
+				Value:   &cliflag.MapValue{},
+				Usage: "Override the job's param `name=value`, can be repeated. Params are passed to the command " +
+					"as JOBS_PARAM_<NAME> variables",
+			},
+			&cli.GenericFlag{
+				Name:  "label",
+				Value: &cliflag.MapValue{},
+				Usage: "Label `name=value` of the host, can be repeated. Labels are matched with the job's host selector",
+			},
+			&cli.GenericFlag{
+				Name:  "wait",
+				Value: &waitValue{},
+				Usage: "Wait for the lock in the server queue instead of exiting with 75, " +
+					"`--wait` waits without limit, `--wait=10m` up to the timeout",
+			},
+			&cli.DurationFlag{
+				Name:  "grace-period",
+				Value: 10 * time.Second,
+				Usage: "How long the command may shut down after SIGINT/SIGTERM before its process group is killed",
+			},
+			&cli.DurationFlag{
+				Name:  "stop-check-interval",
+				Value: 10 * time.Second,
+				Usage: "Poll the server for the stop request (`jobsctl execution stop`) with the interval, " +
+					"the stopped command gets SIGTERM and the grace period, 0 disables polling",
+			},
+			&cli.DurationFlag{
+				Name: "sample-interval",
+				Usage: "Sample RSS of the command's process group with the interval to report its peak (Linux only), " +
+					"CPU time, max RSS and block I/O are reported at exit anyway",
+			},
+			&cli.StringFlag{
+				Name:  "cgroup-root",
+				Value: executor.DefaultCgroupRoot,
+				Usage: "cgroup v2 directory for cgroups of executions with cgroup limits in the job's sandbox",
+			},
+			&cli.StringFlag{
+				Name:  "on-server-unavailable",
+				Value: string(executor.UnavailableFail),
+				Usage: "What to do if the server can't be reached at start: `fail` (don't run the command), " +
+					"`run` (run without the lock, report to the server later) or `retry` (retry with backoff, then fail)",
+			},
+			&cli.DurationFlag{
+				Name:  "retry-budget",
+				Value: executor.DefaultBackoff.Budget,
+				Usage: "How long to retry the start with `--on-server-unavailable=retry`",
+			},
+			&cli.DurationFlag{
+				Name:  "retry-backoff",
+				Value: executor.DefaultBackoff.Initial,
+				Usage: "Initial delay between retries, must be positive, it's doubled after each retry up to 30s",
+			},
+			&cli.StringFlag{
+				Name:  "buffer-dir",
+				Value: executor.DefaultBufferDir(),
+				Usage: "Where executions are kept while the server is unavailable until they are sent",
+			},
+			&cli.StringFlag{
+				Name:    "token",
+				EnvVars: []string{"JOBS_TOKEN"},
+				Usage:   "Api bearer token, required if server auth is enabled",
+			},
 		},
 		Action: action,
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -11,39 +12,92 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/antgubarev/jobs/internal/audit"
 	"github.com/antgubarev/jobs/internal/boltdb"
+	"github.com/antgubarev/jobs/internal/config"
+	"github.com/antgubarev/jobs/internal/host"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/memory"
 	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
 )
 
-const TIMEOUT = 5
+var errUnknownCommand = errors.New("unknown command")
 
 func main() {
 	flags := parseFlags()
 
-	ctx, cancel := context.WithTimeout(context.Background(), TIMEOUT*time.Second)
-	boltDB, err := boltdb.NewBoltDB(flags.dbPath)
+	cfg, err := loadConfig(flags)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	defer func() {
-		boltDB.Close()
-		cancel()
-	}()
 
-	srv := restapi.NewServer(flags.listen, boltDB)
+	if args := flag.Args(); len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if flags.compact {
+		before, after, err := boltdb.Compact(cfg.Storage.Path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Database compacted: %d -> %d bytes \n", before, after)
+
+		return
+	}
+
+	if err := setLogLevel(cfg.LogLevel); err != nil {
+		log.Fatal(err)
+	}
+
+	storages, closeStorage, err := newStorages(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeStorage()
+
+	storages.Resources = job.NewResources(storages.Job, storages.Execution, cfg.Resources)
+	storages.Controller = job.NewController(storages.Execution)
+	storages.Controller.SetResources(storages.Resources)
+	if cfg.Reaper.OverdueCheckInterval > 0 {
+		storages.Watchdog = newWatchdog(storages)
+	}
+	srv := restapi.NewServer(cfg.Listen, storages, restapi.WithAuth(cfg.Auth.Tokens))
+
+	pruneCtx, stopPrune := context.WithCancel(context.Background())
+	defer stopPrune()
+	startPruner(pruneCtx, storages, cfg)
+	startHostChecker(pruneCtx, storages, cfg)
+	if storages.Watchdog != nil {
+		go storages.Watchdog.Run(pruneCtx, cfg.Reaper.OverdueCheckInterval)
+	}
 
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
-			log.Printf("listen: %s\n", err)
+		var err error
+		if cfg.TLS.Enabled() {
+			err = srv.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen: %s\n", err)
 		}
 	}()
-	log.Printf("Start listening in %s \n", flags.listen)
+	log.Printf("Start listening in %s \n", cfg.Listen)
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
 
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown: %s \n", err.Error())
 
@@ -53,19 +107,223 @@ func main() {
 	log.Println("Server has exited")
 }
 
+func runCommand(cfg *config.Config, args []string) error {
+	if len(args) == 2 && args[0] == "config" && args[1] == "print" {
+		data, err := cfg.Print()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("config print: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: %v, available: `config print`", errUnknownCommand, args)
+}
+
+// loadConfig applies defaults, config file, environment and explicitly set flags in this order.
+func loadConfig(flags *runFlags) (*config.Config, error) {
+	cfg := config.Default()
+
+	if flags.config != "" {
+		if err := cfg.LoadFile(flags.config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.LoadEnv(os.Environ()); err != nil {
+		return nil, err
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			cfg.Listen = flags.listen
+		case "storage":
+			cfg.Storage.Type = flags.storage
+		case "dbPath":
+			cfg.Storage.Path = flags.dbPath
+		case "logLevel":
+			cfg.LogLevel = flags.logLevel
+		case "keepLast":
+			cfg.Retention.KeepLast = flags.keepLast
+		case "keepFor":
+			cfg.Retention.KeepFor = flags.keepFor
+		case "keepFailedFor":
+			cfg.Retention.KeepFailedFor = flags.keepFailedFor
+		case "pruneInterval":
+			cfg.Reaper.PruneInterval = flags.pruneInterval
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func setLogLevel(level string) error {
+	threshold := map[string]string{
+		config.LogLevelDebug: "INFO",
+		config.LogLevelInfo:  "INFO",
+		config.LogLevelWarn:  "WARNING",
+		config.LogLevelError: "ERROR",
+	}[level]
+	if err := flag.Set("stderrthreshold", threshold); err != nil {
+		return fmt.Errorf("set log level: %w", err)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	if level == config.LogLevelDebug {
+		gin.SetMode(gin.DebugMode)
+		if err := flag.Set("v", "2"); err != nil {
+			return fmt.Errorf("set log level: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func newStorages(cfg *config.Config) (restapi.Storages, func(), error) {
+	if cfg.Storage.Type == config.StorageMemory {
+		return restapi.Storages{
+			Job:        memory.NewJobStorage(),
+			Execution:  memory.NewExecutionStorage(),
+			Audit:      memory.NewAuditStorage(),
+			Rejections: memory.NewRejectionStorage(),
+			Hosts:      host.NewRegistry(),
+		}, func() {}, nil
+	}
+
+	boltDB, err := boltdb.NewBoltDB(cfg.Storage.Path)
+	if err != nil {
+		return restapi.Storages{}, nil, fmt.Errorf("open storage: %w", err)
+	}
+	jobStorage, err := boltdb.NewJobStorage(boltDB)
+	if err != nil {
+		return restapi.Storages{}, nil, fmt.Errorf("open storage: %w", err)
+	}
+	executionStorage, err := boltdb.NewExecutionStorage(boltDB)
+	if err != nil {
+		return restapi.Storages{}, nil, fmt.Errorf("open storage: %w", err)
+	}
+	auditStorage, err := boltdb.NewAuditStorage(boltDB)
+	if err != nil {
+		return restapi.Storages{}, nil, fmt.Errorf("open storage: %w", err)
+	}
+	rejectionStorage, err := boltdb.NewRejectionStorage(boltDB)
+	if err != nil {
+		return restapi.Storages{}, nil, fmt.Errorf("open storage: %w", err)
+	}
+	hostStorage, err := boltdb.NewHostStorage(boltDB)
+	if err != nil {
+		return restapi.Storages{}, nil, fmt.Errorf("open storage: %w", err)
+	}
+	hosts, err := host.NewStoredRegistry(hostStorage, time.Now())
+	if err != nil {
+		return restapi.Storages{}, nil, fmt.Errorf("open storage: %w", err)
+	}
+
+	return restapi.Storages{
+		Job:        jobStorage,
+		Execution:  executionStorage,
+		Audit:      auditStorage,
+		Rejections: rejectionStorage,
+		Hosts:      hosts,
+	}, func() { boltDB.Close() }, nil
+}
+
+func startPruner(ctx context.Context, storages restapi.Storages, cfg *config.Config) {
+	if cfg.Reaper.PruneInterval <= 0 {
+		return
+	}
+
+	pruner := job.NewPruner(storages.Job, storages.Execution, job.RetentionPolicy{
+		KeepLast:      cfg.Retention.KeepLast,
+		KeepFor:       job.Duration(cfg.Retention.KeepFor),
+		KeepFailedFor: job.Duration(cfg.Retention.KeepFailedFor),
+	})
+	pruner.SetRejections(storages.Rejections)
+	go pruner.Run(ctx, cfg.Reaper.PruneInterval)
+}
+
+// startHostChecker marks hosts with missed heartbeats as unhealthy and their running executions as lost.
+func startHostChecker(ctx context.Context, storages restapi.Storages, cfg *config.Config) {
+	if cfg.Reaper.HostCheckInterval <= 0 || cfg.Hosts.HeartbeatTimeout <= 0 {
+		return
+	}
+
+	checker := host.NewChecker(storages.Hosts, cfg.Hosts.HeartbeatTimeout, func(unhealthy host.Host) {
+		lost, err := storages.Controller.LoseHostExecutions(unhealthy.Name, time.Now())
+		if err != nil {
+			glog.Errorf("host check: %v", err)
+		}
+		if lost > 0 {
+			glog.Warningf("host check: %d executions of %s are lost", lost, unhealthy.Name)
+		}
+	})
+	go checker.Run(ctx, cfg.Reaper.HostCheckInterval)
+}
+
+// newWatchdog creates the watchdog of overdue jobs, jobs becoming overdue and running again are written
+// to the audit log as `job.overdue` and `job.recovered` of the `watchdog` actor.
+func newWatchdog(storages restapi.Storages) *job.Watchdog {
+	return job.NewWatchdog(storages.Job, storages.Execution, func(jobName string, overdue *job.Overdue) {
+		record := audit.NewRecord("job.recovered")
+		if overdue != nil {
+			record = audit.NewRecord("job.overdue")
+			record.Changes = append(record.Changes, audit.Change{Path: "dueAt", To: overdue.DueAt})
+		}
+		record.Actor = "watchdog"
+		record.Target = jobName
+		record.Result = audit.ResultOK
+		if err := storages.Audit.Append(record); err != nil {
+			glog.Errorf("watchdog: %v", err)
+		}
+	})
+}
+
 type runFlags struct {
-	listen string
-	dbPath string
+	config        string
+	listen        string
+	storage       string
+	dbPath        string
+	logLevel      string
+	compact       bool
+	keepLast      int
+	keepFor       time.Duration
+	keepFailedFor time.Duration
+	pruneInterval time.Duration
 }
 
 func parseFlags() *runFlags {
-	result := runFlags{
-		listen: ":8080",
-		dbPath: "./data.db",
+	result := runFlags{}
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [config print]\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nEnvironment variables override the config file, flags override both:\n")
+		for _, name := range config.Default().EnvNames() {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %s\n", name)
+		}
 	}
 
+	flag.StringVar(&result.config, "config", os.Getenv("JOBS_CONFIG"),
+		"config file (.yaml, .yml or .toml). default $JOBS_CONFIG")
 	flag.StringVar(&result.listen, "listen", ":8080", "listen api host port. default :8080")
+	flag.StringVar(&result.storage, "storage", config.StorageBolt,
+		"storage type: `bolt` (data file) or `memory` (data is lost on exit). default bolt")
 	flag.StringVar(&result.dbPath, "dbPath", "./data.db", "data file. default ./data.db")
+	flag.StringVar(&result.logLevel, "logLevel", config.LogLevelInfo, "log level: debug, info, warn or error. default info")
+	flag.BoolVar(&result.compact, "compact", false, "compact data file and exit, server must be stopped")
+	flag.IntVar(&result.keepLast, "keepLast", 0, "keep last N finished executions per job. default 0 (unlimited)")
+	flag.DurationVar(&result.keepFor, "keepFor", 0, "keep finished executions for duration. default 0 (unlimited)")
+	flag.DurationVar(&result.keepFailedFor, "keepFailedFor", 0,
+		"keep failed executions for duration regardless of other limits. default 0 (use other limits)")
+	flag.DurationVar(&result.pruneInterval, "pruneInterval", time.Hour, "history pruning interval, 0 disables pruning. default 1h")
 	flag.Parse()
 
 	return &result
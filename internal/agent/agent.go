@@ -0,0 +1,484 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/executor"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrExecutionNotFound = errors.New("execution not found")
+	ErrNotRunning        = errors.New("execution isn't running")
+	ErrCapacityExceeded  = errors.New("capacity of the host is exceeded")
+	errInvalidArguments  = errors.New("invalid arguments")
+)
+
+const (
+	// DefaultRegisterInterval is how often the agent registers the host again.
+	DefaultRegisterInterval = 30 * time.Second
+	// maxFinished is the number of finished executions kept with their logs.
+	maxFinished     = 100
+	shutdownTimeout = 5 * time.Second
+	logFileExt      = ".log"
+)
+
+// DefaultReconnectBackoff is used to register the host again after the server has been unavailable.
+var DefaultReconnectBackoff = executor.Backoff{Initial: time.Second, Max: time.Minute}
+
+// DefaultSocket is in the user's runtime dir, or in the temp dir if there is no one.
+func DefaultSocket() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "jobs-agent.sock")
+}
+
+// DefaultLogDir is in the user's cache dir, or in the temp dir if there is no one.
+func DefaultLogDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "jobs", "logs")
+}
+
+// Execution is the command supervised by the agent. ID is local, the server knows the execution by its own id.
+type Execution struct {
+	ID uuid.UUID `json:"id"`
+	// ServerID is the id of the execution on the server, it's known after the start is registered.
+	ServerID   *uuid.UUID          `json:"serverId,omitempty"`
+	Job        string              `json:"job"`
+	Command    []string            `json:"command"`
+	Pid        *int                `json:"pid,omitempty"`
+	Status     job.ExecutionStatus `json:"status"`
+	StartedAt  time.Time           `json:"startedAt"`
+	FinishedAt *time.Time          `json:"finishedAt,omitempty"`
+	ExitCode   *int                `json:"exitCode,omitempty"`
+	Msg        string              `json:"msg,omitempty"`
+}
+
+type supervised struct {
+	Execution
+	// signals are forwarded to the command by its executor instead of the agent's own signals.
+	signals chan os.Signal
+	// cancel stops the execution: the pending start is abandoned, the command is terminated.
+	cancel  context.CancelFunc
+	done    chan struct{}
+	logPath string
+}
+
+type options struct {
+	socket           string
+	logDir           string
+	hostName         string
+	labels           map[string]string
+	version          string
+	capacity         int
+	registerInterval time.Duration
+	backoff          executor.Backoff
+	executorOptions  []executor.Option
+}
+
+type Option func(*options)
+
+func WithSocket(path string) Option {
+	return func(o *options) {
+		o.socket = path
+	}
+}
+
+// WithLogDir sets where stdout and stderr of executions are written.
+func WithLogDir(dir string) Option {
+	return func(o *options) {
+		o.logDir = dir
+	}
+}
+
+// WithHost sets the host name and labels registered on the server.
+func WithHost(name string, labels map[string]string) Option {
+	return func(o *options) {
+		o.hostName = name
+		o.labels = labels
+	}
+}
+
+func WithVersion(version string) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// WithCapacity limits the number of executions run at once, zero means no limit.
+func WithCapacity(capacity int) Option {
+	return func(o *options) {
+		o.capacity = capacity
+	}
+}
+
+// WithRegistration sets how often the host is registered and the backoff of reconnects.
+func WithRegistration(interval time.Duration, backoff executor.Backoff) Option {
+	return func(o *options) {
+		o.registerInterval = interval
+		o.backoff = backoff
+	}
+}
+
+// WithExecutorOptions are applied to executors of all executions, outputs and signals are set by the agent.
+func WithExecutorOptions(opts ...executor.Option) Option {
+	return func(o *options) {
+		o.executorOptions = opts
+	}
+}
+
+// Agent is the long-running executor: it supervises many executions, registers the host on the server
+// and serves the local control API on the unix socket.
+type Agent struct {
+	options
+	client restapi.Client
+
+	mu         sync.Mutex
+	executions map[uuid.UUID]*supervised
+	wg         sync.WaitGroup
+}
+
+func NewAgent(client restapi.Client, opts ...Option) *Agent {
+	agent := &Agent{
+		client:     client,
+		executions: make(map[uuid.UUID]*supervised),
+	}
+	agent.socket = DefaultSocket()
+	agent.logDir = DefaultLogDir()
+	agent.registerInterval = DefaultRegisterInterval
+	agent.backoff = DefaultReconnectBackoff
+	if hostname, err := os.Hostname(); err == nil {
+		agent.hostName = hostname
+	}
+
+	for _, optFunc := range opts {
+		optFunc(&agent.options)
+	}
+
+	return agent
+}
+
+// Run serves the socket and registers the host until ctx is done, then running executions are terminated.
+func (a *Agent) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := os.MkdirAll(a.logDir, 0o700); err != nil {
+		return fmt.Errorf("agent run: %w", err)
+	}
+	listener, err := listen(a.socket)
+	if err != nil {
+		return fmt.Errorf("agent run: %w", err)
+	}
+
+	srv := &http.Server{Handler: newRouter(a)}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+	go a.register(ctx)
+	glog.Infof("agent is listening on %s", a.socket)
+
+	select {
+	case <-ctx.Done():
+	case err = <-serveErr:
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	if shutdownErr := srv.Shutdown(shutdownCtx); shutdownErr != nil {
+		glog.Warningf("agent shutdown: %v", shutdownErr)
+	}
+	// Results of stopped executions are reported before exit.
+	a.terminateAll()
+	a.wg.Wait()
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("agent serve: %w", err)
+	}
+
+	return nil
+}
+
+// listen removes the socket file left by the previous agent, the socket is accessible only by the owner.
+func listen(socket string) (net.Listener, error) {
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("remove stale socket: %w", err)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	if err := os.Chmod(socket, 0o600); err != nil {
+		listener.Close()
+
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+
+	return listener, nil
+}
+
+// Start runs the command of the job under supervision. The execution is registered on the server
+// by its executor, the lock refusal and other start errors are reported as its status.
+func (a *Agent) Start(jobName string, command []string, params map[string]string) (Execution, error) {
+	if jobName == "" || len(command) == 0 {
+		return Execution{}, fmt.Errorf("agent start: %w: job and command are required", errInvalidArguments)
+	}
+
+	id := uuid.New()
+	logPath := filepath.Join(a.logDir, id.String()+logFileExt)
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return Execution{}, fmt.Errorf("agent start: %w", err)
+	}
+
+	execCtx, cancel := context.WithCancel(context.Background())
+	supervisedExecution := &supervised{
+		Execution: Execution{
+			ID:        id,
+			Job:       jobName,
+			Command:   command,
+			Status:    job.StatusRunning,
+			StartedAt: time.Now(),
+		},
+		signals: make(chan os.Signal, 1),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		logPath: logPath,
+	}
+
+	cmdChan := make(chan *exec.Cmd, 1)
+	idChan := make(chan uuid.UUID, 1)
+	opts := append([]executor.Option{}, a.executorOptions...)
+	opts = append(opts,
+		executor.WithOutFile(logFile),
+		executor.WithErrFile(logFile),
+		executor.WithCmdChan(cmdChan),
+		executor.WithIDChan(idChan),
+		// Signals of the agent aren't forwarded, executions are stopped by their own signals.
+		executor.WithSignals(supervisedExecution.signals),
+		executor.WithParams(params),
+		executor.WithHost(a.hostName),
+		executor.WithHostLabels(a.labels),
+	)
+	exectr := executor.NewExecutor(a.client, opts...)
+
+	started := supervisedExecution.Execution
+	a.mu.Lock()
+	if a.capacity > 0 && a.running() >= a.capacity {
+		a.mu.Unlock()
+		cancel()
+		logFile.Close()
+		if err := os.Remove(logPath); err != nil {
+			glog.Warningf("remove log: %v", err)
+		}
+
+		return Execution{}, fmt.Errorf("agent start: %w: %d executions are running", ErrCapacityExceeded, a.capacity)
+	}
+	a.executions[id] = supervisedExecution
+	a.mu.Unlock()
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer close(supervisedExecution.done)
+		defer logFile.Close()
+		defer cancel()
+
+		go a.watchStart(id, idChan, cmdChan, supervisedExecution.done)
+		// The execution isn't bound to the request ctx, it's canceled by the stop. The executor reports
+		// the finish with its own ctx.
+		exitCode, err := exectr.StartAndWatch(execCtx, jobName, command)
+		a.finished(id, exitCode, err)
+	}()
+
+	return started, nil
+}
+
+// watchStart sets the server id and the pid of the execution when its executor has started the command.
+func (a *Agent) watchStart(id uuid.UUID, idChan <-chan uuid.UUID, cmdChan <-chan *exec.Cmd, done <-chan struct{}) {
+	for idChan != nil || cmdChan != nil {
+		select {
+		case serverID := <-idChan:
+			idChan = nil
+			a.mu.Lock()
+			if execution, ok := a.executions[id]; ok {
+				execution.ServerID = &serverID
+			}
+			a.mu.Unlock()
+		case cmd := <-cmdChan:
+			cmdChan = nil
+			pid := cmd.Process.Pid
+			a.mu.Lock()
+			if execution, ok := a.executions[id]; ok {
+				execution.Pid = &pid
+			}
+			a.mu.Unlock()
+		case <-done:
+			return
+		}
+	}
+}
+
+func (a *Agent) finished(id uuid.UUID, exitCode int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	execution, ok := a.executions[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	execution.FinishedAt = &now
+	execution.ExitCode = &exitCode
+	execution.Status = job.StatusSuccessed
+	if exitCode != executor.ExitOK {
+		execution.Status = job.StatusFailed
+	}
+	if err != nil {
+		execution.Msg = err.Error()
+		glog.Warningf("execution %s of %s: %v", id, execution.Job, err)
+	}
+
+	a.evictFinished()
+}
+
+// running returns the number of running executions, a.mu is held.
+func (a *Agent) running() int {
+	running := 0
+	for _, execution := range a.executions {
+		if execution.FinishedAt == nil {
+			running++
+		}
+	}
+
+	return running
+}
+
+// evictFinished removes the oldest finished executions and their logs over the limit, a.mu is held.
+func (a *Agent) evictFinished() {
+	var finished []*supervised
+	for _, execution := range a.executions {
+		if execution.FinishedAt != nil {
+			finished = append(finished, execution)
+		}
+	}
+	if len(finished) <= maxFinished {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.Before(*finished[j].FinishedAt)
+	})
+	for _, execution := range finished[:len(finished)-maxFinished] {
+		delete(a.executions, execution.ID)
+		if err := os.Remove(execution.logPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			glog.Warningf("remove log: %v", err)
+		}
+	}
+}
+
+// List returns supervised executions, the latest first.
+func (a *Agent) List() []Execution {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	executions := make([]Execution, 0, len(a.executions))
+	for _, execution := range a.executions {
+		executions = append(executions, execution.Execution)
+	}
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].StartedAt.After(executions[j].StartedAt)
+	})
+
+	return executions
+}
+
+func (a *Agent) Get(id uuid.UUID) (Execution, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	execution, ok := a.executions[id]
+	if !ok {
+		return Execution{}, fmt.Errorf("agent get: %w", ErrExecutionNotFound)
+	}
+
+	return execution.Execution, nil
+}
+
+// Stop terminates the execution's process group, it's killed after the executor's grace period.
+// If wait is true, Stop returns when the execution is finished.
+func (a *Agent) Stop(ctx context.Context, id uuid.UUID, wait bool) (Execution, error) {
+	a.mu.Lock()
+	execution, ok := a.executions[id]
+	a.mu.Unlock()
+	if !ok {
+		return Execution{}, fmt.Errorf("agent stop: %w", ErrExecutionNotFound)
+	}
+
+	select {
+	case <-execution.done:
+		return Execution{}, fmt.Errorf("agent stop: %w", ErrNotRunning)
+	default:
+	}
+
+	execution.terminate()
+	if wait {
+		select {
+		case <-execution.done:
+		case <-ctx.Done():
+			return Execution{}, fmt.Errorf("agent stop: %w", ctx.Err())
+		}
+	}
+
+	return a.Get(id)
+}
+
+// terminateAll stops all running executions, it's used when the agent stops.
+func (a *Agent) terminateAll() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, execution := range a.executions {
+		execution.terminate()
+	}
+}
+
+// terminate cancels the execution's ctx: the executor abandons the start if it's still pending or
+// sends SIGTERM to the command and kills its process group after the grace period.
+func (s *supervised) terminate() {
+	s.cancel()
+}
+
+// LogPath returns the file with stdout and stderr of the execution.
+func (a *Agent) LogPath(id uuid.UUID) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	execution, ok := a.executions[id]
+	if !ok {
+		return "", fmt.Errorf("agent logs: %w", ErrExecutionNotFound)
+	}
+
+	return execution.logPath, nil
+}
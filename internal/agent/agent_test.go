@@ -0,0 +1,228 @@
+//go:build !windows
+// +build !windows
+
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/agent"
+	"github.com/antgubarev/jobs/internal/executor"
+	"github.com/antgubarev/jobs/internal/host"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/antgubarev/jobs/internal/restapi/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func runAgent(t *testing.T, client restapi.Client, opts ...agent.Option) *agent.Client {
+	t.Helper()
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "agent.sock")
+	opts = append([]agent.Option{
+		agent.WithSocket(socket),
+		agent.WithLogDir(filepath.Join(dir, "logs")),
+		agent.WithExecutorOptions(executor.WithGracePeriod(100 * time.Millisecond)),
+	}, opts...)
+	jobAgent := agent.NewAgent(client, opts...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- jobAgent.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	agentClient := agent.NewClient(socket)
+	assert.Eventually(t, func() bool {
+		_, err := agentClient.List(context.Background())
+
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	return agentClient
+}
+
+func TestAgent(t *testing.T) {
+	t.Parallel()
+	client := &mocks.Client{}
+	client.On("HostRegister", mock.Anything, mock.Anything).Return(&host.Host{}, nil)
+	client.On("JobStart", mock.Anything, mock.MatchedBy(func(in *restapi.JobStartIn) bool {
+		return *in.Host == "host-1"
+	})).Return(&restapi.JobStartOut{ID: uuid.New()}, nil)
+	var finished int32
+	// The finish is reported after the stop has canceled the execution's ctx.
+	client.On("JobFinish", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Err() == nil
+	}), mock.Anything, mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		atomic.AddInt32(&finished, 1)
+	})
+
+	agentClient := runAgent(t, client, agent.WithHost("host-1", nil))
+	ctx := context.Background()
+
+	started, err := agentClient.Start(ctx, &agent.StartIn{Job: "job", Command: []string{"sh", "-c", "echo hi; sleep 10"}})
+	assert.NoError(t, err)
+	assert.Equal(t, job.StatusRunning, started.Status)
+
+	executions, err := agentClient.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, executions, 1)
+	assert.Equal(t, started.ID, executions[0].ID)
+
+	assert.Eventually(t, func() bool {
+		var logs bytes.Buffer
+		err := agentClient.Logs(ctx, started.ID, 0, &logs)
+
+		return err == nil && logs.String() == "hi\n"
+	}, time.Second, 10*time.Millisecond)
+	var tail bytes.Buffer
+	assert.NoError(t, agentClient.Logs(ctx, started.ID, 2, &tail))
+	assert.Equal(t, "i\n", tail.String())
+
+	stopped, err := agentClient.Stop(ctx, started.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, job.StatusFailed, stopped.Status)
+	if assert.NotNil(t, stopped.ExitCode) {
+		assert.Equal(t, 143, *stopped.ExitCode)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+
+	_, err = agentClient.Stop(ctx, started.ID, false)
+	assert.ErrorIs(t, err, agent.ErrNotRunning)
+	_, err = agentClient.Get(ctx, uuid.New())
+	assert.ErrorIs(t, err, agent.ErrExecutionNotFound)
+	_, err = agentClient.Start(ctx, &agent.StartIn{Job: "job"})
+	assert.Error(t, err)
+}
+
+func TestAgentStopsExecutionsOnShutdown(t *testing.T) {
+	t.Parallel()
+	client := &mocks.Client{}
+	client.On("HostRegister", mock.Anything, mock.Anything).Return(&host.Host{}, nil)
+	serverID := uuid.New()
+	client.On("JobStart", mock.Anything, mock.Anything).Return(&restapi.JobStartOut{ID: serverID}, nil)
+	client.On("JobFinish", mock.Anything, mock.Anything, mock.MatchedBy(func(in *restapi.JobFinishIn) bool {
+		return *in.ExitCode == 143
+	})).Return(nil).Once()
+
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "agent.sock")
+	jobAgent := agent.NewAgent(client, agent.WithSocket(socket), agent.WithLogDir(dir))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- jobAgent.Run(ctx)
+	}()
+
+	agentClient := agent.NewClient(socket)
+	assert.Eventually(t, func() bool {
+		_, err := agentClient.Start(ctx, &agent.StartIn{Job: "job", Command: []string{"sleep", "10"}})
+
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		executions := jobAgent.List()
+
+		return len(executions) == 1 && executions[0].Pid != nil &&
+			executions[0].ServerID != nil && *executions[0].ServerID == serverID
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+	client.AssertExpectations(t)
+}
+
+func TestAgentStopsPendingStart(t *testing.T) {
+	t.Parallel()
+	client := &mocks.Client{}
+	client.On("HostRegister", mock.Anything, mock.Anything).Return(&host.Host{}, nil)
+	// The start hangs, e.g. the server doesn't respond, until the execution is stopped.
+	client.On("JobStart", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, _ *restapi.JobStartIn) *restapi.JobStartOut {
+			<-ctx.Done()
+
+			return nil
+		}, func(ctx context.Context, _ *restapi.JobStartIn) error {
+			return ctx.Err()
+		}).Once()
+
+	agentClient := runAgent(t, client)
+	ctx := context.Background()
+
+	started, err := agentClient.Start(ctx, &agent.StartIn{Job: "job", Command: []string{"true"}})
+	assert.NoError(t, err)
+	stopped, err := agentClient.Stop(ctx, started.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, job.StatusFailed, stopped.Status)
+	client.AssertExpectations(t)
+}
+
+func TestAgentRegistration(t *testing.T) {
+	t.Parallel()
+	client := &mocks.Client{}
+	var calls int32
+	client.On("HostRegister", mock.Anything, mock.MatchedBy(func(in *restapi.HostRegisterIn) bool {
+		return in.Name == "host-1" && in.Labels["zone"] == "a" && in.AgentVersion == "1.0"
+	})).Return(func(context.Context, *restapi.HostRegisterIn) *host.Host {
+		return &host.Host{}
+	}, func(context.Context, *restapi.HostRegisterIn) error {
+		// The server is unavailable for the first registrations, the agent reconnects with the backoff.
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			return restapi.ErrUnavailable
+		}
+
+		return nil
+	})
+
+	runAgent(t, client,
+		agent.WithHost("host-1", map[string]string{"zone": "a"}),
+		agent.WithVersion("1.0"),
+		agent.WithRegistration(20*time.Millisecond, executor.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond}),
+	)
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) >= 5
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAgentCapacity(t *testing.T) {
+	t.Parallel()
+	client := &mocks.Client{}
+	client.On("HostRegister", mock.Anything, mock.MatchedBy(func(in *restapi.HostRegisterIn) bool {
+		return in.Capacity == 1
+	})).Return(&host.Host{}, nil)
+	client.On("JobStart", mock.Anything, mock.Anything).Return(&restapi.JobStartOut{ID: uuid.New()}, nil)
+	client.On("JobFinish", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	agentClient := runAgent(t, client, agent.WithCapacity(1))
+	ctx := context.Background()
+
+	started, err := agentClient.Start(ctx, &agent.StartIn{Job: "job", Command: []string{"sleep", "10"}})
+	assert.NoError(t, err)
+	_, err = agentClient.Start(ctx, &agent.StartIn{Job: "job", Command: []string{"sleep", "10"}})
+	assert.ErrorIs(t, err, agent.ErrCapacityExceeded)
+
+	_, err = agentClient.Stop(ctx, started.ID, true)
+	assert.NoError(t, err)
+	_, err = agentClient.Start(ctx, &agent.StartIn{Job: "job", Command: []string{"true"}})
+	assert.NoError(t, err)
+}
+
+func TestClientSocketUnavailable(t *testing.T) {
+	t.Parallel()
+	_, err := agent.NewClient(filepath.Join(t.TempDir(), "agent.sock")).List(context.Background())
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "send request"))
+}
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+var errWrongResponse = errors.New("wrong response")
+
+// socketHost is the fake host of requests, they are sent to the socket.
+const socketHost = "http://agent"
+
+// Client is used by local tools to control the agent by its socket.
+type Client struct {
+	client http.Client
+}
+
+func NewClient(socket string) *Client {
+	return &Client{
+		client: http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+func (c *Client) List(ctx context.Context) ([]Execution, error) {
+	out := struct {
+		Executions []Execution `json:"executions"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/executions", nil, &out); err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+
+	return out.Executions, nil
+}
+
+func (c *Client) Start(ctx context.Context, in *StartIn) (*Execution, error) {
+	var execution Execution
+	if err := c.do(ctx, http.MethodPost, "/executions", in, &execution); err != nil {
+		return nil, fmt.Errorf("Start: %w", err)
+	}
+
+	return &execution, nil
+}
+
+func (c *Client) Get(ctx context.Context, id uuid.UUID) (*Execution, error) {
+	var execution Execution
+	if err := c.do(ctx, http.MethodGet, "/execution/"+id.String(), nil, &execution); err != nil {
+		return nil, fmt.Errorf("Get: %w", err)
+	}
+
+	return &execution, nil
+}
+
+func (c *Client) Stop(ctx context.Context, id uuid.UUID, wait bool) (*Execution, error) {
+	var execution Execution
+	path := "/execution/" + id.String() + "/stop?wait=" + strconv.FormatBool(wait)
+	if err := c.do(ctx, http.MethodPost, path, nil, &execution); err != nil {
+		return nil, fmt.Errorf("Stop: %w", err)
+	}
+
+	return &execution, nil
+}
+
+// Logs copies the output of the execution to w, tail limits it by the last bytes if it's positive.
+func (c *Client) Logs(ctx context.Context, id uuid.UUID, tail int64, w io.Writer) error {
+	query := url.Values{}
+	if tail > 0 {
+		query.Set("tail", strconv.FormatInt(tail, 10))
+	}
+	resp, err := c.send(ctx, http.MethodGet, "/execution/"+id.String()+"/logs?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("Logs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("Logs read response: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal in: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+// send returns the response with 2xx status, errors of the agent are converted to the package errors.
+func (c *Client) send(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, socketHost+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	msg := struct {
+		Msg string `json:"msg"`
+	}{}
+	_ = json.NewDecoder(resp.Body).Decode(&msg)
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, ErrExecutionNotFound
+	case http.StatusConflict:
+		return nil, ErrNotRunning
+	case http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: %s", ErrCapacityExceeded, msg.Msg)
+	case http.StatusBadRequest:
+		return nil, fmt.Errorf("%w: %s", errInvalidArguments, msg.Msg)
+	default:
+		return nil, fmt.Errorf("status %d: %s: %w", resp.StatusCode, msg.Msg, errWrongResponse)
+	}
+}
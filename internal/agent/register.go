@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/golang/glog"
+)
+
+// register registers the host every registerInterval, repeated registrations are heartbeats.
+// While the server is unavailable, the registration is retried with the backoff, the budget of the backoff isn't used.
+func (a *Agent) register(ctx context.Context) {
+	in := &restapi.HostRegisterIn{
+		Name:         a.hostName,
+		Labels:       a.labels,
+		AgentVersion: a.version,
+		Capacity:     a.capacity,
+	}
+	delay := a.backoff.Initial
+	connected := true
+
+	for {
+		next := a.registerInterval
+		if _, err := a.client.HostRegister(ctx, in); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if connected {
+				glog.Warningf("register host %s: %v", a.hostName, err)
+			}
+			connected = false
+			if errors.Is(err, restapi.ErrUnavailable) {
+				next = delay
+				delay *= 2
+				if delay > a.backoff.Max {
+					delay = a.backoff.Max
+				}
+			}
+		} else {
+			if !connected {
+				glog.Infof("host %s is registered again", a.hostName)
+			}
+			connected = true
+			delay = a.backoff.Initial
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next):
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+)
+
+// StartIn is the body of the execution start on the agent socket.
+type StartIn struct {
+	Job     string            `json:"job" binding:"required"`
+	Command []string          `json:"command" binding:"required"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+type socketHandler struct {
+	agent *Agent
+}
+
+func newRouter(agent *Agent) http.Handler {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	handler := &socketHandler{agent: agent}
+	router.GET("/executions", handler.ListHandle)
+	router.POST("/executions", handler.StartHandle)
+	router.GET("/execution/:id", handler.GetHandle)
+	router.POST("/execution/:id/stop", handler.StopHandle)
+	router.GET("/execution/:id/logs", handler.LogsHandle)
+
+	return router
+}
+
+func (sh *socketHandler) ListHandle(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"executions": sh.agent.List()})
+}
+
+func (sh *socketHandler) StartHandle(ctx *gin.Context) {
+	var in StartIn
+	if err := ctx.ShouldBindJSON(&in); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+
+		return
+	}
+
+	execution, err := sh.agent.Start(in.Job, in.Command, in.Params)
+	if err != nil {
+		writeError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, execution)
+}
+
+func (sh *socketHandler) GetHandle(ctx *gin.Context) {
+	id, ok := bindID(ctx)
+	if !ok {
+		return
+	}
+
+	execution, err := sh.agent.Get(id)
+	if err != nil {
+		writeError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, execution)
+}
+
+// StopHandle stops the execution, with `wait=true` it responds when the execution is finished.
+func (sh *socketHandler) StopHandle(ctx *gin.Context) {
+	id, ok := bindID(ctx)
+	if !ok {
+		return
+	}
+
+	execution, err := sh.agent.Stop(ctx.Request.Context(), id, ctx.Query("wait") == "true")
+	if err != nil {
+		writeError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, execution)
+}
+
+// LogsHandle responds with the output of the execution, `tail=N` limits it by the last N bytes.
+func (sh *socketHandler) LogsHandle(ctx *gin.Context) {
+	id, ok := bindID(ctx)
+	if !ok {
+		return
+	}
+
+	var tail int64
+	if value := ctx.Query("tail"); value != "" {
+		var err error
+		if tail, err = strconv.ParseInt(value, 10, 64); err != nil || tail < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"msg": "tail must be a non-negative number"})
+
+			return
+		}
+	}
+
+	logPath, err := sh.agent.LogPath(id)
+	if err != nil {
+		writeError(ctx, err)
+
+		return
+	}
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		writeError(ctx, err)
+
+		return
+	}
+	defer logFile.Close()
+
+	stat, err := logFile.Stat()
+	if err != nil {
+		writeError(ctx, err)
+
+		return
+	}
+	size := stat.Size()
+	if tail > 0 && tail < size {
+		if _, err := logFile.Seek(-tail, io.SeekEnd); err != nil {
+			writeError(ctx, err)
+
+			return
+		}
+		size = tail
+	}
+
+	ctx.DataFromReader(http.StatusOK, size, "text/plain; charset=utf-8", io.LimitReader(logFile, size), nil)
+}
+
+func bindID(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"msg": "invalid execution id"})
+
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func writeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrExecutionNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"msg": err.Error()})
+	case errors.Is(err, ErrNotRunning):
+		ctx.JSON(http.StatusConflict, gin.H{"msg": err.Error()})
+	case errors.Is(err, ErrCapacityExceeded):
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"msg": err.Error()})
+	case errors.Is(err, errInvalidArguments):
+		ctx.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+	default:
+		glog.Errorf("agent socket %s: %v", ctx.Request.RequestURI, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+	}
+}
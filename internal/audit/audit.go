@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ResultOK    = "ok"
+	ResultError = "error"
+)
+
+type Change struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type Record struct {
+	ID       uuid.UUID `json:"id"`
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`
+	SourceIP string    `json:"sourceIp"`
+	Action   string    `json:"action"`
+	Target   string    `json:"target"`
+	Changes  []Change  `json:"changes"`
+	Status   int       `json:"status"`
+	Result   string    `json:"result"`
+}
+
+func NewRecord(action string) *Record {
+	return &Record{
+		ID:      uuid.New(),
+		Time:    time.Now(),
+		Action:  action,
+		Changes: []Change{},
+	}
+}
+
+// Filter selects records, empty fields match everything.
+type Filter struct {
+	Actor  string
+	Action string
+	Target string
+	Since  *time.Time
+	Until  *time.Time
+	Limit  int
+}
+
+func (f *Filter) Match(record *Record) bool {
+	if f.Actor != "" && f.Actor != record.Actor {
+		return false
+	}
+	if f.Action != "" && f.Action != record.Action {
+		return false
+	}
+	if f.Target != "" && f.Target != record.Target {
+		return false
+	}
+	if f.Since != nil && record.Time.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && record.Time.After(*f.Until) {
+		return false
+	}
+
+	return true
+}
+
+//go:generate mockery --case underscore --name Storage
+type Storage interface {
+	Append(record *Record) error
+	// Find returns records matched by filter, newest first.
+	Find(filter Filter) ([]Record, error)
+}
@@ -0,0 +1,58 @@
+// Package audittest contains conformance tests which every audit.Storage
+// implementation has to pass.
+package audittest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage(t *testing.T, newStorage func(t *testing.T) audit.Storage) {
+	t.Helper()
+
+	t.Run("append and find newest first", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		for _, action := range []string{"job.create", "job.pause", "job.delete"} {
+			record := audit.NewRecord(action)
+			record.Actor = "admin"
+			record.Target = "job1"
+			assert.NoError(t, store.Append(record))
+		}
+
+		records, err := store.Find(audit.Filter{})
+		assert.NoError(t, err)
+		assert.Len(t, records, 3)
+		assert.Equal(t, "job.delete", records[0].Action)
+		assert.Equal(t, "job.create", records[2].Action)
+	})
+
+	t.Run("find with filter", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		old := audit.NewRecord("job.create")
+		old.Time = time.Now().Add(-time.Hour)
+		old.Actor = "admin"
+		assert.NoError(t, store.Append(old))
+		for i := 0; i < 3; i++ {
+			record := audit.NewRecord("job.pause")
+			record.Actor = "admin"
+			assert.NoError(t, store.Append(record))
+		}
+		other := audit.NewRecord("job.pause")
+		other.Actor = "robot"
+		assert.NoError(t, store.Append(other))
+
+		records, err := store.Find(audit.Filter{Actor: "admin", Action: "job.pause", Limit: 2})
+		assert.NoError(t, err)
+		assert.Len(t, records, 2)
+
+		since := time.Now().Add(-time.Minute)
+		records, err = store.Find(audit.Filter{Actor: "admin", Since: &since})
+		assert.NoError(t, err)
+		assert.Len(t, records, 3)
+	})
+}
@@ -0,0 +1,98 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/antgubarev/jobs/internal/audit"
+	bolt "go.etcd.io/bbolt"
+)
+
+const AuditBucketName string = "audit"
+
+const auditKeyLen = 8
+
+// AuditStorage is append-only, records are keyed by the bucket sequence.
+type AuditStorage struct {
+	db *bolt.DB
+}
+
+func NewAuditStorage(db *bolt.DB) (*AuditStorage, error) {
+	if err := CreateBucketIfNotExists(db, AuditBucketName); err != nil {
+		return nil, err
+	}
+
+	return &AuditStorage{db: db}, nil
+}
+
+func (as *AuditStorage) Append(record *audit.Record) error {
+	if err := as.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := as.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return fmt.Errorf("audit append: next sequence: %w", err)
+		}
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("audit append: marshal: %w", err)
+		}
+
+		key := make([]byte, auditKeyLen)
+		binary.BigEndian.PutUint64(key, seq)
+		if err := bucket.Put(key, data); err != nil {
+			return fmt.Errorf("audit append: bucket put: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("Append: %w", err)
+	}
+
+	return nil
+}
+
+func (as *AuditStorage) Find(filter audit.Filter) ([]audit.Record, error) {
+	var result []audit.Record
+
+	if err := as.db.View(func(tx *bolt.Tx) error {
+		bucket, err := as.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+		c := bucket.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var record audit.Record
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("audit find: unmarshal: %w", err)
+			}
+			if !filter.Match(&record) {
+				continue
+			}
+			result = append(result, record)
+			if filter.Limit > 0 && len(result) >= filter.Limit {
+				break
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("Find: %w", err)
+	}
+
+	return result, nil
+}
+
+func (as *AuditStorage) GetBucket(tx *bolt.Tx) (*bolt.Bucket, error) {
+	bucket := tx.Bucket([]byte(AuditBucketName))
+	if bucket == nil {
+		return nil, fmt.Errorf("%w: %s", errBucketNotFound, AuditBucketName)
+	}
+
+	return bucket, nil
+}
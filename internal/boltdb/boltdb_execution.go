@@ -3,13 +3,20 @@ package boltdb
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/antgubarev/jobs/internal/job"
 	"github.com/google/uuid"
 	bolt "go.etcd.io/bbolt"
 )
 
+var ErrExecutionNotFound = job.ErrExecutionNotFound
+
+// RunningBucketName is the index of running executions: ids of executions to their keys in the jobs bucket.
+const RunningBucketName string = "running"
+
 type ExecutionStorage struct {
 	db *bolt.DB
 }
@@ -18,12 +25,110 @@ func NewExecutionStorage(db *bolt.DB) (*ExecutionStorage, error) {
 	if err := CreateBucketIfNotExists(db, JobBucketName); err != nil {
 		return nil, err
 	}
+	storage := &ExecutionStorage{db: db}
+	if err := storage.rekey(); err != nil {
+		return nil, err
+	}
+	if err := storage.reindex(); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// reindex builds the index of running executions from scratch, executions might be stored by older versions.
+func (bes *ExecutionStorage) reindex() error {
+	if err := bes.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(RunningBucketName)); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return fmt.Errorf("delete index: %w", err)
+		}
+		running, err := tx.CreateBucket([]byte(RunningBucketName))
+		if err != nil {
+			return fmt.Errorf("create index: %w", err)
+		}
+		bucket, err := bes.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		c := bucket.Cursor()
+		prefix := []byte("execution:")
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var e job.Execution
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("unmarshal execution: %w", err)
+			}
+			if err := bes.index(running, &e, k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("reindex executions: %w", err)
+	}
+
+	return nil
+}
+
+// index adds the running execution to the index or removes the finished one.
+func (bes *ExecutionStorage) index(running *bolt.Bucket, execution *job.Execution, key []byte) error {
+	if execution.Status != job.StatusRunning {
+		if err := running.Delete(execution.ID[:]); err != nil {
+			return fmt.Errorf("index execution: %w", err)
+		}
+
+		return nil
+	}
+	if err := running.Put(execution.ID[:], key); err != nil {
+		return fmt.Errorf("index execution: %w", err)
+	}
 
-	return &ExecutionStorage{db: db}, nil
+	return nil
+}
+
+// rekey moves executions stored under keys of older versions (`execution:job:host:pid`) to their current keys.
+func (bes *ExecutionStorage) rekey() error {
+	if err := bes.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := bes.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		moved := make(map[string][]byte)
+		c := bucket.Cursor()
+		prefix := []byte("execution:")
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var e job.Execution
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("unmarshal execution: %w", err)
+			}
+			if key := bes.GetExecutionKey(&e); !bytes.Equal(key, k) {
+				moved[string(k)] = append([]byte{}, v...)
+			}
+		}
+		for oldKey, data := range moved {
+			var e job.Execution
+			if err := json.Unmarshal(data, &e); err != nil {
+				return fmt.Errorf("unmarshal execution: %w", err)
+			}
+			if err := bucket.Delete([]byte(oldKey)); err != nil {
+				return fmt.Errorf("delete old key: %w", err)
+			}
+			if err := bucket.Put(bes.GetExecutionKey(&e), data); err != nil {
+				return fmt.Errorf("put new key: %w", err)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("rekey executions: %w", err)
+	}
+
+	return nil
 }
 
 func (bes *ExecutionStorage) Store(execution *job.Execution) error {
-	// TODO: Id!!!
 	if err := bes.db.Update(func(tx *bolt.Tx) error {
 		bucket, err := bes.GetBucket(tx)
 		if err != nil {
@@ -35,11 +140,16 @@ func (bes *ExecutionStorage) Store(execution *job.Execution) error {
 			return fmt.Errorf("execution store: marshal: %w", err)
 		}
 
-		if err := bucket.Put(bes.GetExecutionKey(execution), data); err != nil {
+		// The key changes with the host or pid of the execution, the previous one is removed.
+		key := bes.GetExecutionKey(execution)
+		if err := bes.deleteStaleKeys(bucket, execution, key); err != nil {
+			return err
+		}
+		if err := bucket.Put(key, data); err != nil {
 			return fmt.Errorf("execution store: bucket put: %w", err)
 		}
 
-		return nil
+		return bes.index(tx.Bucket([]byte(RunningBucketName)), execution, key)
 	}); err != nil {
 		return fmt.Errorf("Store execution: %w", err)
 	}
@@ -48,36 +158,28 @@ func (bes *ExecutionStorage) Store(execution *job.Execution) error {
 }
 
 func (bes *ExecutionStorage) GetByID(executionID uuid.UUID) (*job.Execution, error) {
-	var result job.Execution
+	var result *job.Execution
 
 	err := bes.db.View(func(tx *bolt.Tx) error {
 		bucket, err := bes.GetBucket(tx)
 		if err != nil {
 			return fmt.Errorf("get bucket: %w", err)
 		}
-		if err := bucket.ForEach(func(k, v []byte) error {
-			var e job.Execution
-			if err := json.Unmarshal(v, &e); err != nil {
-				return fmt.Errorf("execution getbyname: unmarshal job: %w", err)
-			}
-			if e.ID == executionID {
-				result = e
-
-				return nil
-			}
-
-			return nil
-		}); err != nil {
-			return fmt.Errorf("search execution in bucket: %w", err)
+		_, result, err = bes.find(bucket, executionID)
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			return fmt.Errorf("%w: %s", ErrExecutionNotFound, executionID)
 		}
 
 		return nil
 	})
 	if err != nil {
-		return &result, fmt.Errorf("GetByID: %w", err)
+		return nil, fmt.Errorf("GetByID: %w", err)
 	}
 
-	return &result, nil
+	return result, nil
 }
 
 func (bes *ExecutionStorage) GetByJobName(jobName string) ([]job.Execution, error) {
@@ -106,15 +208,56 @@ func (bes *ExecutionStorage) GetByJobName(jobName string) ([]job.Execution, erro
 	return result, nil
 }
 
+// GetRunning returns running executions by the index, the history isn't read.
+func (bes *ExecutionStorage) GetRunning() ([]job.Execution, error) {
+	var result []job.Execution
+
+	if err := bes.db.View(func(tx *bolt.Tx) error {
+		bucket, err := bes.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket([]byte(RunningBucketName)).ForEach(func(_, key []byte) error {
+			data := bucket.Get(key)
+			if data == nil {
+				return nil
+			}
+			var e job.Execution
+			if err := json.Unmarshal(data, &e); err != nil {
+				return fmt.Errorf("execution getrunning: unmarshal: %w", err)
+			}
+			result = append(result, e)
+
+			return nil
+		})
+	}); err != nil {
+		return nil, fmt.Errorf("GetRunning: %w", err)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+
+	return result, nil
+}
+
 func (bes *ExecutionStorage) DeleteByJobName(jobName string) error {
 	if err := bes.db.Update(func(tx *bolt.Tx) error {
 		bucket, err := bes.GetBucket(tx)
 		if err != nil {
 			return err
 		}
+		running := tx.Bucket([]byte(RunningBucketName))
 		c := bucket.Cursor()
 		prefix := bes.GetExecutionNameKeyPrefix(jobName)
-		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var e job.Execution
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("unmarshal execution: %w", err)
+			}
+			if err := running.Delete(e.ID[:]); err != nil {
+				return fmt.Errorf("execution remove: %w", err)
+			}
 			if err := c.Delete(); err != nil {
 				return fmt.Errorf("execution remove: %w", err)
 			}
@@ -134,22 +277,18 @@ func (bes *ExecutionStorage) Delete(executionID uuid.UUID) error {
 		if err != nil {
 			return err
 		}
-		if err := bucket.ForEach(func(key, value []byte) error {
-			var e job.Execution
-			if err := json.Unmarshal(value, &e); err != nil {
-				return fmt.Errorf("unmarshal execution: %w", err)
-			}
-			if e.ID.String() == executionID.String() {
-				if err := bucket.Delete(key); err != nil {
-					return fmt.Errorf("remove execution from bucket: %w", err)
-				}
-
-				return nil
-			}
-
+		key, _, err := bes.find(bucket, executionID)
+		if err != nil {
+			return err
+		}
+		if key == nil {
 			return nil
-		}); err != nil {
-			return fmt.Errorf("search execution in bucket: %w", err)
+		}
+		if err := bucket.Delete(key); err != nil {
+			return fmt.Errorf("remove execution from bucket: %w", err)
+		}
+		if err := tx.Bucket([]byte(RunningBucketName)).Delete(executionID[:]); err != nil {
+			return fmt.Errorf("remove execution from index: %w", err)
 		}
 
 		return nil
@@ -160,6 +299,42 @@ func (bes *ExecutionStorage) Delete(executionID uuid.UUID) error {
 	return nil
 }
 
+// deleteStaleKeys deletes keys of the execution's job ending with its ID except the current key.
+func (bes *ExecutionStorage) deleteStaleKeys(bucket *bolt.Bucket, execution *job.Execution, key []byte) error {
+	suffix := []byte(":" + execution.ID.String())
+	var stale [][]byte
+	c := bucket.Cursor()
+	prefix := bes.GetExecutionNameKeyPrefix(execution.Job)
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if bytes.HasSuffix(k, suffix) && !bytes.Equal(k, key) {
+			stale = append(stale, append([]byte{}, k...))
+		}
+	}
+	for _, k := range stale {
+		if err := bucket.Delete(k); err != nil {
+			return fmt.Errorf("execution store: delete stale key: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (bes *ExecutionStorage) find(bucket *bolt.Bucket, executionID uuid.UUID) ([]byte, *job.Execution, error) {
+	c := bucket.Cursor()
+	prefix := []byte("execution:")
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		var e job.Execution
+		if err := json.Unmarshal(v, &e); err != nil {
+			return nil, nil, fmt.Errorf("unmarshal execution: %w", err)
+		}
+		if e.ID == executionID {
+			return append([]byte{}, k...), &e, nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
 func (bes *ExecutionStorage) GetBucket(tx *bolt.Tx) (*bolt.Bucket, error) {
 	bucket := tx.Bucket([]byte(JobBucketName))
 	if bucket == nil {
@@ -179,7 +354,7 @@ func (bes *ExecutionStorage) GetExecutionKey(execution *job.Execution) []byte {
 		pid = *execution.Pid
 	}
 
-	return []byte(fmt.Sprintf("execution:%s:%s:%d", execution.Job, host, pid))
+	return []byte(fmt.Sprintf("execution:%s:%s:%d:%s", execution.Job, host, pid, execution.ID))
 }
 
 func (bes *ExecutionStorage) GetExecutionNameKeyPrefix(name string) []byte {
@@ -3,6 +3,7 @@ package boltdb_test
 import (
 	"encoding/json"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/r3labs/diff/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -212,3 +214,118 @@ func TestBoltDbExecutionDelete(t *testing.T) {
 	}
 	assert.Equal(t, 1, len(items))
 }
+
+func TestBoltDbExecutionGetByIdNotFound(t *testing.T) {
+	t.Parallel()
+	store, db := newTestExecutionStorage(t)
+	defer func(db *bolt.DB) {
+		db.Close()
+		os.Remove(db.Path())
+	}(db)
+
+	execution, err := store.GetByID(uuid.New())
+	assert.ErrorIs(t, err, boltdb.ErrExecutionNotFound)
+	assert.Nil(t, execution)
+}
+
+func countExecutionKeys(t *testing.T, db *bolt.DB) int {
+	t.Helper()
+	count := 0
+	assert.NoError(t, db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(boltdb.JobBucketName)).ForEach(func(k, v []byte) error {
+			if strings.HasPrefix(string(k), "execution:") {
+				count++
+			}
+
+			return nil
+		})
+	}))
+
+	return count
+}
+
+func TestBoltDbExecutionStoreChangedKey(t *testing.T) {
+	t.Parallel()
+	store, db := newTestExecutionStorage(t)
+	defer func(db *bolt.DB) {
+		db.Close()
+		os.Remove(db.Path())
+	}(db)
+
+	execution := job.NewRunningExecution("job")
+	require.NoError(t, store.Store(execution))
+	execution.SetHost("host1")
+	execution.SetPid(10)
+	require.NoError(t, store.Store(execution))
+
+	assert.Equal(t, 1, countExecutionKeys(t, db))
+	stored, err := store.GetByID(execution.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "host1", *stored.Host)
+}
+
+func TestBoltDbExecutionRekeyLegacyKeys(t *testing.T) {
+	t.Parallel()
+	db := internal.NewTestBoltDB(t)
+	defer func(db *bolt.DB) {
+		db.Close()
+		os.Remove(db.Path())
+	}(db)
+
+	running := job.NewRunningExecution("job")
+	running.SetHost("host1")
+	running.SetPid(10)
+	data, err := json.Marshal(running)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(boltdb.JobBucketName)).Put([]byte("execution:job:host1:10"), data)
+	}))
+
+	store, err := boltdb.NewExecutionStorage(db)
+	require.NoError(t, err)
+	running.Finish(job.StatusSuccessed, time.Now(), "")
+	require.NoError(t, store.Store(running))
+
+	assert.Equal(t, 1, countExecutionKeys(t, db))
+	executions, err := store.GetByJobName("job")
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+	assert.Equal(t, job.StatusSuccessed, executions[0].Status)
+}
+
+func TestBoltDbExecutionIndexRunningOnOpen(t *testing.T) {
+	t.Parallel()
+	db := internal.NewTestBoltDB(t)
+	defer func(db *bolt.DB) {
+		db.Close()
+		os.Remove(db.Path())
+	}(db)
+
+	// Stored by the version without the index.
+	running := job.NewRunningExecution("job")
+	running.SetHost("host1")
+	running.SetPid(10)
+	finished := job.NewRunningExecution("job")
+	finished.Finish(job.StatusFailed, time.Now(), "")
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(boltdb.JobBucketName))
+		for _, execution := range []*job.Execution{running, finished} {
+			data, err := json.Marshal(execution)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put((&boltdb.ExecutionStorage{}).GetExecutionKey(execution), data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}))
+
+	store, err := boltdb.NewExecutionStorage(db)
+	require.NoError(t, err)
+	executions, err := store.GetRunning()
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+	assert.Equal(t, running.ID, executions[0].ID)
+}
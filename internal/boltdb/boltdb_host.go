@@ -0,0 +1,81 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/antgubarev/jobs/internal/host"
+	bolt "go.etcd.io/bbolt"
+)
+
+const HostBucketName string = "hosts"
+
+// HostStorage keeps hosts registered by agents keyed by their names.
+type HostStorage struct {
+	db *bolt.DB
+}
+
+func NewHostStorage(db *bolt.DB) (*HostStorage, error) {
+	if err := CreateBucketIfNotExists(db, HostBucketName); err != nil {
+		return nil, err
+	}
+
+	return &HostStorage{db: db}, nil
+}
+
+func (hs *HostStorage) Store(known *host.Host) error {
+	if err := hs.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := hs.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(known)
+		if err != nil {
+			return fmt.Errorf("host store: marshal: %w", err)
+		}
+		if err := bucket.Put([]byte(known.Name), data); err != nil {
+			return fmt.Errorf("host store: bucket put: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("Store: %w", err)
+	}
+
+	return nil
+}
+
+func (hs *HostStorage) GetAll() ([]host.Host, error) {
+	var result []host.Host
+
+	if err := hs.db.View(func(tx *bolt.Tx) error {
+		bucket, err := hs.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		return bucket.ForEach(func(_, v []byte) error {
+			var known host.Host
+			if err := json.Unmarshal(v, &known); err != nil {
+				return fmt.Errorf("host getall: unmarshal: %w", err)
+			}
+			result = append(result, known)
+
+			return nil
+		})
+	}); err != nil {
+		return nil, fmt.Errorf("GetAll: %w", err)
+	}
+
+	return result, nil
+}
+
+func (hs *HostStorage) GetBucket(tx *bolt.Tx) (*bolt.Bucket, error) {
+	bucket := tx.Bucket([]byte(HostBucketName))
+	if bucket == nil {
+		return nil, fmt.Errorf("%w: %s", errBucketNotFound, HostBucketName)
+	}
+
+	return bucket, nil
+}
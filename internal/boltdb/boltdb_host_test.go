@@ -0,0 +1,41 @@
+package boltdb_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/boltdb"
+	"github.com/antgubarev/jobs/internal/host"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltDbHostStorage(t *testing.T) {
+	t.Parallel()
+	db := internal.NewTestBoltDB(t)
+	defer func() {
+		db.Close()
+		os.Remove(db.Path())
+	}()
+	store, err := boltdb.NewHostStorage(db)
+	require.NoError(t, err)
+
+	hosts, err := store.GetAll()
+	assert.NoError(t, err)
+	assert.Empty(t, hosts)
+
+	now := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+	known := &host.Host{Name: "node1", Labels: map[string]string{"zone": "a"}, Status: host.StatusHealthy, LastSeen: now}
+	require.NoError(t, store.Store(known))
+	require.NoError(t, store.Store(&host.Host{Name: "node2", Status: host.StatusHealthy}))
+	known.Status = host.StatusUnhealthy
+	require.NoError(t, store.Store(known))
+
+	hosts, err = store.GetAll()
+	assert.NoError(t, err)
+	require.Len(t, hosts, 2)
+	assert.Equal(t, *known, hosts[0])
+	assert.Equal(t, "node2", hosts[1].Name)
+}
@@ -1,8 +1,8 @@
 package boltdb
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 
 	"github.com/antgubarev/jobs/internal/job"
@@ -15,7 +15,7 @@ type JobStorage struct {
 
 const JobBucketName string = "jobs"
 
-var ErrJobNotFound = errors.New("job not found")
+var ErrJobNotFound = job.ErrJobNotFound
 
 func NewJobStorage(boltDB *bolt.DB) (*JobStorage, error) {
 	if err := CreateBucketIfNotExists(boltDB, JobBucketName); err != nil {
@@ -103,16 +103,14 @@ func (s *JobStorage) GetAll() ([]job.Job, error) {
 			return err
 		}
 
-		if err := bucket.ForEach(func(k, v []byte) error {
+		c := bucket.Cursor()
+		prefix := s.GetJobKey("")
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
 			var j job.Job
 			if err := json.Unmarshal(v, &j); err != nil {
-				return fmt.Errorf("getbyname: unmarshal job: %w", err)
+				return fmt.Errorf("getall: unmarshal job: %w", err)
 			}
 			result = append(result, j)
-
-			return nil
-		}); err != nil {
-			return fmt.Errorf("search job in bucket: %w", err)
 		}
 
 		return nil
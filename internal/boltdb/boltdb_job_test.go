@@ -169,6 +169,37 @@ func TestBoltDbStorageDeleteByName(t *testing.T) {
 	}
 }
 
+func TestBoltDbStorageGetAll(t *testing.T) {
+	t.Parallel()
+	store, testDB := newTestJobStorage(t)
+	defer func(db *bolt.DB) {
+		db.Close()
+		os.Remove(db.Path())
+	}(testDB)
+
+	jobs := []job.Job{
+		{Name: "job1", LockMode: job.HostLockMode},
+		{Name: "job2", LockMode: job.ClusterLockMode},
+	}
+	if err := storeJobs(jobs, store, testDB); err != nil {
+		t.Errorf("store fixtures %v", err)
+	}
+
+	executionStorage, err := boltdb.NewExecutionStorage(testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := executionStorage.Store(job.NewRunningExecution("job1")); err != nil {
+		t.Fatal(err)
+	}
+
+	allJobs, err := store.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, allJobs, 2)
+	assert.Equal(t, "job1", allJobs[0].Name)
+	assert.Equal(t, "job2", allJobs[1].Name)
+}
+
 func storeJobs(jobs []job.Job, store *boltdb.JobStorage, db *bolt.DB) error {
 	if err := db.Update(func(tx *bolt.Tx) error {
 		bucket, err := store.GetBucket(tx)
@@ -0,0 +1,166 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	bolt "go.etcd.io/bbolt"
+)
+
+const RejectionBucketName string = "rejections"
+
+const rejectionSeqLen = 8
+
+// RejectionStorage keeps lock rejections keyed by the job name, zero byte and the bucket sequence,
+// so rejections of the job are adjacent and in the order of appending.
+type RejectionStorage struct {
+	db *bolt.DB
+}
+
+func NewRejectionStorage(db *bolt.DB) (*RejectionStorage, error) {
+	if err := CreateBucketIfNotExists(db, RejectionBucketName); err != nil {
+		return nil, err
+	}
+
+	return &RejectionStorage{db: db}, nil
+}
+
+func (rs *RejectionStorage) Append(rejection job.LockRejection) error {
+	if err := rs.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := rs.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return fmt.Errorf("rejection append: next sequence: %w", err)
+		}
+
+		data, err := json.Marshal(rejection)
+		if err != nil {
+			return fmt.Errorf("rejection append: marshal: %w", err)
+		}
+
+		prefix := rejectionPrefix(rejection.Job)
+		key := make([]byte, len(prefix)+rejectionSeqLen)
+		copy(key, prefix)
+		binary.BigEndian.PutUint64(key[len(prefix):], seq)
+		if err := bucket.Put(key, data); err != nil {
+			return fmt.Errorf("rejection append: bucket put: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("Append: %w", err)
+	}
+
+	return nil
+}
+
+func (rs *RejectionStorage) Find(jobName string, since time.Time) ([]job.LockRejection, error) {
+	result := make([]job.LockRejection, 0)
+
+	if err := rs.db.View(func(tx *bolt.Tx) error {
+		bucket, err := rs.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		prefix := rejectionPrefix(jobName)
+		cursor := bucket.Cursor()
+		for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			var rejection job.LockRejection
+			if err := json.Unmarshal(v, &rejection); err != nil {
+				return fmt.Errorf("rejection find: unmarshal: %w", err)
+			}
+			if !rejection.At.Before(since) {
+				result = append(result, rejection)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("Find: %w", err)
+	}
+
+	return result, nil
+}
+
+func (rs *RejectionStorage) DeleteByJobName(jobName string) error {
+	if err := rs.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := rs.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		prefix := rejectionPrefix(jobName)
+		cursor := bucket.Cursor()
+		for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Seek(prefix) {
+			if err := bucket.Delete(k); err != nil {
+				return fmt.Errorf("rejection delete: %w", err)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("DeleteByJobName: %w", err)
+	}
+
+	return nil
+}
+
+func (rs *RejectionStorage) DeleteBefore(before time.Time) (int, error) {
+	deleted := 0
+	if err := rs.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := rs.GetBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		// Keys are collected first, deleting under the cursor skips the next key.
+		var keys [][]byte
+		if err := bucket.ForEach(func(k, v []byte) error {
+			var rejection job.LockRejection
+			if err := json.Unmarshal(v, &rejection); err != nil {
+				return fmt.Errorf("rejection delete: unmarshal: %w", err)
+			}
+			if rejection.At.Before(before) {
+				keys = append(keys, k)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return fmt.Errorf("rejection delete: %w", err)
+			}
+		}
+		deleted = len(keys)
+
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("DeleteBefore: %w", err)
+	}
+
+	return deleted, nil
+}
+
+func (rs *RejectionStorage) GetBucket(tx *bolt.Tx) (*bolt.Bucket, error) {
+	bucket := tx.Bucket([]byte(RejectionBucketName))
+	if bucket == nil {
+		return nil, fmt.Errorf("%w: %s", errBucketNotFound, RejectionBucketName)
+	}
+
+	return bucket, nil
+}
+
+func rejectionPrefix(jobName string) []byte {
+	return append([]byte(jobName), 0)
+}
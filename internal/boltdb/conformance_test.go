@@ -0,0 +1,77 @@
+package boltdb_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/audit"
+	"github.com/antgubarev/jobs/internal/audit/audittest"
+	"github.com/antgubarev/jobs/internal/boltdb"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/job/storagetest"
+)
+
+func TestJobStorageConformance(t *testing.T) {
+	t.Parallel()
+	storagetest.TestStorage(t, func(t *testing.T) job.Storage {
+		t.Helper()
+		store, db := newTestJobStorage(t)
+		t.Cleanup(func() {
+			db.Close()
+			os.Remove(db.Path())
+		})
+
+		return store
+	})
+}
+
+func TestExecutionStorageConformance(t *testing.T) {
+	t.Parallel()
+	storagetest.TestExecutionStorage(t, func(t *testing.T) job.ExecutionStorage {
+		t.Helper()
+		store, db := newTestExecutionStorage(t)
+		t.Cleanup(func() {
+			db.Close()
+			os.Remove(db.Path())
+		})
+
+		return store
+	})
+}
+
+func TestRejectionStorageConformance(t *testing.T) {
+	t.Parallel()
+	storagetest.TestRejectionStorage(t, func(t *testing.T) job.RejectionStorage {
+		t.Helper()
+		db := internal.NewTestBoltDB(t)
+		t.Cleanup(func() {
+			db.Close()
+			os.Remove(db.Path())
+		})
+		store, err := boltdb.NewRejectionStorage(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return store
+	})
+}
+
+func TestAuditStorageConformance(t *testing.T) {
+	t.Parallel()
+	audittest.TestStorage(t, func(t *testing.T) audit.Storage {
+		t.Helper()
+		db := internal.NewTestBoltDB(t)
+		t.Cleanup(func() {
+			db.Close()
+			os.Remove(db.Path())
+		})
+		store, err := boltdb.NewAuditStorage(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return store
+	})
+}
@@ -3,11 +3,16 @@ package boltdb
 import (
 	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	bolt "go.etcd.io/bbolt"
 )
 
-var errBucketNotFound = errors.New("bucket not found")
+var (
+	errBucketNotFound = errors.New("bucket not found")
+	ErrDatabaseInUse  = errors.New("database is in use, stop the server")
+)
 
 const BoltdbFileAccess = 0666
 
@@ -45,3 +50,57 @@ func CreateBucketIfNotExists(db *bolt.DB, bucketName string) error {
 
 	return nil
 }
+
+const (
+	compactTxMaxSize = 65536
+	// compactOpenTimeout limits waiting for the file lock held by the running server.
+	compactOpenTimeout = time.Second
+)
+
+// Compact rewrites the database file to reclaim the space of removed records.
+// The database must not be opened by another process. Returns file sizes before and after.
+func Compact(path string) (before int64, after int64, err error) {
+	srcInfo, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("compact: %w", err)
+	}
+
+	src, err := bolt.Open(path, BoltdbFileAccess, &bolt.Options{ReadOnly: true, Timeout: compactOpenTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return 0, 0, fmt.Errorf("compact %s: %w", path, ErrDatabaseInUse)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("compact: open source: %w", err)
+	}
+
+	tmpPath := path + ".compact"
+	dst, err := bolt.Open(tmpPath, srcInfo.Mode(), nil)
+	if err != nil {
+		src.Close()
+
+		return 0, 0, fmt.Errorf("compact: open destination: %w", err)
+	}
+
+	err = bolt.Compact(dst, src, compactTxMaxSize)
+	src.Close()
+	if err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+
+		return 0, 0, fmt.Errorf("compact: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return 0, 0, fmt.Errorf("compact: close destination: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, 0, fmt.Errorf("compact: replace file: %w", err)
+	}
+
+	dstInfo, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("compact: %w", err)
+	}
+
+	return srcInfo.Size(), dstInfo.Size(), nil
+}
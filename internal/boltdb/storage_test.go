@@ -0,0 +1,68 @@
+package boltdb_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/boltdb"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompact(t *testing.T) {
+	t.Parallel()
+	db := internal.NewTestBoltDB(t)
+	path := db.Path()
+	defer os.Remove(path)
+
+	store, err := boltdb.NewExecutionStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	executions := make([]*job.Execution, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		exec := job.NewRunningExecution("job")
+		exec.SetPid(i)
+		if err := store.Store(exec); err != nil {
+			t.Fatal(err)
+		}
+		executions = append(executions, exec)
+	}
+	for _, exec := range executions[1:] {
+		if err := store.Delete(exec.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	before, after, err := boltdb.Compact(path)
+	assert.NoError(t, err)
+	assert.Less(t, after, before)
+
+	db, err = boltdb.NewBoltDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store, err = boltdb.NewExecutionStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec, err := store.GetByID(executions[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, executions[0].ID, exec.ID)
+}
+
+func TestCompactInUse(t *testing.T) {
+	t.Parallel()
+	db := internal.NewTestBoltDB(t)
+	defer func() {
+		db.Close()
+		os.Remove(db.Path())
+	}()
+
+	_, _, err := boltdb.Compact(db.Path())
+	assert.True(t, errors.Is(err, boltdb.ErrDatabaseInUse), "unexpected error: %v", err)
+}
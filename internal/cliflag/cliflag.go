@@ -0,0 +1,50 @@
+// Package cliflag contains flags shared by command line tools.
+package cliflag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+var ErrInvalidValue = errors.New("invalid value")
+
+// MapValue is the repeated `--flag name=value` flag, e.g. params or labels.
+type MapValue struct {
+	values map[string]string
+}
+
+func (m *MapValue) Set(value string) error {
+	i := strings.IndexByte(value, '=')
+	if i <= 0 {
+		return fmt.Errorf("%w: must be `name=value`: %s", ErrInvalidValue, value)
+	}
+	if m.values == nil {
+		m.values = make(map[string]string)
+	}
+	m.values[value[:i]] = value[i+1:]
+
+	return nil
+}
+
+func (m *MapValue) String() string {
+	values := make([]string, 0, len(m.values))
+	for name, value := range m.values {
+		values = append(values, name+"="+value)
+	}
+	sort.Strings(values)
+
+	return strings.Join(values, ",")
+}
+
+// Map returns values of the MapValue flag, nil if the flag isn't set.
+func Map(ctx *cli.Context, name string) map[string]string {
+	if value, ok := ctx.Generic(name).(*MapValue); ok {
+		return value.values
+	}
+
+	return nil
+}
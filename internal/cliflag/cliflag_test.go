@@ -0,0 +1,22 @@
+package cliflag_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/cliflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapValue(t *testing.T) {
+	t.Parallel()
+	value := &cliflag.MapValue{}
+	assert.NoError(t, value.Set("zone=eu"))
+	assert.NoError(t, value.Set("env=a=b"))
+	assert.NoError(t, value.Set("empty="))
+	assert.Equal(t, "empty=,env=a=b,zone=eu", value.String())
+
+	for _, invalid := range []string{"zone", "=eu", ""} {
+		assert.True(t, errors.Is(value.Set(invalid), cliflag.ErrInvalidValue), invalid)
+	}
+}
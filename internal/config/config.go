@@ -0,0 +1,281 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StorageBolt   = "bolt"
+	StorageMemory = "memory"
+
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+
+	EnvPrefix = "JOBS_"
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid config")
+	errUnknownFormat = errors.New("unknown config format")
+)
+
+// Config is the server configuration. Values are taken from defaults, the config file,
+// JOBS_* environment variables and command line flags, later ones override earlier.
+type Config struct {
+	Listen          string        `yaml:"listen"`
+	LogLevel        string        `yaml:"logLevel"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	Storage         Storage       `yaml:"storage"`
+	TLS             TLS           `yaml:"tls"`
+	Auth            Auth          `yaml:"auth"`
+	Retention       Retention     `yaml:"retention"`
+	Reaper          Reaper        `yaml:"reaper"`
+	Hosts           Hosts         `yaml:"hosts"`
+	// Resources are capacities of shared resources by name, resources which aren't listed are mutexes.
+	Resources map[string]int `yaml:"resources"`
+}
+
+type Storage struct {
+	Type string `yaml:"type"`
+	Path string `yaml:"path"`
+}
+
+type TLS struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+}
+
+func (t *TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Auth maps actor names to their bearer tokens. Empty tokens disable authentication.
+type Auth struct {
+	Tokens map[string]string `yaml:"tokens"`
+}
+
+type Retention struct {
+	KeepLast      int           `yaml:"keepLast"`
+	KeepFor       time.Duration `yaml:"keepFor"`
+	KeepFailedFor time.Duration `yaml:"keepFailedFor"`
+}
+
+// Reaper holds intervals of the background jobs, zero disables the job.
+type Reaper struct {
+	PruneInterval        time.Duration `yaml:"pruneInterval"`
+	HostCheckInterval    time.Duration `yaml:"hostCheckInterval"`
+	OverdueCheckInterval time.Duration `yaml:"overdueCheckInterval"`
+}
+
+// Hosts configures health tracking of hosts registered by agents. A host is unhealthy if it hasn't sent
+// a heartbeat for HeartbeatTimeout, its running executions are marked as lost.
+type Hosts struct {
+	HeartbeatTimeout time.Duration `yaml:"heartbeatTimeout"`
+}
+
+func Default() *Config {
+	return &Config{
+		Listen:          ":8080",
+		LogLevel:        LogLevelInfo,
+		ShutdownTimeout: 5 * time.Second,
+		Storage: Storage{
+			Type: StorageBolt,
+			Path: "./data.db",
+		},
+		Auth: Auth{Tokens: map[string]string{}},
+		Reaper: Reaper{
+			PruneInterval:        time.Hour,
+			HostCheckInterval:    10 * time.Second,
+			OverdueCheckInterval: time.Minute,
+		},
+		Hosts: Hosts{
+			HeartbeatTimeout: 90 * time.Second,
+		},
+		Resources: map[string]int{},
+	}
+}
+
+// LoadFile reads yaml (.yaml, .yml) or toml (.toml) config file over the current values.
+func (c *Config) LoadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	case ".toml":
+		// Toml is decoded the same way as yaml to check unknown fields and parse durations.
+		var values map[string]interface{}
+		if err := toml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("load config %s: %w", path, err)
+		}
+		if data, err = yaml.Marshal(values); err != nil {
+			return fmt.Errorf("load config %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("load config %s: %w, use .yaml, .yml or .toml", path, errUnknownFormat)
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("load config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// LoadEnv overrides values with JOBS_* variables from environ (os.Environ format).
+func (c *Config) LoadEnv(environ []string) error {
+	setters := c.envSetters()
+	for _, item := range environ {
+		name, value, found := cut(item, "=")
+		if !found || !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		setter, ok := setters[name]
+		if !ok {
+			continue
+		}
+		if err := setter(value); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, name, err)
+		}
+	}
+
+	return nil
+}
+
+// EnvNames returns names of all supported environment variables.
+func (c *Config) EnvNames() []string {
+	names := make([]string, 0)
+	for name := range c.envSetters() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (c *Config) envSetters() map[string]func(string) error {
+	return map[string]func(string) error{
+		"JOBS_LISTEN":                        stringSetter(&c.Listen),
+		"JOBS_LOG_LEVEL":                     stringSetter(&c.LogLevel),
+		"JOBS_SHUTDOWN_TIMEOUT":              durationSetter(&c.ShutdownTimeout),
+		"JOBS_STORAGE_TYPE":                  stringSetter(&c.Storage.Type),
+		"JOBS_STORAGE_PATH":                  stringSetter(&c.Storage.Path),
+		"JOBS_TLS_CERT_FILE":                 stringSetter(&c.TLS.CertFile),
+		"JOBS_TLS_KEY_FILE":                  stringSetter(&c.TLS.KeyFile),
+		"JOBS_AUTH_TOKENS":                   tokensSetter(&c.Auth.Tokens),
+		"JOBS_RETENTION_KEEP_LAST":           intSetter(&c.Retention.KeepLast),
+		"JOBS_RETENTION_KEEP_FOR":            durationSetter(&c.Retention.KeepFor),
+		"JOBS_RETENTION_KEEP_FAILED_FOR":     durationSetter(&c.Retention.KeepFailedFor),
+		"JOBS_REAPER_PRUNE_INTERVAL":         durationSetter(&c.Reaper.PruneInterval),
+		"JOBS_REAPER_HOST_CHECK_INTERVAL":    durationSetter(&c.Reaper.HostCheckInterval),
+		"JOBS_REAPER_OVERDUE_CHECK_INTERVAL": durationSetter(&c.Reaper.OverdueCheckInterval),
+		"JOBS_HOSTS_HEARTBEAT_TIMEOUT":       durationSetter(&c.Hosts.HeartbeatTimeout),
+		"JOBS_RESOURCES":                     resourcesSetter(&c.Resources),
+	}
+}
+
+func (c *Config) Validate() error {
+	if c.Listen == "" {
+		return fmt.Errorf("%w: listen: must not be empty", ErrInvalidConfig)
+	}
+
+	switch c.LogLevel {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		return fmt.Errorf("%w: logLevel: must be `debug`, `info`, `warn` or `error`, got `%s`",
+			ErrInvalidConfig, c.LogLevel)
+	}
+
+	switch c.Storage.Type {
+	case StorageMemory:
+	case StorageBolt:
+		if c.Storage.Path == "" {
+			return fmt.Errorf("%w: storage.path: must not be empty for `bolt` storage", ErrInvalidConfig)
+		}
+	default:
+		return fmt.Errorf("%w: storage.type: must be `bolt` or `memory`, got `%s`", ErrInvalidConfig, c.Storage.Type)
+	}
+
+	if c.TLS.Enabled() {
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+			return fmt.Errorf("%w: tls: both certFile and keyFile are required", ErrInvalidConfig)
+		}
+		for _, file := range []string{c.TLS.CertFile, c.TLS.KeyFile} {
+			if _, err := os.Stat(file); err != nil {
+				return fmt.Errorf("%w: tls: %v", ErrInvalidConfig, err)
+			}
+		}
+	}
+
+	for actor, token := range c.Auth.Tokens {
+		if token == "" || actor == "" {
+			return fmt.Errorf("%w: auth.tokens: token and actor name must not be empty", ErrInvalidConfig)
+		}
+	}
+
+	durations := map[string]time.Duration{
+		"shutdownTimeout":             c.ShutdownTimeout,
+		"retention.keepFor":           c.Retention.KeepFor,
+		"retention.keepFailedFor":     c.Retention.KeepFailedFor,
+		"reaper.pruneInterval":        c.Reaper.PruneInterval,
+		"reaper.hostCheckInterval":    c.Reaper.HostCheckInterval,
+		"reaper.overdueCheckInterval": c.Reaper.OverdueCheckInterval,
+		"hosts.heartbeatTimeout":      c.Hosts.HeartbeatTimeout,
+	}
+	for name, value := range durations {
+		if value < 0 {
+			return fmt.Errorf("%w: %s: must not be negative", ErrInvalidConfig, name)
+		}
+	}
+	if c.Retention.KeepLast < 0 {
+		return fmt.Errorf("%w: retention.keepLast: must not be negative", ErrInvalidConfig)
+	}
+	for name, capacity := range c.Resources {
+		if capacity < 1 {
+			return fmt.Errorf("%w: resources.%s: capacity must be positive", ErrInvalidConfig, name)
+		}
+	}
+
+	return nil
+}
+
+// Print writes the config as yaml, auth tokens are masked.
+func (c *Config) Print() ([]byte, error) {
+	masked := *c
+	masked.Auth.Tokens = make(map[string]string, len(c.Auth.Tokens))
+	for actor, token := range c.Auth.Tokens {
+		masked.Auth.Tokens[actor] = maskToken(token)
+	}
+
+	data, err := yaml.Marshal(&masked)
+	if err != nil {
+		return nil, fmt.Errorf("print config: %w", err)
+	}
+
+	return data, nil
+}
+
+func maskToken(token string) string {
+	const visible = 4
+	if len(token) <= visible {
+		return strings.Repeat("*", len(token))
+	}
+
+	return token[:visible] + strings.Repeat("*", len(token)-visible)
+}
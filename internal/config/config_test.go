@@ -0,0 +1,189 @@
+package config_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFile(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "jobs.yaml",
+			content: `
+listen: ":9090"
+storage:
+  type: memory
+auth:
+  tokens:
+    alice: secret
+retention:
+  keepLast: 10
+  keepFor: 72h
+reaper:
+  pruneInterval: 10m
+`,
+		},
+		{
+			name: "toml",
+			file: "jobs.toml",
+			content: `
+listen = ":9090" # api
+[storage]
+type = 'memory'
+
+[auth.tokens]
+alice = "secret"
+
+[retention]
+keepLast = 10
+keepFor = "72h"
+
+[reaper]
+pruneInterval = "10m"
+`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), testCase.file)
+			assert.NoError(t, ioutil.WriteFile(path, []byte(testCase.content), 0o600))
+
+			cfg := config.Default()
+			assert.NoError(t, cfg.LoadFile(path))
+			assert.Equal(t, ":9090", cfg.Listen)
+			assert.Equal(t, config.StorageMemory, cfg.Storage.Type)
+			assert.Equal(t, map[string]string{"alice": "secret"}, cfg.Auth.Tokens)
+			assert.Equal(t, 10, cfg.Retention.KeepLast)
+			assert.Equal(t, 72*time.Hour, cfg.Retention.KeepFor)
+			assert.Equal(t, 10*time.Minute, cfg.Reaper.PruneInterval)
+			assert.Equal(t, 5*time.Second, cfg.ShutdownTimeout)
+			assert.NoError(t, cfg.Validate())
+		})
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "unknown field", file: "jobs.yaml", content: "lisen: :8080"},
+		{name: "unknown format", file: "jobs.json", content: "{}"},
+		{name: "toml without value", file: "jobs.toml", content: "listen"},
+		{name: "toml invalid value", file: "jobs.toml", content: "listen = :8080"},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), testCase.file)
+			assert.NoError(t, ioutil.WriteFile(path, []byte(testCase.content), 0o600))
+
+			assert.Error(t, config.Default().LoadFile(path))
+		})
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Parallel()
+	cfg := config.Default()
+	err := cfg.LoadEnv([]string{
+		"PATH=/bin",
+		"JOBS_LISTEN=:9091",
+		"JOBS_SHUTDOWN_TIMEOUT=30s",
+		"JOBS_AUTH_TOKENS=alice:secret, bob:other",
+		"JOBS_RETENTION_KEEP_LAST=3",
+		"JOBS_HOSTS_HEARTBEAT_TIMEOUT=2m",
+		"JOBS_REAPER_OVERDUE_CHECK_INTERVAL=30s",
+		"JOBS_RESOURCES=db=1, gpu=4",
+		"JOBS_UNKNOWN=1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ":9091", cfg.Listen)
+	assert.Equal(t, 30*time.Second, cfg.ShutdownTimeout)
+	assert.Equal(t, map[string]string{"alice": "secret", "bob": "other"}, cfg.Auth.Tokens)
+	assert.Equal(t, 3, cfg.Retention.KeepLast)
+	assert.Equal(t, 2*time.Minute, cfg.Hosts.HeartbeatTimeout)
+	assert.Equal(t, 30*time.Second, cfg.Reaper.OverdueCheckInterval)
+	assert.Equal(t, map[string]int{"db": 1, "gpu": 4}, cfg.Resources)
+
+	err = config.Default().LoadEnv([]string{"JOBS_RETENTION_KEEP_LAST=many"})
+	assert.True(t, errors.Is(err, config.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "JOBS_RETENTION_KEEP_LAST")
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name   string
+		modify func(cfg *config.Config)
+		field  string
+	}{
+		{name: "default", modify: func(cfg *config.Config) {}},
+		{name: "empty listen", modify: func(cfg *config.Config) { cfg.Listen = "" }, field: "listen"},
+		{name: "log level", modify: func(cfg *config.Config) { cfg.LogLevel = "trace" }, field: "logLevel"},
+		{name: "storage type", modify: func(cfg *config.Config) { cfg.Storage.Type = "redis" }, field: "storage.type"},
+		{name: "bolt path", modify: func(cfg *config.Config) { cfg.Storage.Path = "" }, field: "storage.path"},
+		{name: "tls pair", modify: func(cfg *config.Config) { cfg.TLS.CertFile = "cert.pem" }, field: "tls"},
+		{name: "tls files", modify: func(cfg *config.Config) {
+			cfg.TLS.CertFile, cfg.TLS.KeyFile = "missing.pem", "missing.key"
+		}, field: "tls"},
+		{name: "empty token", modify: func(cfg *config.Config) {
+			cfg.Auth.Tokens["alice"] = ""
+		}, field: "auth.tokens"},
+		{name: "negative keep last", modify: func(cfg *config.Config) { cfg.Retention.KeepLast = -1 }, field: "retention.keepLast"},
+		{name: "negative interval", modify: func(cfg *config.Config) {
+			cfg.Reaper.PruneInterval = -time.Second
+		}, field: "reaper.pruneInterval"},
+		{name: "resource capacity", modify: func(cfg *config.Config) {
+			cfg.Resources["db"] = 0
+		}, field: "resources.db"},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := config.Default()
+			testCase.modify(cfg)
+			err := cfg.Validate()
+			if testCase.field == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+			assert.True(t, errors.Is(err, config.ErrInvalidConfig))
+			assert.Contains(t, err.Error(), testCase.field)
+		})
+	}
+}
+
+func TestPrintMasksTokens(t *testing.T) {
+	t.Parallel()
+	cfg := config.Default()
+	cfg.Auth.Tokens["alice"] = "secret-token"
+
+	data, err := cfg.Print()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "alice: secr********")
+	assert.NotContains(t, string(data), "secret-token")
+	assert.Equal(t, "secret-token", cfg.Auth.Tokens["alice"])
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errInvalidTokens    = errors.New("expected comma separated `actor:token` pairs")
+	errInvalidResources = errors.New("expected comma separated `name=capacity` pairs")
+)
+
+func stringSetter(target *string) func(string) error {
+	return func(value string) error {
+		*target = value
+
+		return nil
+	}
+}
+
+func intSetter(target *int) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("parse int: %w", err)
+		}
+		*target = parsed
+
+		return nil
+	}
+}
+
+func durationSetter(target *time.Duration) func(string) error {
+	return func(value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("parse duration: %w", err)
+		}
+		*target = parsed
+
+		return nil
+	}
+}
+
+// tokensSetter parses `actor:token,actor2:token2`.
+func tokensSetter(target *map[string]string) func(string) error {
+	return func(value string) error {
+		tokens := map[string]string{}
+		for _, pair := range strings.Split(value, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			actor, token, found := cut(pair, ":")
+			if !found || actor == "" || token == "" {
+				return errInvalidTokens
+			}
+			tokens[actor] = token
+		}
+		*target = tokens
+
+		return nil
+	}
+}
+
+// resourcesSetter parses `name=capacity,name2=capacity2`.
+func resourcesSetter(target *map[string]int) func(string) error {
+	return func(value string) error {
+		resources := map[string]int{}
+		for _, pair := range strings.Split(value, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			name, capacity, found := cut(pair, "=")
+			if !found || name == "" {
+				return errInvalidResources
+			}
+			parsed, err := strconv.Atoi(capacity)
+			if err != nil {
+				return errInvalidResources
+			}
+			resources[name] = parsed
+		}
+		*target = resources
+
+		return nil
+	}
+}
+
+// cut is strings.Cut, which isn't available in go 1.17.
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
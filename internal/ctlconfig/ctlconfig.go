@@ -0,0 +1,271 @@
+// Package ctlconfig is the jobsctl configuration: named contexts of servers kept in the config file,
+// one of them is current. The context in use is overridden by JOBSCTL_* environment variables and flags.
+package ctlconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvPrefix = "JOBSCTL_"
+
+	// NamespaceLabel is the label of jobs which holds their namespace.
+	NamespaceLabel = "namespace"
+
+	// DefaultServer is used when neither the context nor the environment sets the server.
+	DefaultServer = "http://localhost:8080"
+)
+
+var (
+	ErrInvalidConfig   = errors.New("invalid config")
+	ErrContextNotFound = errors.New("context not found")
+)
+
+// Config is the content of the config file.
+type Config struct {
+	CurrentContext string    `json:"currentContext,omitempty" yaml:"currentContext,omitempty"`
+	Contexts       []Context `json:"contexts,omitempty" yaml:"contexts,omitempty"`
+}
+
+// Context is a named set of connection settings of a server.
+type Context struct {
+	Name   string `json:"name" yaml:"name"`
+	Server string `json:"server,omitempty" yaml:"server,omitempty"`
+	Token  string `json:"token,omitempty" yaml:"token,omitempty"`
+	// Namespace is the value of the `namespace` label of jobs, jobsctl lists only jobs of the namespace
+	// and adds the label to created jobs.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	TLS       TLS    `json:"tls" yaml:"tls,omitempty"`
+}
+
+// TLS settings of the connection. CAFile is added to trusted roots of the system,
+// CertFile and KeyFile are the client certificate.
+type TLS struct {
+	CAFile             string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+}
+
+// DefaultPath returns $JOBSCTL_CONFIG or config.yaml in jobsctl dir of $XDG_CONFIG_HOME (~/.config by default).
+func DefaultPath() string {
+	if path := os.Getenv(EnvPrefix + "CONFIG"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(".config", "jobsctl", "config.yaml")
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "jobsctl", "config.yaml")
+}
+
+// Load reads the config file, a missing file is an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("load config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("load config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config file, it's readable only by the owner because of tokens.
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Config) Validate() error {
+	names := make(map[string]bool, len(c.Contexts))
+	for i, context := range c.Contexts {
+		if context.Name == "" {
+			return fmt.Errorf("%w: contexts[%d]: name must not be empty", ErrInvalidConfig, i)
+		}
+		if names[context.Name] {
+			return fmt.Errorf("%w: contexts[%d]: duplicated name `%s`", ErrInvalidConfig, i, context.Name)
+		}
+		names[context.Name] = true
+	}
+
+	return nil
+}
+
+// Context returns the context by its name.
+func (c *Config) Context(name string) (*Context, error) {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: `%s`", ErrContextNotFound, name)
+}
+
+// SetContext adds the context or replaces the one with the same name.
+func (c *Config) SetContext(context Context) {
+	if existing, err := c.Context(context.Name); err == nil {
+		*existing = context
+
+		return
+	}
+	c.Contexts = append(c.Contexts, context)
+}
+
+// DeleteContext deletes the context, the current context is unset if it's deleted.
+func (c *Config) DeleteContext(name string) error {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name != name {
+			continue
+		}
+		c.Contexts = append(c.Contexts[:i], c.Contexts[i+1:]...)
+		if c.CurrentContext == name {
+			c.CurrentContext = ""
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: `%s`", ErrContextNotFound, name)
+}
+
+// UseContext makes the context current.
+func (c *Config) UseContext(name string) error {
+	if _, err := c.Context(name); err != nil {
+		return err
+	}
+	c.CurrentContext = name
+
+	return nil
+}
+
+// Resolve returns settings of the context with the given name, JOBSCTL_CONTEXT or the current one
+// overridden by JOBSCTL_* variables from environ (os.Environ format). Without contexts it's the default server.
+func (c *Config) Resolve(name string, environ []string) (Context, error) {
+	env := make(map[string]string)
+	for _, item := range environ {
+		if i := strings.Index(item, "="); i >= 0 && strings.HasPrefix(item, EnvPrefix) {
+			env[item[:i]] = item[i+1:]
+		}
+	}
+	if name == "" {
+		name = env[EnvPrefix+"CONTEXT"]
+	}
+	if name == "" {
+		name = c.CurrentContext
+	}
+
+	var resolved Context
+	if name != "" {
+		context, err := c.Context(name)
+		if err != nil {
+			return Context{}, err
+		}
+		resolved = *context
+	}
+
+	for variable, setter := range resolved.envSetters() {
+		value, ok := env[variable]
+		if !ok {
+			continue
+		}
+		if err := setter(value); err != nil {
+			return Context{}, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, variable, err)
+		}
+	}
+	if resolved.Server == "" {
+		resolved.Server = DefaultServer
+	}
+	resolved.Server = NormalizeServer(resolved.Server)
+
+	return resolved, nil
+}
+
+// EnvNames returns names of all supported environment variables.
+func EnvNames() []string {
+	return []string{
+		EnvPrefix + "CONFIG",
+		EnvPrefix + "CONTEXT",
+		EnvPrefix + "SERVER",
+		EnvPrefix + "TOKEN",
+		EnvPrefix + "NAMESPACE",
+		EnvPrefix + "TLS_CA_FILE",
+		EnvPrefix + "TLS_CERT_FILE",
+		EnvPrefix + "TLS_KEY_FILE",
+		EnvPrefix + "TLS_INSECURE_SKIP_VERIFY",
+	}
+}
+
+func (c *Context) envSetters() map[string]func(string) error {
+	stringSetter := func(target *string) func(string) error {
+		return func(value string) error {
+			*target = value
+
+			return nil
+		}
+	}
+
+	return map[string]func(string) error{
+		EnvPrefix + "SERVER":        stringSetter(&c.Server),
+		EnvPrefix + "TOKEN":         stringSetter(&c.Token),
+		EnvPrefix + "NAMESPACE":     stringSetter(&c.Namespace),
+		EnvPrefix + "TLS_CA_FILE":   stringSetter(&c.TLS.CAFile),
+		EnvPrefix + "TLS_CERT_FILE": stringSetter(&c.TLS.CertFile),
+		EnvPrefix + "TLS_KEY_FILE":  stringSetter(&c.TLS.KeyFile),
+		EnvPrefix + "TLS_INSECURE_SKIP_VERIFY": func(value string) error {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("parse bool: %w", err)
+			}
+			c.TLS.InsecureSkipVerify = parsed
+
+			return nil
+		},
+	}
+}
+
+// NormalizeServer adds `http://` to the server address without scheme and drops trailing slashes,
+// so `localhost:8080` is accepted as well.
+func NormalizeServer(server string) string {
+	if !strings.Contains(server, "://") {
+		server = "http://" + server
+	}
+
+	return strings.TrimRight(server, "/")
+}
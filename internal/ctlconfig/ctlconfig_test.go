@@ -0,0 +1,228 @@
+package ctlconfig_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/ctlconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	path := writeConfig(t, `
+currentContext: prod
+contexts:
+  - name: local
+    server: localhost:8080
+  - name: prod
+    server: https://jobs.example.com
+    token: secret
+    tls:
+      caFile: /etc/jobs/ca.pem
+`)
+
+	cfg, err := ctlconfig.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", cfg.CurrentContext)
+	require.Len(t, cfg.Contexts, 2)
+	assert.Equal(t, ctlconfig.Context{
+		Name:   "prod",
+		Server: "https://jobs.example.com",
+		Token:  "secret",
+		TLS:    ctlconfig.TLS{CAFile: "/etc/jobs/ca.pem"},
+	}, cfg.Contexts[1])
+}
+
+func TestLoadMissingOrEmpty(t *testing.T) {
+	t.Parallel()
+	cfg, err := ctlconfig.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, &ctlconfig.Config{}, cfg)
+
+	cfg, err = ctlconfig.Load(writeConfig(t, ""))
+	require.NoError(t, err)
+	assert.Equal(t, &ctlconfig.Config{}, cfg)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown field", content: "contexts:\n  - name: a\n    url: localhost\n"},
+		{name: "empty name", content: "contexts:\n  - server: localhost\n"},
+		{name: "duplicated name", content: "contexts:\n  - name: a\n  - name: a\n"},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ctlconfig.Load(writeConfig(t, testCase.content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "jobsctl", "config.yaml")
+	cfg := &ctlconfig.Config{}
+	cfg.SetContext(ctlconfig.Context{Name: "local", Server: "http://localhost:8080"})
+	cfg.SetContext(ctlconfig.Context{Name: "prod", Server: "https://jobs.example.com", Token: "secret"})
+	cfg.SetContext(ctlconfig.Context{Name: "local", Server: "http://127.0.0.1:8080"})
+	require.NoError(t, cfg.UseContext("prod"))
+	require.NoError(t, cfg.Save(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := ctlconfig.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, loaded)
+	assert.Len(t, loaded.Contexts, 2)
+	assert.Equal(t, "http://127.0.0.1:8080", loaded.Contexts[0].Server)
+}
+
+func TestUseAndDeleteContext(t *testing.T) {
+	t.Parallel()
+	cfg := &ctlconfig.Config{Contexts: []ctlconfig.Context{{Name: "local"}, {Name: "prod"}}}
+
+	assert.True(t, errors.Is(cfg.UseContext("stage"), ctlconfig.ErrContextNotFound))
+	require.NoError(t, cfg.UseContext("prod"))
+	assert.Equal(t, "prod", cfg.CurrentContext)
+
+	require.NoError(t, cfg.DeleteContext("local"))
+	assert.Equal(t, "prod", cfg.CurrentContext)
+	require.NoError(t, cfg.DeleteContext("prod"))
+	assert.Empty(t, cfg.CurrentContext)
+	assert.Empty(t, cfg.Contexts)
+	assert.True(t, errors.Is(cfg.DeleteContext("prod"), ctlconfig.ErrContextNotFound))
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	cfg := &ctlconfig.Config{
+		CurrentContext: "local",
+		Contexts: []ctlconfig.Context{
+			{Name: "local", Server: "localhost:8080"},
+			{Name: "prod", Server: "https://jobs.example.com/", Token: "secret", Namespace: "billing"},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		config   *ctlconfig.Config
+		context  string
+		environ  []string
+		expected ctlconfig.Context
+		err      error
+	}{
+		{
+			name:     "no contexts",
+			config:   &ctlconfig.Config{},
+			expected: ctlconfig.Context{Server: ctlconfig.DefaultServer},
+		},
+		{
+			name:     "current",
+			config:   cfg,
+			expected: ctlconfig.Context{Name: "local", Server: "http://localhost:8080"},
+		},
+		{
+			name:    "by name",
+			config:  cfg,
+			context: "prod",
+			environ: []string{"JOBSCTL_CONTEXT=local"},
+			expected: ctlconfig.Context{
+				Name: "prod", Server: "https://jobs.example.com", Token: "secret", Namespace: "billing",
+			},
+		},
+		{
+			name:    "by env",
+			config:  cfg,
+			environ: []string{"JOBSCTL_CONTEXT=prod"},
+			expected: ctlconfig.Context{
+				Name: "prod", Server: "https://jobs.example.com", Token: "secret", Namespace: "billing",
+			},
+		},
+		{
+			name:   "env overrides",
+			config: cfg,
+			environ: []string{
+				"JOBSCTL_SERVER=jobs.internal:8443", "JOBSCTL_TOKEN=token", "JOBSCTL_TLS_CA_FILE=ca.pem",
+				"JOBSCTL_TLS_INSECURE_SKIP_VERIFY=true", "JOBS_TOKEN=ignored", "JOBSCTL_UNKNOWN=1",
+				"JOBSCTL_NAMESPACE=reports",
+			},
+			expected: ctlconfig.Context{
+				Name:      "local",
+				Server:    "http://jobs.internal:8443",
+				Token:     "token",
+				Namespace: "reports",
+				TLS:       ctlconfig.TLS{CAFile: "ca.pem", InsecureSkipVerify: true},
+			},
+		},
+		{name: "unknown context", config: cfg, context: "stage", err: ctlconfig.ErrContextNotFound},
+		{
+			name:    "invalid env",
+			config:  cfg,
+			environ: []string{"JOBSCTL_TLS_INSECURE_SKIP_VERIFY=maybe"},
+			err:     ctlconfig.ErrInvalidConfig,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			resolved, err := testCase.config.Resolve(testCase.context, testCase.environ)
+			if testCase.err != nil {
+				assert.True(t, errors.Is(err, testCase.err), "unexpected error: %v", err)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, resolved)
+		})
+	}
+}
+
+func TestNormalizeServer(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "http://localhost:8080", ctlconfig.NormalizeServer("localhost:8080"))
+	assert.Equal(t, "https://jobs.example.com", ctlconfig.NormalizeServer("https://jobs.example.com/"))
+	assert.Equal(t, "http://localhost:8080/api", ctlconfig.NormalizeServer("http://localhost:8080/api"))
+}
+
+func TestTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := (&ctlconfig.TLS{}).Config()
+	require.NoError(t, err)
+	assert.Nil(t, config)
+
+	config, err = (&ctlconfig.TLS{InsecureSkipVerify: true}).Config()
+	require.NoError(t, err)
+	assert.True(t, config.InsecureSkipVerify)
+
+	_, err = (&ctlconfig.TLS{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).Config()
+	assert.Error(t, err)
+	_, err = (&ctlconfig.TLS{CAFile: writeConfig(t, "not a certificate")}).Config()
+	assert.Error(t, err)
+	_, err = (&ctlconfig.TLS{CertFile: "cert.pem"}).Config()
+	assert.Error(t, err)
+}
@@ -0,0 +1,523 @@
+// Package dashboard is the model of `jobsctl top`: it keeps the polled state of jobs, handles keys
+// and renders the screen. Polling, actions and the terminal are handled by the caller.
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/google/uuid"
+)
+
+// Row is the job with its running executions and the last finished one.
+type Row struct {
+	Job     job.Job
+	Running []job.Execution
+	Last    *job.Execution
+}
+
+// Snapshot is the state of jobs polled at once.
+type Snapshot struct {
+	Rows []Row
+	At   time.Time
+}
+
+// NewSnapshot groups running executions by jobs, the last finished execution of the job is found in recent ones.
+func NewSnapshot(jobs []job.Job, running []job.Execution, recent []job.Execution, at time.Time) *Snapshot {
+	rows := make([]Row, 0, len(jobs))
+	index := make(map[string]int, len(jobs))
+	sorted := append([]job.Job{}, jobs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	for _, jb := range sorted {
+		index[jb.Name] = len(rows)
+		rows = append(rows, Row{Job: jb})
+	}
+
+	for _, execution := range running {
+		if i, ok := index[execution.Job]; ok {
+			rows[i].Running = append(rows[i].Running, execution)
+		}
+	}
+	for i := range rows {
+		executions := rows[i].Running
+		sort.Slice(executions, func(a, b int) bool {
+			if host(&executions[a]) != host(&executions[b]) {
+				return host(&executions[a]) < host(&executions[b])
+			}
+
+			return executions[a].StartedAt.Before(executions[b].StartedAt)
+		})
+	}
+
+	for i := range recent {
+		execution := &recent[i]
+		row, ok := index[execution.Job]
+		if !ok || execution.Status == job.StatusRunning || execution.FinishedAt == nil {
+			continue
+		}
+		last := rows[row].Last
+		if last == nil || execution.FinishedAt.After(*last.FinishedAt) {
+			rows[row].Last = execution
+		}
+	}
+
+	return &Snapshot{Rows: rows, At: at}
+}
+
+type ActionKind int
+
+const (
+	NoAction ActionKind = iota
+	Quit
+	Refresh
+	Pause
+	Resume
+	Stop
+	Logs
+)
+
+// Action is requested by keys, the caller does it and reports the result with SetMessage.
+type Action struct {
+	Kind      ActionKind
+	Job       string
+	Execution uuid.UUID
+}
+
+type view int
+
+const (
+	jobsView view = iota
+	logsView
+)
+
+// Dashboard is the state of the screen.
+type Dashboard struct {
+	title    string
+	snapshot *Snapshot
+	pollErr  error
+	message  string
+
+	view view
+	// selected is the index of the selected job, execution is the index of its selected running execution,
+	// it's -1 while the jobs list has the focus.
+	selected  int
+	execution int
+	// confirmStop is set while the stop of the selected execution waits for confirmation.
+	confirmStop bool
+
+	logsOf *job.Execution
+	logs   string
+}
+
+func New(title string) *Dashboard {
+	return &Dashboard{title: title, execution: -1}
+}
+
+// Update replaces the snapshot, the selection follows the selected job and execution by their names and ids.
+func (d *Dashboard) Update(snapshot *Snapshot, err error) {
+	d.pollErr = err
+	if snapshot == nil {
+		return
+	}
+
+	jobName, executionID := "", uuid.Nil
+	if row := d.selectedRow(); row != nil {
+		jobName = row.Job.Name
+	}
+	if execution := d.selectedExecution(); execution != nil {
+		executionID = execution.ID
+	}
+
+	d.snapshot = snapshot
+	d.selected, d.execution = 0, -1
+	for i, row := range snapshot.Rows {
+		if row.Job.Name != jobName {
+			continue
+		}
+		d.selected = i
+		for j, execution := range row.Running {
+			if execution.ID == executionID {
+				d.execution = j
+			}
+		}
+	}
+	if d.execution < 0 {
+		d.confirmStop = false
+	}
+}
+
+// SetMessage shows the result of the action in the status line.
+func (d *Dashboard) SetMessage(message string) {
+	d.message = message
+}
+
+// LogsOf returns the execution which logs are shown or nil.
+func (d *Dashboard) LogsOf() *job.Execution {
+	if d.view != logsView {
+		return nil
+	}
+
+	return d.logsOf
+}
+
+// SetLogs sets the tail of the shown logs.
+func (d *Dashboard) SetLogs(logs string) {
+	d.logs = logs
+}
+
+// HandleKey changes the selection and the view or returns the action of the key.
+// Keys are runes or names: up, down, left, right, tab, enter, esc, ctrl+c.
+func (d *Dashboard) HandleKey(key string) Action {
+	if key == "ctrl+c" {
+		return Action{Kind: Quit}
+	}
+	if d.view == logsView {
+		switch key {
+		case "q", "esc", "l":
+			d.view = jobsView
+			d.logs = ""
+		case "r":
+			return Action{Kind: Refresh}
+		}
+
+		return Action{}
+	}
+	if d.confirmStop {
+		d.confirmStop = false
+		if execution := d.selectedExecution(); key == "y" && execution != nil {
+			return Action{Kind: Stop, Job: execution.Job, Execution: execution.ID}
+		}
+		d.message = "stop is canceled"
+
+		return Action{}
+	}
+
+	switch key {
+	case "q":
+		return Action{Kind: Quit}
+	case "r":
+		return Action{Kind: Refresh}
+	case "up", "k":
+		d.move(-1)
+	case "down", "j":
+		d.move(1)
+	case "tab", "right", "left":
+		d.toggleFocus()
+	case "p":
+		if row := d.selectedRow(); row != nil {
+			if row.Job.Status == job.JobStatusPaused {
+				return Action{Kind: Resume, Job: row.Job.Name}
+			}
+
+			return Action{Kind: Pause, Job: row.Job.Name}
+		}
+	case "s":
+		if execution := d.selectedExecution(); execution != nil {
+			d.confirmStop = true
+		} else {
+			d.message = "select a running execution with tab to stop it"
+		}
+	case "l", "enter":
+		if execution := d.selectedExecution(); execution != nil {
+			selected := *execution
+			d.logsOf = &selected
+			d.view = logsView
+
+			return Action{Kind: Logs, Job: execution.Job, Execution: execution.ID}
+		}
+		d.message = "select a running execution with tab to tail its logs"
+	}
+
+	return Action{}
+}
+
+func (d *Dashboard) move(delta int) {
+	if d.execution >= 0 {
+		if row := d.selectedRow(); row != nil {
+			d.execution = clamp(d.execution+delta, len(row.Running))
+		}
+
+		return
+	}
+	if d.snapshot != nil {
+		d.selected = clamp(d.selected+delta, len(d.snapshot.Rows))
+	}
+}
+
+func (d *Dashboard) toggleFocus() {
+	if d.execution >= 0 {
+		d.execution = -1
+
+		return
+	}
+	if row := d.selectedRow(); row != nil && len(row.Running) > 0 {
+		d.execution = 0
+	}
+}
+
+func (d *Dashboard) selectedRow() *Row {
+	if d.snapshot == nil || d.selected >= len(d.snapshot.Rows) {
+		return nil
+	}
+
+	return &d.snapshot.Rows[d.selected]
+}
+
+func (d *Dashboard) selectedExecution() *job.Execution {
+	row := d.selectedRow()
+	if row == nil || d.execution < 0 || d.execution >= len(row.Running) {
+		return nil
+	}
+
+	return &row.Running[d.execution]
+}
+
+// Render returns lines of the screen, they are cut by the width and the height.
+// Selected lines are in reverse video.
+func (d *Dashboard) Render(width, height int) []string {
+	lines := []string{d.header()}
+	switch {
+	case d.confirmStop:
+		lines = append(lines, fmt.Sprintf("Stop execution %s? (y/n)", d.selectedExecution().ID))
+	case d.pollErr != nil:
+		lines = append(lines, "Error: "+d.pollErr.Error())
+	default:
+		lines = append(lines, d.message)
+	}
+
+	if d.view == logsView {
+		lines = append(lines, d.renderLogs(height-len(lines))...)
+	} else {
+		footer := "q quit  ↑↓ select  tab executions  p pause/resume  s stop  l logs  r refresh"
+		available := height - len(lines) - 1
+		jobs := d.renderJobs(available)
+		if len(jobs) > available && available >= 0 {
+			jobs = jobs[:available]
+		}
+		lines = append(lines, jobs...)
+		for len(lines) < height-1 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, footer)
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for i, line := range lines {
+		lines[i] = cut(line, width)
+	}
+
+	return lines
+}
+
+func (d *Dashboard) header() string {
+	header := d.title
+	if d.snapshot != nil {
+		header += "  " + d.snapshot.At.Format("15:04:05")
+	}
+
+	return header
+}
+
+func (d *Dashboard) renderJobs(height int) []string {
+	if d.snapshot == nil {
+		return []string{"loading..."}
+	}
+	now := d.snapshot.At
+	table := [][]string{{"NAME", "STATUS", "LOCK", "RUNNING", "LAST", "DURATION", "FINISHED"}}
+	for _, row := range d.snapshot.Rows {
+		lockMode := string(row.Job.LockMode)
+		if row.Job.LockLabel != "" {
+			lockMode += "(" + row.Job.LockLabel + ")"
+		}
+		status := string(row.Job.Status)
+		if row.Job.Overdue != nil {
+			status += "(overdue)"
+		}
+		last, duration, finished := "-", "-", "-"
+		if row.Last != nil {
+			last = string(row.Last.Status)
+			if row.Last.ExitCode != nil && *row.Last.ExitCode != 0 {
+				last += "(" + strconv.Itoa(*row.Last.ExitCode) + ")"
+			}
+			duration = formatDuration(row.Last.FinishedAt.Sub(row.Last.StartedAt))
+			finished = formatDuration(now.Sub(*row.Last.FinishedAt)) + " ago"
+		}
+		table = append(table, []string{
+			row.Job.Name, status, lockMode, runningByHost(row.Running), last, duration, finished,
+		})
+	}
+
+	jobLines := columns(table)
+	var executionLines []string
+	if row := d.selectedRow(); row != nil && len(row.Running) > 0 {
+		executions := [][]string{{"ID", "HOST", "STARTED", "DURATION", "STOP"}}
+		for _, execution := range row.Running {
+			stop := ""
+			if execution.StopRequestedAt != nil {
+				stop = "requested"
+			}
+			executions = append(executions, []string{
+				execution.ID.String(), host(&execution), execution.StartedAt.Format("15:04:05"),
+				formatDuration(now.Sub(execution.StartedAt)), stop,
+			})
+		}
+		executionLines = append([]string{"", "Running executions of " + row.Job.Name + ":"}, columns(executions)...)
+	}
+
+	// The jobs list is scrolled to keep the selected job visible, running executions are below it.
+	visible := height - len(executionLines) - 1
+	if visible < 1 {
+		visible = 1
+	}
+	offset := 0
+	if d.selected >= visible {
+		offset = d.selected - visible + 1
+	}
+	lines := []string{"  " + jobLines[0]}
+	for i := offset; i < len(d.snapshot.Rows) && i < offset+visible; i++ {
+		lines = append(lines, selectLine(jobLines[i+1], i == d.selected, d.execution < 0))
+	}
+	for i, line := range executionLines {
+		switch {
+		case i < 2:
+			lines = append(lines, line)
+		case i == 2:
+			lines = append(lines, "  "+line)
+		default:
+			lines = append(lines, selectLine(line, i-3 == d.execution, true))
+		}
+	}
+
+	return lines
+}
+
+func (d *Dashboard) renderLogs(height int) []string {
+	lines := []string{fmt.Sprintf("Logs of %s (%s@%s), esc to return", d.logsOf.ID, d.logsOf.Job, host(d.logsOf))}
+	logLines := strings.Split(strings.TrimRight(d.logs, "\n"), "\n")
+	if available := height - len(lines); len(logLines) > available {
+		logLines = logLines[len(logLines)-available:]
+	}
+
+	return append(lines, logLines...)
+}
+
+// selectLine marks the selected line with `>`, it's in reverse video if it has the focus.
+func selectLine(line string, selected bool, focused bool) string {
+	switch {
+	case selected && focused:
+		return "\x1b[7m> " + line + "\x1b[0m"
+	case selected:
+		return "> " + line
+	default:
+		return "  " + line
+	}
+}
+
+// runningByHost returns `host (count)` of running executions.
+func runningByHost(executions []job.Execution) string {
+	if len(executions) == 0 {
+		return "-"
+	}
+	counts := make(map[string]int)
+	hosts := make([]string, 0)
+	for i := range executions {
+		name := host(&executions[i])
+		if counts[name] == 0 {
+			hosts = append(hosts, name)
+		}
+		counts[name]++
+	}
+	running := make([]string, 0, len(hosts))
+	for _, name := range hosts {
+		running = append(running, fmt.Sprintf("%s (%d)", name, counts[name]))
+	}
+
+	return strings.Join(running, ", ")
+}
+
+// columns pads cells to the widest cell of the column.
+func columns(table [][]string) []string {
+	widths := make([]int, 0)
+	for _, row := range table {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if width := len([]rune(cell)); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	lines := make([]string, 0, len(table))
+	for _, row := range table {
+		cells := make([]string, 0, len(row))
+		for i, cell := range row {
+			cells = append(cells, cell+strings.Repeat(" ", widths[i]-len([]rune(cell))))
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+
+	return lines
+}
+
+// cut cuts the line by the width, escape sequences of the reverse video aren't counted.
+func cut(line string, width int) string {
+	visible := 0
+	var result strings.Builder
+	escape := false
+	for _, r := range line {
+		switch {
+		case r == '\x1b':
+			escape = true
+		case escape:
+			if r == 'm' {
+				escape = false
+			}
+		case visible >= width:
+			continue
+		default:
+			visible++
+		}
+		result.WriteRune(r)
+	}
+
+	return result.String()
+}
+
+func formatDuration(duration time.Duration) string {
+	switch {
+	case duration < time.Second:
+		return duration.Round(time.Millisecond).String()
+	case duration < time.Hour:
+		return duration.Round(time.Second).String()
+	default:
+		return duration.Round(time.Minute).String()
+	}
+}
+
+func host(execution *job.Execution) string {
+	if execution.Host == nil {
+		return "-"
+	}
+
+	return *execution.Host
+}
+
+func clamp(value int, length int) int {
+	if value >= length {
+		value = length - 1
+	}
+	if value < 0 {
+		value = 0
+	}
+
+	return value
+}
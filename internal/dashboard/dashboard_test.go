@@ -0,0 +1,169 @@
+package dashboard_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/dashboard"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func newExecution(jobName string, host string, status job.ExecutionStatus, startedAgo time.Duration) job.Execution {
+	execution := job.NewRunningExecution(jobName)
+	execution.SetHost(host)
+	execution.SetStartedAt(now.Add(-startedAgo))
+	execution.Status = status
+	if status != job.StatusRunning {
+		execution.Finish(status, now.Add(-startedAgo+time.Minute), "")
+	}
+
+	return *execution
+}
+
+func newSnapshot() *dashboard.Snapshot {
+	backup, report := job.NewJob("backup"), job.NewJob("report")
+	backup.LockMode = job.HostLockMode
+	backup.Overdue = &job.Overdue{DueAt: now.Add(-time.Hour)}
+	report.Pause()
+
+	running := []job.Execution{
+		newExecution("backup", "host-2", job.StatusRunning, time.Minute),
+		newExecution("backup", "host-1", job.StatusRunning, 2*time.Minute),
+		newExecution("backup", "host-1", job.StatusRunning, time.Minute),
+	}
+	recent := append([]job.Execution{
+		newExecution("report", "host-1", job.StatusFailed, time.Hour),
+		newExecution("report", "host-1", job.StatusSuccessed, 2*time.Hour),
+		newExecution("deleted", "host-1", job.StatusSuccessed, time.Hour),
+	}, running...)
+
+	return dashboard.NewSnapshot([]job.Job{*report, *backup}, running, recent, now)
+}
+
+func TestNewSnapshot(t *testing.T) {
+	t.Parallel()
+	snapshot := newSnapshot()
+
+	require.Len(t, snapshot.Rows, 2)
+	backup, report := snapshot.Rows[0], snapshot.Rows[1]
+	assert.Equal(t, "backup", backup.Job.Name)
+	require.Len(t, backup.Running, 3)
+	assert.Equal(t, "host-1", *backup.Running[0].Host)
+	assert.True(t, backup.Running[0].StartedAt.Before(backup.Running[1].StartedAt))
+	assert.Equal(t, "host-2", *backup.Running[2].Host)
+	assert.Nil(t, backup.Last)
+
+	assert.Equal(t, "report", report.Job.Name)
+	assert.Empty(t, report.Running)
+	require.NotNil(t, report.Last)
+	assert.Equal(t, job.StatusFailed, report.Last.Status)
+}
+
+func TestDashboardKeys(t *testing.T) {
+	t.Parallel()
+	snapshot := newSnapshot()
+	backup := snapshot.Rows[0]
+
+	testCases := []struct {
+		name     string
+		keys     []string
+		expected dashboard.Action
+	}{
+		{name: "quit", keys: []string{"q"}, expected: dashboard.Action{Kind: dashboard.Quit}},
+		{name: "ctrl+c", keys: []string{"ctrl+c"}, expected: dashboard.Action{Kind: dashboard.Quit}},
+		{name: "refresh", keys: []string{"r"}, expected: dashboard.Action{Kind: dashboard.Refresh}},
+		{name: "pause", keys: []string{"p"}, expected: dashboard.Action{Kind: dashboard.Pause, Job: "backup"}},
+		{name: "resume paused", keys: []string{"down", "p"}, expected: dashboard.Action{Kind: dashboard.Resume, Job: "report"}},
+		{name: "selection is clamped", keys: []string{"j", "j", "k", "k", "p"}, expected: dashboard.Action{Kind: dashboard.Pause, Job: "backup"}},
+		{name: "stop without execution", keys: []string{"s", "y"}, expected: dashboard.Action{}},
+		{
+			name:     "stop",
+			keys:     []string{"tab", "down", "s", "y"},
+			expected: dashboard.Action{Kind: dashboard.Stop, Job: "backup", Execution: backup.Running[1].ID},
+		},
+		{name: "stop is canceled", keys: []string{"tab", "s", "n"}, expected: dashboard.Action{}},
+		{
+			name:     "logs",
+			keys:     []string{"tab", "enter"},
+			expected: dashboard.Action{Kind: dashboard.Logs, Job: "backup", Execution: backup.Running[0].ID},
+		},
+		{name: "logs view ignores actions", keys: []string{"tab", "l", "p"}, expected: dashboard.Action{}},
+		{name: "logs view is closed", keys: []string{"tab", "l", "esc", "q"}, expected: dashboard.Action{Kind: dashboard.Quit}},
+		{name: "focus back to jobs", keys: []string{"tab", "tab", "down", "p"}, expected: dashboard.Action{Kind: dashboard.Resume, Job: "report"}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			board := dashboard.New("top")
+			board.Update(newSnapshot(), nil)
+
+			var action dashboard.Action
+			for _, key := range testCase.keys {
+				action = board.HandleKey(key)
+			}
+			assert.Equal(t, testCase.expected.Kind, action.Kind)
+			assert.Equal(t, testCase.expected.Job, action.Job)
+			if testCase.expected.Kind == dashboard.Stop || testCase.expected.Kind == dashboard.Logs {
+				// Executions of snapshots have new ids, they are compared by the position.
+				assert.NotEmpty(t, action.Execution)
+			}
+		})
+	}
+}
+
+func TestDashboardUpdateKeepsSelection(t *testing.T) {
+	t.Parallel()
+	board := dashboard.New("top")
+	snapshot := newSnapshot()
+	board.Update(snapshot, nil)
+	board.HandleKey("tab")
+	board.HandleKey("down")
+
+	// The selected execution is found by its id after the update.
+	updated := *snapshot
+	updated.Rows = append([]dashboard.Row{{Job: *job.NewJob("archive")}}, snapshot.Rows...)
+	board.Update(&updated, nil)
+	board.HandleKey("s")
+	action := board.HandleKey("y")
+	assert.Equal(t, dashboard.Stop, action.Kind)
+	assert.Equal(t, snapshot.Rows[0].Running[1].ID, action.Execution)
+}
+
+func TestDashboardRender(t *testing.T) {
+	t.Parallel()
+	board := dashboard.New("jobsctl top")
+	board.Update(newSnapshot(), nil)
+
+	screen := strings.Join(board.Render(200, 20), "\n")
+	assert.Contains(t, screen, "jobsctl top  12:00:00")
+	assert.Contains(t, screen, "host-1 (2), host-2 (1)")
+	assert.Contains(t, screen, "failed")
+	assert.Contains(t, screen, "active(overdue)")
+	assert.Contains(t, screen, "59m0s ago")
+	assert.Contains(t, screen, "Running executions of backup:")
+	assert.Contains(t, screen, "\x1b[7m> backup")
+
+	lines := board.Render(10, 5)
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "jobsctl to", lines[0])
+	assert.True(t, strings.HasPrefix(lines[4], "q quit"))
+
+	board.Update(nil, errors.New("connection refused"))
+	assert.Contains(t, strings.Join(board.Render(200, 20), "\n"), "Error: ")
+}
+
+func TestParseKeys(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t,
+		[]string{"q", "up", "down", "right", "left", "esc", "tab", "enter", "ctrl+c"},
+		dashboard.ParseKeys([]byte("q\x1b[A\x1b[B\x1bOC\x1b[D\x1b\t\r\x03")),
+	)
+}
@@ -0,0 +1,33 @@
+package dashboard
+
+// ParseKeys converts input of the terminal in raw mode to keys of HandleKey, unknown escape sequences are skipped.
+func ParseKeys(data []byte) []string {
+	keys := make([]string, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case 3:
+			keys = append(keys, "ctrl+c")
+		case '\t':
+			keys = append(keys, "tab")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case 0x1b:
+			// CSI sequences of arrows are `ESC [ A`, `ESC O A` in the application mode.
+			if i+2 < len(data) && (data[i+1] == '[' || data[i+1] == 'O') {
+				if arrow, ok := arrows[data[i+2]]; ok {
+					keys = append(keys, arrow)
+				}
+				i += 2
+
+				continue
+			}
+			keys = append(keys, "esc")
+		default:
+			keys = append(keys, string(rune(data[i])))
+		}
+	}
+
+	return keys
+}
+
+var arrows = map[byte]string{'A': "up", 'B': "down", 'C': "right", 'D': "left"}
@@ -0,0 +1,194 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/google/uuid"
+)
+
+const (
+	bufferFileExt = ".json"
+	jobConfigDir  = "jobs"
+	lostMsg       = "executor exited without finishing the execution"
+)
+
+// Buffer keeps executions on local disk while the server is unavailable,
+// one file per execution. Replay sends them to the server with the import api.
+type Buffer struct {
+	dir string
+}
+
+func NewBuffer(dir string) *Buffer {
+	return &Buffer{dir: dir}
+}
+
+// DefaultBufferDir is in the user's cache dir, or in the temp dir if there is no one.
+func DefaultBufferDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "jobs", "buffer")
+}
+
+// Save writes the execution atomically, the previous state of the execution is replaced.
+func (b *Buffer) Save(execution *job.Execution) error {
+	data, err := json.Marshal(execution)
+	if err != nil {
+		return fmt.Errorf("buffer save: marshal: %w", err)
+	}
+	if err := writeFileAtomic(b.path(execution), data); err != nil {
+		return fmt.Errorf("buffer save: %w", err)
+	}
+
+	return nil
+}
+
+// SaveJobConfig keeps the last known sandbox, env and params of the job from the start response,
+// the execution id isn't kept. The config is kept even if it's empty, it tells that the job has been
+// started once.
+func (b *Buffer) SaveJobConfig(jobName string, config *restapi.JobStartOut) error {
+	kept := restapi.JobStartOut{}
+	if config != nil {
+		kept = *config
+	}
+	kept.ID = uuid.Nil
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return fmt.Errorf("buffer save job config: marshal: %w", err)
+	}
+	if err := writeFileAtomic(b.jobConfigPath(jobName), data); err != nil {
+		return fmt.Errorf("buffer save job config: %w", err)
+	}
+
+	return nil
+}
+
+// LoadJobConfig returns the last known config of the job, nil if there is no one.
+func (b *Buffer) LoadJobConfig(jobName string) (*restapi.JobStartOut, error) {
+	data, err := ioutil.ReadFile(b.jobConfigPath(jobName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("buffer load job config: %w", err)
+	}
+
+	var config restapi.JobStartOut
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("buffer load job config: %w", err)
+	}
+
+	return &config, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Replay sends buffered executions to the server and removes them from the buffer.
+// Running executions are sent only when their executor has exited, they are marked as failed.
+func (b *Buffer) Replay(ctx context.Context, client restapi.Client) (int, error) {
+	executions, err := b.load()
+	if err != nil {
+		return 0, err
+	}
+
+	dump := &job.Dump{}
+	for i := range executions {
+		execution := executions[i]
+		if execution.Status == job.StatusRunning {
+			if execution.Pid != nil && processExists(*execution.Pid) {
+				continue
+			}
+			execution.Finish(job.StatusFailed, time.Now(), lostMsg)
+		}
+		dump.Executions = append(dump.Executions, execution)
+	}
+	if len(dump.Executions) == 0 {
+		return 0, nil
+	}
+
+	// Overwrite, because the server may know the execution as running if it went down after the start.
+	if _, err := client.Import(ctx, dump, job.ConflictOverwrite); err != nil {
+		return 0, fmt.Errorf("buffer replay: %w", err)
+	}
+
+	for i := range dump.Executions {
+		if err := os.Remove(b.path(&dump.Executions[i])); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return i, fmt.Errorf("buffer replay: %w", err)
+		}
+	}
+
+	return len(dump.Executions), nil
+}
+
+func (b *Buffer) load() ([]job.Execution, error) {
+	files, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("buffer load: %w", err)
+	}
+
+	executions := make([]job.Execution, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), bufferFileExt) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(b.dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("buffer load: %w", err)
+		}
+		var execution job.Execution
+		if err := json.Unmarshal(data, &execution); err != nil {
+			return nil, fmt.Errorf("buffer load %s: %w", file.Name(), err)
+		}
+		executions = append(executions, execution)
+	}
+
+	return executions, nil
+}
+
+func (b *Buffer) path(execution *job.Execution) string {
+	return filepath.Join(b.dir, execution.ID.String()+bufferFileExt)
+}
+
+func (b *Buffer) jobConfigPath(jobName string) string {
+	return filepath.Join(b.dir, jobConfigDir, url.PathEscape(jobName)+bufferFileExt)
+}
@@ -0,0 +1,97 @@
+package executor_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/executor"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/antgubarev/jobs/internal/restapi/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBufferReplay(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	buffer := executor.NewBuffer(dir)
+
+	finished := job.NewRunningExecution("job")
+	finished.SetExitCode(2)
+	finished.Finish(job.StatusFailed, finished.StartedAt, "exit status 2")
+	running := job.NewRunningExecution("job")
+	running.SetPid(os.Getpid())
+	lost := job.NewRunningExecution("job")
+	lost.SetPid(-1)
+	for _, execution := range []*job.Execution{finished, running, lost} {
+		assert.NoError(t, buffer.Save(execution))
+	}
+
+	client := &mocks.Client{}
+	client.On("Import", mock.Anything, mock.MatchedBy(func(dump *job.Dump) bool {
+		if len(dump.Executions) != 2 {
+			return false
+		}
+		for _, execution := range dump.Executions {
+			if execution.ID == lost.ID && execution.Status != job.StatusFailed {
+				return false
+			}
+			if execution.ID == running.ID {
+				return false
+			}
+		}
+
+		return true
+	}), job.ConflictOverwrite).Return(&job.ImportResult{Created: 2}, nil).Once()
+
+	replayed, err := buffer.Replay(context.Background(), client)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	client.AssertExpectations(t)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, running.ID.String()+".json", files[0].Name())
+}
+
+func TestBufferReplayEmpty(t *testing.T) {
+	t.Parallel()
+	buffer := executor.NewBuffer(t.TempDir() + "/not-created")
+
+	replayed, err := buffer.Replay(context.Background(), &mocks.Client{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, replayed)
+}
+
+func TestBufferJobConfig(t *testing.T) {
+	t.Parallel()
+	buffer := executor.NewBuffer(t.TempDir())
+
+	config, err := buffer.LoadJobConfig("my/job")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+
+	saved := &restapi.JobStartOut{
+		ID:      uuid.New(),
+		Sandbox: &job.Sandbox{WorkDir: "/srv", EnvAllow: []string{"PATH"}},
+		Env:     map[string]string{"A": "1"},
+		Params:  map[string]string{"size": "10"},
+	}
+	assert.NoError(t, buffer.SaveJobConfig("my/job", saved))
+	config, err = buffer.LoadJobConfig("my/job")
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Nil, config.ID)
+	assert.Equal(t, saved.Sandbox, config.Sandbox)
+	assert.Equal(t, saved.Env, config.Env)
+	assert.Equal(t, saved.Params, config.Params)
+
+	assert.NoError(t, buffer.SaveJobConfig("my/job", &restapi.JobStartOut{ID: uuid.New()}))
+	config, err = buffer.LoadJobConfig("my/job")
+	assert.NoError(t, err)
+	assert.Equal(t, &restapi.JobStartOut{}, config)
+}
@@ -6,25 +6,74 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"sort"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
 	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/job"
 	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/golang/glog"
+	"github.com/google/uuid"
 )
 
+// Exit codes of job-exec. If the command has been run, job-exec exits with its exit code
+// (128+signal for the command killed by a signal), so codes of the command can overlap these.
 const (
-	ExitError = 1
-	ExitOK    = 0
+	ExitOK = 0
+	// ExitUsage means invalid job-exec arguments.
+	ExitUsage = 64
+	// ExitServerUnavailable means the server can't be reached, the command hasn't been run.
+	ExitServerUnavailable = 69
+	// ExitLockRefused means the job is already running according to its lock mode, the command hasn't been run.
+	ExitLockRefused = 75
+	// ExitHostMismatch means labels of the host don't match the job's host selector, the command hasn't been run.
+	ExitHostMismatch = 77
+	// ExitError means the server refused to start the command for another reason (job not found, paused, etc.).
+	ExitError = 125
+	// ExitCannotExecute and ExitNotFound mean the command can't be started, like in shells.
+	ExitCannotExecute = 126
+	ExitNotFound      = 127
+
+	exitSignalBase = 128
+)
+
+var (
+	errInvalidArguments  = errors.New("invalid arguments")
+	errUnsupportedSignal = errors.New("unsupported signal")
+	errNoJobConfig       = errors.New("server is unavailable and the job's config isn't known")
 )
 
-var errInvalidArguments = errors.New("invalid arguments")
+const (
+	defaultGracePeriod = 10 * time.Second
+	// finishTimeout limits reporting of the finish, it isn't bound to the execution's ctx.
+	finishTimeout = 30 * time.Second
+)
 
 type options struct {
-	outFile *os.File
-	errFile *os.File
-	cmdChan chan *exec.Cmd
+	outFile     *os.File
+	errFile     *os.File
+	cmdChan     chan *exec.Cmd
+	idChan      chan uuid.UUID
+	signals     <-chan os.Signal
+	gracePeriod time.Duration
+	// sampleInterval is the interval of RSS sampling during the run, zero disables it.
+	sampleInterval time.Duration
+	shim           string
+	cgroupRoot     string
+	serverURL      string
+	params         map[string]string
+	host           string
+	labels         map[string]string
+	// stopCheckInterval is the interval of polling stop requests of the execution, zero disables it.
+	stopCheckInterval time.Duration
+
+	unavailablePolicy UnavailablePolicy
+	backoff           Backoff
+	buffer            *Buffer
+	lockWait          LockWait
 }
 
 type Option func(*options)
@@ -47,6 +96,80 @@ func WithCmdChan(cmdChan chan *exec.Cmd) Option {
 	}
 }
 
+// WithIDChan sets the channel which gets the id of the execution on the server right before the command's start.
+func WithIDChan(idChan chan uuid.UUID) Option {
+	return func(o *options) {
+		o.idChan = idChan
+	}
+}
+
+// WithSignals sets the channel of signals to forward to the command,
+// by default the executor subscribes to ForwardedSignals itself.
+func WithSignals(signals <-chan os.Signal) Option {
+	return func(o *options) {
+		o.signals = signals
+	}
+}
+
+// WithGracePeriod sets how long the command may shut down after SIGINT/SIGTERM
+// before the whole process group is killed.
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(o *options) {
+		o.gracePeriod = gracePeriod
+	}
+}
+
+// lockWaitPoll is the duration of one long-polling start request.
+const lockWaitPoll = 30 * time.Second
+
+// LockWait makes the start wait for the lock in the server queue, zero timeout means no limit.
+type LockWait struct {
+	Enabled bool
+	Timeout time.Duration
+}
+
+func WithLockWait(lockWait LockWait) Option {
+	return func(o *options) {
+		o.lockWait = lockWait
+	}
+}
+
+// WithServerURL sets the server url passed to the command in JOBS_SERVER_URL.
+func WithServerURL(url string) Option {
+	return func(o *options) {
+		o.serverURL = url
+	}
+}
+
+// WithParams overrides defaults of the job's params.
+func WithParams(params map[string]string) Option {
+	return func(o *options) {
+		o.params = params
+	}
+}
+
+// WithHost sets the host name of executions, it's the hostname by default.
+func WithHost(host string) Option {
+	return func(o *options) {
+		o.host = host
+	}
+}
+
+// WithHostLabels sets labels of the host which are matched with the job's host selector.
+func WithHostLabels(labels map[string]string) Option {
+	return func(o *options) {
+		o.labels = labels
+	}
+}
+
+// WithStopCheck polls the server for the stop request of the execution with the interval,
+// the requested stop is handled like SIGTERM.
+func WithStopCheck(interval time.Duration) Option {
+	return func(o *options) {
+		o.stopCheckInterval = interval
+	}
+}
+
 type Executor struct {
 	options
 	client restapi.Client
@@ -54,6 +177,9 @@ type Executor struct {
 
 func NewExecutor(client restapi.Client, opts ...Option) *Executor {
 	cli := &Executor{client: client}
+	cli.gracePeriod = defaultGracePeriod
+	cli.unavailablePolicy = UnavailableFail
+	cli.backoff = DefaultBackoff
 
 	for _, optFunc := range opts {
 		optFunc(&cli.options)
@@ -62,81 +188,358 @@ func NewExecutor(client restapi.Client, opts ...Option) *Executor {
 	return cli
 }
 
-func (e *Executor) StartAndWatch(ctx context.Context, job string, args []string) (exitCode int, err error) {
+func (e *Executor) StartAndWatch(ctx context.Context, jobName string, args []string) (exitCode int, err error) {
 	if len(args) == 0 {
-		return ExitError, fmt.Errorf("StartAndWatch: %w: command name is required", errInvalidArguments)
+		return ExitUsage, fmt.Errorf("StartAndWatch: %w: command name is required", errInvalidArguments)
 	}
-	hostname, err := os.Hostname()
-	if err != nil {
-		return ExitError, fmt.Errorf("StartAndWatch: %w", err)
+	hostname := e.host
+	if hostname == "" {
+		var err error
+		if hostname, err = os.Hostname(); err != nil {
+			return ExitError, fmt.Errorf("StartAndWatch: %w", err)
+		}
 	}
 
 	startIn := &restapi.JobStartIn{
-		Job:       job,
+		Job:       jobName,
 		StartedAt: internal.NewPointerOfTime(time.Now()),
 		Command:   internal.NewPointerOfString(strings.Join(args, " ")),
 		Pid:       internal.NewPointerOfInt(os.Getpid()),
 		Host:      &hostname,
+		Params:    e.params,
+		Labels:    e.labels,
 	}
 
-	_, err = e.client.JobStart(ctx, startIn)
-	if err != nil {
-		return ExitError, fmt.Errorf("send job start to api: %w", err)
+	execution := newExecution(startIn)
+	degraded := false
+	startOut, err := e.start(ctx, startIn)
+	switch {
+	case err == nil:
+		execution.SetID(startOut.ID)
+		e.saveJobConfig(jobName, startOut)
+		e.replay(ctx)
+	case errors.Is(err, restapi.ErrUnavailable) && e.unavailablePolicy == UnavailableRun:
+		glog.Warningf("server is unavailable, run without lock: %v", err)
+		degraded = true
+		if startOut, err = e.degradedJobConfig(jobName); err != nil {
+			return ExitServerUnavailable, fmt.Errorf("StartAndWatch: %w", err)
+		}
+		execution.Params = startOut.Params
+		if e.buffer != nil {
+			if err := e.buffer.Save(execution); err != nil {
+				glog.Errorf("buffer execution: %v", err)
+			}
+		}
+	default:
+		return startErrorCode(err), err
 	}
 
-	var cmd *exec.Cmd
-	if len(args) == 1 {
-		cmd = exec.Command(args[0]) //nolint:gosec
-	} else {
-		cmd = exec.Command(args[0], args[1:]...) //nolint:gosec
+	if e.idChan != nil {
+		e.idChan <- execution.ID
 	}
 
-	cmd.Stdout = e.outFile
-	cmd.Stderr = e.errFile
+	env := e.commandEnv(jobName, execution.ID, startOut)
+	cmd, cgrp, err := e.command(args, startOut.Sandbox, env, execution.ID)
+	if err == nil {
+		cmd.Stdout = e.outFile
+		cmd.Stderr = e.errFile
+		setProcessGroup(cmd)
+		err = cmd.Start()
+	}
+	if err != nil {
+		e.removeCgroup(cgrp)
+		exitCode = commandErrorCode(err)
+		if finishErr := e.finish(execution, degraded, exitCode, err.Error(), nil); finishErr != nil {
+			return exitCode, fmt.Errorf("error start command: %v, %w", err, finishErr)
+		}
 
-	if err := cmd.Start(); err != nil {
-		return ExitError, fmt.Errorf("error start command: %w", err)
+		return exitCode, fmt.Errorf("error start command: %w", err)
 	}
 	if e.cmdChan != nil {
 		e.cmdChan <- cmd
 	}
 
-	return e.watch(ctx, cmd)
+	var smplr *sampler
+	if e.sampleInterval > 0 {
+		smplr = startSampler(cmd.Process.Pid, e.sampleInterval)
+	}
+
+	var stop <-chan struct{}
+	if !degraded && e.stopCheckInterval > 0 {
+		stopCtx, cancelStop := context.WithCancel(ctx)
+		stop = e.watchStop(stopCtx, execution.ID)
+		defer cancelStop()
+	}
+
+	exitCode, msg := e.watch(ctx, cmd, stop)
+
+	usage := processUsage(cmd.ProcessState)
+	if smplr != nil {
+		smplr.stop(usage)
+	}
+	e.removeCgroup(cgrp)
+
+	return exitCode, e.finish(execution, degraded, exitCode, msg, usage)
 }
 
-func (e *Executor) watch(ctx context.Context, cmd *exec.Cmd) (exitCode int, err error) {
-	sigs := make(chan os.Signal, 1)
-	done := make(chan bool, 1)
-	errs := make(chan error)
+// finish reports the execution result to the server. If the execution has been run in degraded mode
+// or the server is unavailable now, the result is buffered and replayed later. The result is reported
+// even if the execution's ctx is canceled.
+func (e *Executor) finish(execution *job.Execution, degraded bool, exitCode int, msg string, usage *job.Usage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancel()
+
+	if !degraded {
+		err := e.client.JobFinish(ctx, execution.ID, &restapi.JobFinishIn{
+			ExitCode: &exitCode,
+			Msg:      &msg,
+			Usage:    usage,
+		})
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, restapi.ErrUnavailable) || e.buffer == nil {
+			return fmt.Errorf("send job finish to api: %w", err)
+		}
+		glog.Warningf("server is unavailable, buffer execution finish: %v", err)
+	}
+	if e.buffer == nil {
+		return nil
+	}
+
+	status := job.StatusSuccessed
+	if exitCode != ExitOK {
+		status = job.StatusFailed
+	}
+	execution.SetExitCode(exitCode)
+	execution.Usage = usage
+	execution.Finish(status, time.Now(), msg)
+	if err := e.buffer.Save(execution); err != nil {
+		return fmt.Errorf("buffer execution finish: %w", err)
+	}
+	e.replay(ctx)
 
-	wgCmd := sync.WaitGroup{}
+	return nil
+}
 
-	wgCmd.Add(1)
+func (e *Executor) removeCgroup(cgrp *cgroup) {
+	if err := cgrp.remove(); err != nil {
+		glog.Warningf("%v", err)
+	}
+}
+
+// saveJobConfig keeps the job's config to apply it if the job is run while the server is unavailable.
+func (e *Executor) saveJobConfig(jobName string, config *restapi.JobStartOut) {
+	if e.buffer == nil {
+		return
+	}
+	if err := e.buffer.SaveJobConfig(jobName, config); err != nil {
+		glog.Warningf("save job config: %v", err)
+	}
+}
+
+// degradedJobConfig returns the last known config of the job, local params override the kept ones.
+// The job which has never been started with the server isn't run, its sandbox and env are unknown.
+func (e *Executor) degradedJobConfig(jobName string) (*restapi.JobStartOut, error) {
+	if e.buffer == nil {
+		return nil, fmt.Errorf("%w: no buffer to keep the config of %s", errNoJobConfig, jobName)
+	}
+	config, err := e.buffer.LoadJobConfig(jobName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNoJobConfig, err)
+	}
+	if config == nil {
+		return nil, fmt.Errorf("%w: %s has never been started with the server", errNoJobConfig, jobName)
+	}
+	if len(e.params) > 0 {
+		params := make(map[string]string, len(config.Params)+len(e.params))
+		for name, value := range config.Params {
+			params[name] = value
+		}
+		for name, value := range e.params {
+			params[name] = value
+		}
+		config.Params = params
+	}
+
+	return config, nil
+}
+
+// commandEnv returns variables added to the command's environment: the job's env, params
+// and the execution's ones.
+func (e *Executor) commandEnv(jobName string, executionID uuid.UUID, config *restapi.JobStartOut) []string {
+	env := make([]string, 0, len(config.Env)+len(config.Params)+3)
+	for _, name := range sortedKeys(config.Env) {
+		env = append(env, name+"="+config.Env[name])
+	}
+	for _, name := range sortedKeys(config.Params) {
+		env = append(env, job.ParamEnvName(name)+"="+config.Params[name])
+	}
+	env = append(env,
+		job.EnvExecutionID+"="+executionID.String(),
+		job.EnvJobName+"="+jobName,
+	)
+	if e.serverURL != "" {
+		env = append(env, job.EnvServerURL+"="+e.serverURL)
+	}
+
+	return env
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func newExecution(startIn *restapi.JobStartIn) *job.Execution {
+	execution := job.NewRunningExecution(startIn.Job)
+	execution.SetStartedAt(*startIn.StartedAt)
+	execution.SetCommand(*startIn.Command)
+	execution.SetPid(*startIn.Pid)
+	execution.SetHost(*startIn.Host)
+
+	return execution
+}
+
+func startErrorCode(err error) int {
+	switch {
+	case errors.Is(err, restapi.ErrLocked):
+		return ExitLockRefused
+	case errors.Is(err, restapi.ErrHostMismatch):
+		return ExitHostMismatch
+	case errors.Is(err, restapi.ErrUnavailable):
+		return ExitServerUnavailable
+	default:
+		return ExitError
+	}
+}
+
+func commandErrorCode(err error) int {
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return ExitNotFound
+	}
+
+	return ExitCannotExecute
+}
+
+// ExitCode returns the exit code of the finished process, 128+signal if it was killed by a signal.
+func ExitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return exitSignalBase + int(status.Signal())
+	}
+
+	return state.ExitCode()
+}
+
+// watchStop polls the stop request of the execution until ctx is done, the returned channel is closed
+// when the stop is requested or the execution is deleted with its job.
+func (e *Executor) watchStop(ctx context.Context, id uuid.UUID) <-chan struct{} {
+	stop := make(chan struct{})
 	go func() {
-		defer wgCmd.Done()
+		ticker := time.NewTicker(e.stopCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			execution, err := e.client.ExecutionGet(ctx, id)
+			if errors.Is(err, restapi.ErrExecutionNotFound) {
+				glog.Warningf("execution %s is deleted, stop the command", id)
+				close(stop)
 
-		select {
-		case <-ctx.Done():
-			// TODO: parent process was finished
-			return
-		case <-done:
-			// TODO: user finished process
-			return
-		case sig := <-sigs:
-			if err := cmd.Process.Signal(sig); err != nil {
-				errs <- fmt.Errorf("error sending signal to process: %w", err)
+				return
+			}
+			if err != nil {
+				glog.V(1).Infof("check stop request: %v", err)
+
+				continue
+			}
+			if execution.StopRequestedAt != nil {
+				close(stop)
 
 				return
 			}
 		}
 	}()
 
-	if err := cmd.Wait(); err != nil {
-		return ExitError, fmt.Errorf("error running command: %w", err)
+	return stop
+}
+
+// watch waits for the command and returns its exit code and status message.
+// Signals are forwarded to the command's process group. SIGINT/SIGTERM, canceled ctx or the stop request
+// start the grace period, after it the whole group is killed.
+func (e *Executor) watch(ctx context.Context, cmd *exec.Cmd, stop <-chan struct{}) (exitCode int, msg string) {
+	sigs := e.signals
+	if sigs == nil {
+		notified := make(chan os.Signal, 1)
+		signal.Notify(notified, ForwardedSignals...)
+		defer signal.Stop(notified)
+		sigs = notified
 	}
-	done <- true
 
-	wgCmd.Wait()
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+	}()
+
+	done := ctx.Done()
+	var killTimer <-chan time.Time
+	stopRequested := false
+
+	for {
+		select {
+		case err := <-waitErr:
+			if err == nil {
+				return ExitOK, ""
+			}
+			if cmd.ProcessState == nil {
+				return ExitError, err.Error()
+			}
+			if stopRequested {
+				return ExitCode(cmd.ProcessState), "stopped by request: " + err.Error()
+			}
+
+			return ExitCode(cmd.ProcessState), err.Error()
+		case <-stop:
+			stop = nil
+			stopRequested = true
+			glog.Infof("stop is requested, terminate command")
+			if err := signalGroup(cmd, syscall.SIGTERM); err != nil {
+				glog.Warningf("terminate command: %v", err)
+			}
+			if killTimer == nil {
+				killTimer = time.After(e.gracePeriod)
+			}
+		case sig := <-sigs:
+			if err := signalGroup(cmd, sig); err != nil {
+				glog.Warningf("forward signal %v: %v", sig, err)
+			}
+			if isShutdownSignal(sig) && killTimer == nil {
+				killTimer = time.After(e.gracePeriod)
+			}
+		case <-done:
+			done = nil
+			if err := signalGroup(cmd, syscall.SIGTERM); err != nil {
+				glog.Warningf("terminate command: %v", err)
+			}
+			if killTimer == nil {
+				killTimer = time.After(e.gracePeriod)
+			}
+		case <-killTimer:
+			if err := killGroup(cmd); err != nil {
+				glog.Warningf("kill command: %v", err)
+			}
+		}
+	}
+}
 
-	return ExitOK, nil
+func isShutdownSignal(sig os.Signal) bool {
+	return sig == syscall.SIGINT || sig == syscall.SIGTERM
 }
@@ -0,0 +1,264 @@
+package executor_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/executor"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/antgubarev/jobs/internal/restapi/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStartAndWatchExitCode(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		args     []string
+		startErr error
+		exitCode int
+		finished bool
+		err      bool
+	}{
+		{name: "success", args: []string{"true"}, exitCode: executor.ExitOK, finished: true},
+		{name: "command failed", args: []string{"sh", "-c", "exit 3"}, exitCode: 3, finished: true},
+		{name: "command killed", args: []string{"sh", "-c", "kill -TERM $$"}, exitCode: 143, finished: true},
+		{
+			name: "command not found", args: []string{"/not/existing/command"},
+			exitCode: executor.ExitNotFound, finished: true, err: true,
+		},
+		{
+			name: "lock refused", args: []string{"true"}, startErr: fmt.Errorf("JobStart %w", restapi.ErrLocked),
+			exitCode: executor.ExitLockRefused, err: true,
+		},
+		{
+			name: "host mismatch", args: []string{"true"}, startErr: fmt.Errorf("JobStart %w", restapi.ErrHostMismatch),
+			exitCode: executor.ExitHostMismatch, err: true,
+		},
+		{
+			name: "server unavailable", args: []string{"true"}, startErr: fmt.Errorf("JobStart %w", restapi.ErrUnavailable),
+			exitCode: executor.ExitServerUnavailable, err: true,
+		},
+		{name: "empty command", exitCode: executor.ExitUsage, err: true},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			executionID := uuid.New()
+			client := &mocks.Client{}
+			client.On("JobStart", mock.Anything, mock.Anything).Return(&restapi.JobStartOut{ID: executionID}, testCase.startErr)
+			if testCase.finished {
+				client.On("JobFinish", mock.Anything, executionID, mock.MatchedBy(func(in *restapi.JobFinishIn) bool {
+					return *in.ExitCode == testCase.exitCode
+				})).Return(nil).Once()
+			}
+
+			exitCode, err := executor.NewExecutor(client).StartAndWatch(context.Background(), "job", testCase.args)
+			assert.Equal(t, testCase.exitCode, exitCode)
+			if testCase.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if testCase.finished {
+				client.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestServerUnavailablePolicy(t *testing.T) {
+	t.Parallel()
+	unavailable := fmt.Errorf("JobStart %w", restapi.ErrUnavailable)
+	backoff := executor.Backoff{Initial: time.Millisecond, Max: time.Millisecond, Budget: time.Second}
+
+	t.Run("run", func(t *testing.T) {
+		t.Parallel()
+		client := &mocks.Client{}
+		client.On("JobStart", mock.Anything, mock.Anything).Return(nil, unavailable).Once()
+		client.On("Import", mock.Anything, mock.MatchedBy(func(dump *job.Dump) bool {
+			return len(dump.Executions) == 1 && *dump.Executions[0].ExitCode == 3
+		}), job.ConflictOverwrite).Return(nil, unavailable).Once()
+
+		dir := t.TempDir()
+		buffer := executor.NewBuffer(dir)
+		assert.NoError(t, buffer.SaveJobConfig("job", &restapi.JobStartOut{}))
+		exectr := executor.NewExecutor(client,
+			executor.WithUnavailablePolicy(executor.UnavailableRun, backoff),
+			executor.WithBuffer(buffer),
+		)
+		exitCode, err := exectr.StartAndWatch(context.Background(), "job", []string{"sh", "-c", "exit 3"})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, exitCode)
+
+		// Buffered executions, the job config is kept in a subdirectory.
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+
+		client.AssertExpectations(t)
+
+		// The next start replays the buffered execution.
+		client = &mocks.Client{}
+		client.On("JobStart", mock.Anything, mock.Anything).Return(&restapi.JobStartOut{ID: uuid.New()}, nil).Once()
+		client.On("JobFinish", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		client.On("Import", mock.Anything, mock.Anything, job.ConflictOverwrite).
+			Return(&job.ImportResult{Created: 1}, nil).Once()
+
+		exectr = executor.NewExecutor(client, executor.WithBuffer(executor.NewBuffer(dir)))
+		exitCode, err = exectr.StartAndWatch(context.Background(), "job", []string{"true"})
+		assert.NoError(t, err)
+		assert.Equal(t, executor.ExitOK, exitCode)
+
+		files, err = filepath.Glob(filepath.Join(dir, "*.json"))
+		assert.NoError(t, err)
+		assert.Len(t, files, 0)
+	})
+
+	t.Run("run never started job", func(t *testing.T) {
+		t.Parallel()
+		client := &mocks.Client{}
+		client.On("JobStart", mock.Anything, mock.Anything).Return(nil, unavailable).Once()
+
+		exectr := executor.NewExecutor(client,
+			executor.WithUnavailablePolicy(executor.UnavailableRun, backoff),
+			executor.WithBuffer(executor.NewBuffer(t.TempDir())),
+		)
+		exitCode, err := exectr.StartAndWatch(context.Background(), "job", []string{"true"})
+		assert.Error(t, err)
+		assert.Equal(t, executor.ExitServerUnavailable, exitCode)
+		client.AssertExpectations(t)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		t.Parallel()
+		client := &mocks.Client{}
+		client.On("JobStart", mock.Anything, mock.Anything).Return(nil, unavailable).Twice()
+		client.On("JobStart", mock.Anything, mock.Anything).Return(&restapi.JobStartOut{ID: uuid.New()}, nil).Once()
+		client.On("JobFinish", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+		exectr := executor.NewExecutor(client, executor.WithUnavailablePolicy(executor.UnavailableRetry, backoff))
+		exitCode, err := exectr.StartAndWatch(context.Background(), "job", []string{"true"})
+		assert.NoError(t, err)
+		assert.Equal(t, executor.ExitOK, exitCode)
+		client.AssertExpectations(t)
+	})
+
+	t.Run("retry budget is spent", func(t *testing.T) {
+		t.Parallel()
+		client := &mocks.Client{}
+		client.On("JobStart", mock.Anything, mock.Anything).Return(nil, unavailable)
+
+		exectr := executor.NewExecutor(client, executor.WithUnavailablePolicy(executor.UnavailableRetry, executor.Backoff{
+			Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, Budget: 100 * time.Millisecond,
+		}))
+		exitCode, err := exectr.StartAndWatch(context.Background(), "job", []string{"true"})
+		assert.Error(t, err)
+		assert.Equal(t, executor.ExitServerUnavailable, exitCode)
+	})
+}
+
+func TestLockWait(t *testing.T) {
+	t.Parallel()
+	waiter := job.Waiter{ID: uuid.New(), Position: 1}
+	locked := fmt.Errorf("JobStart %w", &restapi.LockWaitError{Waiter: waiter})
+
+	t.Run("lock is got", func(t *testing.T) {
+		t.Parallel()
+		client := &mocks.Client{}
+		client.On("JobStart", mock.Anything, mock.MatchedBy(func(in *restapi.JobStartIn) bool {
+			return in.Wait > 0 && in.WaiterID == nil
+		})).Return(nil, locked).Once()
+		client.On("JobStart", mock.Anything, mock.MatchedBy(func(in *restapi.JobStartIn) bool {
+			return in.WaiterID != nil && *in.WaiterID == waiter.ID
+		})).Return(&restapi.JobStartOut{ID: uuid.New()}, nil).Once()
+		client.On("JobFinish", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+		exectr := executor.NewExecutor(client, executor.WithLockWait(executor.LockWait{Enabled: true}))
+		exitCode, err := exectr.StartAndWatch(context.Background(), "job", []string{"true"})
+		assert.NoError(t, err)
+		assert.Equal(t, executor.ExitOK, exitCode)
+		client.AssertExpectations(t)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		client := &mocks.Client{}
+		client.On("JobStart", mock.Anything, mock.Anything).Return(nil, locked)
+		client.On("WaiterLeave", mock.Anything, waiter.ID).Return(nil).Once()
+
+		exectr := executor.NewExecutor(client, executor.WithLockWait(executor.LockWait{
+			Enabled: true,
+			Timeout: 20 * time.Millisecond,
+		}))
+		exitCode, err := exectr.StartAndWatch(context.Background(), "job", []string{"true"})
+		assert.Error(t, err)
+		assert.Equal(t, executor.ExitLockRefused, exitCode)
+		client.AssertExpectations(t)
+	})
+}
+
+func TestUsageReported(t *testing.T) {
+	t.Parallel()
+	client := &mocks.Client{}
+	client.On("JobStart", mock.Anything, mock.Anything).Return(&restapi.JobStartOut{ID: uuid.New()}, nil)
+	client.On("JobFinish", mock.Anything, mock.Anything, mock.MatchedBy(func(in *restapi.JobFinishIn) bool {
+		return in.Usage != nil && in.Usage.MaxRSS > 0 && in.Usage.UserCPU+in.Usage.SystemCPU > 0
+	})).Return(nil).Once()
+
+	exectr := executor.NewExecutor(client, executor.WithUsageSampling(10*time.Millisecond))
+	exitCode, err := exectr.StartAndWatch(context.Background(), "job",
+		[]string{"sh", "-c", "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done"})
+	assert.NoError(t, err)
+	assert.Equal(t, executor.ExitOK, exitCode)
+	client.AssertExpectations(t)
+}
+
+func TestCommandEnv(t *testing.T) {
+	t.Parallel()
+	executionID := uuid.New()
+	client := &mocks.Client{}
+	client.On("JobStart", mock.Anything, mock.MatchedBy(func(in *restapi.JobStartIn) bool {
+		return in.Params["date"] == "today"
+	})).Return(&restapi.JobStartOut{
+		ID:     executionID,
+		Env:    map[string]string{"APP_MODE": "prod"},
+		Params: map[string]string{"date": "today", "batch-size": "10"},
+	}, nil)
+	client.On("JobFinish", mock.Anything, executionID, mock.Anything).Return(nil).Once()
+
+	out, err := ioutil.TempFile(t.TempDir(), "out")
+	assert.NoError(t, err)
+	defer out.Close()
+
+	exectr := executor.NewExecutor(client,
+		executor.WithOutFile(out),
+		executor.WithServerURL("http://jobs:8080"),
+		executor.WithParams(map[string]string{"date": "today"}),
+	)
+	exitCode, err := exectr.StartAndWatch(context.Background(), "job", []string{"env"})
+	assert.NoError(t, err)
+	assert.Equal(t, executor.ExitOK, exitCode)
+
+	data, err := ioutil.ReadFile(out.Name())
+	assert.NoError(t, err)
+	for _, variable := range []string{
+		"APP_MODE=prod",
+		"JOBS_PARAM_DATE=today",
+		"JOBS_PARAM_BATCH_SIZE=10",
+		"JOBS_EXECUTION_ID=" + executionID.String(),
+		"JOBS_JOB_NAME=job",
+		"JOBS_SERVER_URL=http://jobs:8080",
+	} {
+		assert.Contains(t, string(data), variable+"\n")
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// ForwardedSignals are passed from the executor to the command's process group.
+var ForwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// setProcessGroup starts the command in its own process group, so its children
+// can be signaled together and aren't affected by signals sent to the executor's group.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	sysSig, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("%w: %v", errUnsupportedSignal, sig)
+	}
+
+	// The group id equals the pid of its leader, negative pid means the whole group.
+	if err := syscall.Kill(-cmd.Process.Pid, sysSig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("signal process group: %w", err)
+	}
+
+	return nil
+}
+
+func killGroup(cmd *exec.Cmd) error {
+	return signalGroup(cmd, syscall.SIGKILL)
+}
+
+func processExists(pid int) bool {
+	// Non-positive pids address process groups, not a process.
+	return pid > 0 && syscall.Kill(pid, 0) == nil
+}
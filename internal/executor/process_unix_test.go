@@ -0,0 +1,183 @@
+//go:build !windows
+// +build !windows
+
+package executor_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/executor"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/antgubarev/jobs/internal/restapi/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const echoFixture = "../../tests/fixtures/echo.sh"
+
+func newClientMock() *mocks.Client {
+	client := &mocks.Client{}
+	client.On("JobStart", mock.Anything, mock.Anything).Return(&restapi.JobStartOut{ID: uuid.New()}, nil)
+	client.On("JobFinish", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	return client
+}
+
+func TestSignalForwarding(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		signal   os.Signal
+		output   string
+		exitCode int
+	}{
+		{name: "trapped by command", signal: syscall.SIGINT, output: "SIGINT", exitCode: 0},
+		{name: "not trapped by command", signal: syscall.SIGTERM, exitCode: 128 + int(syscall.SIGTERM)},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			out, err := ioutil.TempFile(t.TempDir(), "out")
+			assert.NoError(t, err)
+			defer out.Close()
+
+			cmdChan := make(chan *exec.Cmd, 1)
+			signals := make(chan os.Signal, 1)
+			exectr := executor.NewExecutor(newClientMock(),
+				executor.WithOutFile(out),
+				executor.WithCmdChan(cmdChan),
+				executor.WithSignals(signals),
+			)
+
+			go func() {
+				<-cmdChan
+				waitOutput(t, out.Name(), "step 1")
+				signals <- testCase.signal
+			}()
+
+			exitCode, err := exectr.StartAndWatch(context.Background(), "job", []string{echoFixture, "100", "0.1"})
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.exitCode, exitCode)
+
+			output, err := ioutil.ReadFile(out.Name())
+			assert.NoError(t, err)
+			assert.Contains(t, string(output), testCase.output)
+			assert.NotContains(t, string(output), "finish")
+		})
+	}
+}
+
+func TestShutdownKillsProcessGroup(t *testing.T) {
+	t.Parallel()
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	// The command ignores SIGTERM and leaves a child, both must be killed after the grace period.
+	script := `trap "" TERM; sleep 30 & echo $! > ` + pidFile + `; wait`
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmdChan := make(chan *exec.Cmd, 1)
+	exectr := executor.NewExecutor(newClientMock(),
+		executor.WithCmdChan(cmdChan),
+		executor.WithSignals(make(chan os.Signal)),
+		executor.WithGracePeriod(100*time.Millisecond),
+	)
+
+	go func() {
+		<-cmdChan
+		waitOutput(t, pidFile, "\n")
+		cancel()
+	}()
+
+	started := time.Now()
+	exitCode, err := exectr.StartAndWatch(ctx, "job", []string{"sh", "-c", script})
+	assert.NoError(t, err)
+	assert.Equal(t, 128+int(syscall.SIGKILL), exitCode)
+	assert.Less(t, time.Since(started), 10*time.Second)
+
+	data, err := ioutil.ReadFile(pidFile)
+	assert.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return !processAlive(pid)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStopRequest(t *testing.T) {
+	t.Parallel()
+	stopRequestedAt := time.Now()
+	client := &mocks.Client{}
+	client.On("JobStart", mock.Anything, mock.Anything).Return(&restapi.JobStartOut{ID: uuid.New()}, nil)
+	client.On("ExecutionGet", mock.Anything, mock.Anything).
+		Return(&job.Execution{Status: job.StatusRunning, StopRequestedAt: &stopRequestedAt}, nil)
+	client.On("JobFinish", mock.Anything, mock.Anything, mock.MatchedBy(func(in *restapi.JobFinishIn) bool {
+		return in.Msg != nil && strings.HasPrefix(*in.Msg, "stopped by request")
+	})).Return(nil)
+
+	exectr := executor.NewExecutor(client,
+		executor.WithSignals(make(chan os.Signal)),
+		executor.WithGracePeriod(time.Second),
+		executor.WithStopCheck(10*time.Millisecond),
+	)
+
+	started := time.Now()
+	exitCode, err := exectr.StartAndWatch(context.Background(), "job", []string{"sleep", "30"})
+	assert.NoError(t, err)
+	assert.Equal(t, 128+int(syscall.SIGTERM), exitCode)
+	assert.Less(t, time.Since(started), 10*time.Second)
+	client.AssertExpectations(t)
+}
+
+func TestStopDeletedExecution(t *testing.T) {
+	t.Parallel()
+	client := &mocks.Client{}
+	client.On("JobStart", mock.Anything, mock.Anything).Return(&restapi.JobStartOut{ID: uuid.New()}, nil)
+	client.On("ExecutionGet", mock.Anything, mock.Anything).Return(nil, restapi.ErrExecutionNotFound)
+	client.On("JobFinish", mock.Anything, mock.Anything, mock.Anything).Return(restapi.ErrExecutionNotFound)
+
+	exectr := executor.NewExecutor(client,
+		executor.WithSignals(make(chan os.Signal)),
+		executor.WithGracePeriod(time.Second),
+		executor.WithStopCheck(10*time.Millisecond),
+	)
+
+	started := time.Now()
+	exitCode, _ := exectr.StartAndWatch(context.Background(), "job", []string{"sleep", "30"})
+	assert.Equal(t, 128+int(syscall.SIGTERM), exitCode)
+	assert.Less(t, time.Since(started), 10*time.Second)
+}
+
+func waitOutput(t *testing.T, file string, expected string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		data, err := ioutil.ReadFile(file)
+		if err == nil && strings.Contains(string(data), expected) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Errorf("%s doesn't contain %q", file, expected)
+}
+
+// processAlive reports false for exited processes and zombies which are waiting to be reaped.
+func processAlive(pid int) bool {
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return syscall.Kill(pid, 0) == nil
+	}
+	fields := strings.Fields(string(stat))
+
+	return len(fields) > 2 && fields[2] != "Z"
+}
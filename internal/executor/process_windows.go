@@ -0,0 +1,51 @@
+//go:build windows
+// +build windows
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// ForwardedSignals are passed from the executor to the command.
+var ForwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+}
+
+// setProcessGroup does nothing, windows has no process groups.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	if err := cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("signal process: %w", err)
+	}
+
+	return nil
+}
+
+func killGroup(cmd *exec.Cmd) error {
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("kill process: %w", err)
+	}
+
+	return nil
+}
+
+// processExists relies on FindProcess, which opens the process on windows.
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+
+	return true
+}
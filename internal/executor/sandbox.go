@@ -0,0 +1,149 @@
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+)
+
+// ShimCommand is the first argument which makes job-exec the sandbox shim, see RunShim.
+const ShimCommand = "__sandbox-shim"
+
+// shimSpecEnv passes the sandbox to the shim, the shim removes it from the command's environment.
+const shimSpecEnv = "JOBS_SANDBOX_SPEC"
+
+var errSandboxUnsupported = errors.New("sandbox is unsupported")
+
+type shimSpec struct {
+	Sandbox job.Sandbox `json:"sandbox"`
+	// Cgroup is the path of the cgroup directory to join.
+	Cgroup string `json:"cgroup,omitempty"`
+}
+
+// WithShim sets the binary which runs RunShim when started with ShimCommand, usually job-exec itself.
+// Without it only the working directory and environment of the sandbox can be applied.
+func WithShim(path string) Option {
+	return func(o *options) {
+		o.shim = path
+	}
+}
+
+// WithCgroupRoot sets the cgroup v2 directory where cgroups of executions are created,
+// the executor has to be able to create sub-cgroups there.
+func WithCgroupRoot(path string) Option {
+	return func(o *options) {
+		o.cgroupRoot = path
+	}
+}
+
+// command creates the command to launch in the sandbox, env is added to the environment allowed
+// by the sandbox. Limits, priorities and credentials are applied
+// by the shim, the shim execs the command after that. Cgroup is created if cgroup limits are set and
+// cgroup v2 is available, it has to be removed after the command exits.
+func (e *Executor) command(args []string, sandbox *job.Sandbox, env []string,
+	executionID uuid.UUID,
+) (*exec.Cmd, *cgroup, error) {
+	env = append(sandbox.FilterEnv(os.Environ()), env...)
+	if !sandbox.NeedsShim() {
+		cmd := exec.Command(args[0], args[1:]...) //nolint:gosec
+		cmd.Env = env
+		if sandbox != nil {
+			cmd.Dir = sandbox.WorkDir
+		}
+
+		return cmd, nil, nil
+	}
+	if e.shim == "" {
+		return nil, nil, fmt.Errorf("command: %w: shim isn't set", errSandboxUnsupported)
+	}
+
+	spec := shimSpec{Sandbox: *sandbox}
+	var cgrp *cgroup
+	if sandbox.Cgroup != nil {
+		created, err := newCgroup(e.cgroupRoot, executionID, *sandbox.Cgroup)
+		if err != nil {
+			glog.Warningf("cgroup limits aren't applied: %v", err)
+		} else {
+			cgrp = created
+			spec.Cgroup = cgrp.path
+		}
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, cgrp, fmt.Errorf("command: marshal sandbox: %w", err)
+	}
+
+	cmd := exec.Command(e.shim, append([]string{ShimCommand, "--"}, args...)...) //nolint:gosec
+	cmd.Env = append(env, shimSpecEnv+"="+string(data))
+	cmd.Dir = sandbox.WorkDir
+
+	return cmd, cgrp, nil
+}
+
+// RunShim applies the sandbox passed by the executor to the current process and execs the command
+// from args. It returns only if the command can't be executed, the result is the exit code.
+func RunShim(args []string) int {
+	// Niceness and io priority are per thread on Linux, exec has to be called by the same thread.
+	runtime.LockOSThread()
+
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "sandbox shim: command is required")
+
+		return ExitUsage
+	}
+
+	var spec shimSpec
+	if err := json.Unmarshal([]byte(os.Getenv(shimSpecEnv)), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox shim: invalid sandbox: %v\n", err)
+
+		return ExitCannotExecute
+	}
+	if err := os.Unsetenv(shimSpecEnv); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox shim: %v\n", err)
+
+		return ExitCannotExecute
+	}
+	if err := applySandbox(&spec); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox shim: %v\n", err)
+
+		return ExitCannotExecute
+	}
+
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox shim: %v\n", err)
+
+		return commandErrorCode(err)
+	}
+	err = execCommand(path, args, os.Environ())
+	fmt.Fprintf(os.Stderr, "sandbox shim: exec %s: %v\n", path, err)
+
+	return commandErrorCode(err)
+}
+
+// cgroup is the cgroup v2 of one execution.
+type cgroup struct {
+	path string
+}
+
+// remove removes the cgroup, it's possible only when all its processes have exited.
+func (c *cgroup) remove() error {
+	if c == nil {
+		return nil
+	}
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove cgroup: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/google/uuid"
+)
+
+const (
+	cgroupMount     = "/sys/fs/cgroup"
+	cgroupCPUPeriod = 100000
+
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+var ioniceClasses = map[string]int{
+	job.IONiceRealtime:   1,
+	job.IONiceBestEffort: 2,
+	job.IONiceIdle:       3,
+}
+
+// DefaultCgroupRoot is the cgroup where cgroups of executions are created by default.
+const DefaultCgroupRoot = cgroupMount + "/jobs"
+
+// applySandbox is called by the shim, the order matters: the cgroup is joined and priorities are set
+// while the shim has privileges of the executor, credentials are dropped the last.
+func applySandbox(spec *shimSpec) error {
+	sandbox := spec.Sandbox
+	if spec.Cgroup != "" {
+		if err := writeCgroupFile(spec.Cgroup, "cgroup.procs", strconv.Itoa(os.Getpid())); err != nil {
+			return fmt.Errorf("join cgroup: %w", err)
+		}
+	}
+	if sandbox.Limits != nil {
+		if err := setLimits(sandbox.Limits); err != nil {
+			return err
+		}
+	}
+	if sandbox.Nice != nil {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, *sandbox.Nice); err != nil {
+			return fmt.Errorf("set nice: %w", err)
+		}
+	}
+	if sandbox.IONice != nil {
+		prio := ioniceClasses[sandbox.IONice.Class]<<ioprioClassShift | sandbox.IONice.Level
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(prio)); errno != 0 {
+			return fmt.Errorf("set ionice: %w", errno)
+		}
+	}
+
+	return setCredentials(sandbox.User, sandbox.Group)
+}
+
+func setLimits(limits *job.Limits) error {
+	for resource, value := range map[int]*uint64{
+		syscall.RLIMIT_CPU:    limits.CPUSeconds,
+		syscall.RLIMIT_AS:     limits.AddressSpace,
+		syscall.RLIMIT_NOFILE: limits.OpenFiles,
+	} {
+		if value == nil {
+			continue
+		}
+		if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: *value, Max: *value}); err != nil {
+			return fmt.Errorf("set rlimit %d: %w", resource, err)
+		}
+	}
+
+	return nil
+}
+
+// setCredentials switches to the user and group, the user's primary group is used if the group isn't set.
+// The user's supplementary groups are kept.
+func setCredentials(userName, groupName string) error {
+	uid, gid, groups := -1, -1, []int(nil)
+	if userName != "" {
+		var err error
+		if uid, gid, groups, err = lookupUser(userName); err != nil {
+			return err
+		}
+	}
+	if groupName != "" {
+		grp, err := user.LookupGroup(groupName)
+		if err != nil {
+			grp, err = user.LookupGroupId(groupName)
+		}
+		if err != nil {
+			return fmt.Errorf("lookup group: %w", err)
+		}
+		if gid, err = strconv.Atoi(grp.Gid); err != nil {
+			return fmt.Errorf("lookup group: %w", err)
+		}
+		groups = append(groups, gid)
+	}
+
+	if gid >= 0 {
+		if err := syscall.Setgroups(groups); err != nil {
+			return fmt.Errorf("set groups: %w", err)
+		}
+		if err := syscall.Setgid(gid); err != nil {
+			return fmt.Errorf("set gid: %w", err)
+		}
+	}
+	if uid >= 0 {
+		if err := syscall.Setuid(uid); err != nil {
+			return fmt.Errorf("set uid: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// lookupUser returns ids of the user by the name or the id, groups are the user's supplementary groups
+// with the primary one.
+func lookupUser(userName string) (uid, gid int, groups []int, err error) {
+	usr, err := user.Lookup(userName)
+	if err != nil {
+		usr, err = user.LookupId(userName)
+	}
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("lookup user: %w", err)
+	}
+	if uid, err = strconv.Atoi(usr.Uid); err != nil {
+		return 0, 0, nil, fmt.Errorf("lookup user: %w", err)
+	}
+	if gid, err = strconv.Atoi(usr.Gid); err != nil {
+		return 0, 0, nil, fmt.Errorf("lookup user: %w", err)
+	}
+	groupIDs, err := usr.GroupIds()
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("lookup groups of %s: %w", userName, err)
+	}
+	groups = []int{gid}
+	for _, groupID := range groupIDs {
+		id, err := strconv.Atoi(groupID)
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("lookup groups of %s: %w", userName, err)
+		}
+		if id != gid {
+			groups = append(groups, id)
+		}
+	}
+
+	return uid, gid, groups, nil
+}
+
+func execCommand(path string, args []string, env []string) error {
+	return syscall.Exec(path, args, env) //nolint:gosec
+}
+
+// newCgroup creates the cgroup of the execution under root and sets its limits.
+func newCgroup(root string, executionID uuid.UUID, limits job.CgroupLimits) (*cgroup, error) {
+	if _, err := os.Stat(filepath.Join(cgroupMount, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroup v2 isn't available: %w", err)
+	}
+	if root == "" {
+		root = DefaultCgroupRoot
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create cgroup root: %w", err)
+	}
+	if err := writeCgroupFile(root, "cgroup.subtree_control", "+memory +cpu"); err != nil {
+		return nil, fmt.Errorf("enable cgroup controllers: %w", err)
+	}
+
+	cgrp := &cgroup{path: filepath.Join(root, "execution-"+executionID.String())}
+	if err := os.Mkdir(cgrp.path, 0o755); err != nil {
+		return nil, fmt.Errorf("create cgroup: %w", err)
+	}
+	if limits.MemoryMax > 0 {
+		if err := writeCgroupFile(cgrp.path, "memory.max", strconv.FormatInt(limits.MemoryMax, 10)); err != nil {
+			_ = cgrp.remove()
+
+			return nil, fmt.Errorf("set memory.max: %w", err)
+		}
+	}
+	if limits.CPUs > 0 {
+		quota := int64(limits.CPUs * cgroupCPUPeriod)
+		if err := writeCgroupFile(cgrp.path, "cpu.max", fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)); err != nil {
+			_ = cgrp.remove()
+
+			return nil, fmt.Errorf("set cpu.max: %w", err)
+		}
+	}
+
+	return cgrp, nil
+}
+
+func writeCgroupFile(dir, name, value string) error {
+	return os.WriteFile(filepath.Join(dir, name), []byte(value), 0o600)
+}
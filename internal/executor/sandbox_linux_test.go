@@ -0,0 +1,85 @@
+package executor_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/executor"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/antgubarev/jobs/internal/restapi/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain lets the test binary be the sandbox shim like job-exec.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == executor.ShimCommand {
+		os.Exit(executor.RunShim(os.Args[2:]))
+	}
+	os.Exit(m.Run())
+}
+
+func TestSandbox(t *testing.T) {
+	t.Parallel()
+	openFiles := uint64(64)
+	nice := 5
+	workDir := t.TempDir()
+	sandbox := &job.Sandbox{
+		Limits:   &job.Limits{OpenFiles: &openFiles},
+		Nice:     &nice,
+		IONice:   &job.IONice{Class: job.IONiceBestEffort, Level: 7},
+		WorkDir:  workDir,
+		EnvAllow: []string{"PATH"},
+	}
+
+	client := &mocks.Client{}
+	client.On("JobStart", mock.Anything, mock.Anything).
+		Return(&restapi.JobStartOut{ID: uuid.New(), Sandbox: sandbox}, nil)
+	client.On("JobFinish", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	out, err := ioutil.TempFile(t.TempDir(), "out")
+	require.NoError(t, err)
+	defer out.Close()
+
+	exectr := executor.NewExecutor(client, executor.WithShim(os.Args[0]), executor.WithOutFile(out))
+	exitCode, err := exectr.StartAndWatch(context.Background(), "job",
+		[]string{"sh", "-c", "pwd; ulimit -n; nice; env"})
+	require.NoError(t, err)
+	assert.Equal(t, executor.ExitOK, exitCode)
+
+	data, err := ioutil.ReadFile(out.Name())
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.GreaterOrEqual(t, len(lines), 3, "%s", data)
+	assert.Equal(t, workDir, lines[0])
+	assert.Equal(t, "64", lines[1])
+	assert.Equal(t, "5", lines[2])
+	for _, variable := range lines[3:] {
+		name := strings.SplitN(variable, "=", 2)[0]
+		assert.Contains(t, []string{
+			"PATH", "PWD", "SHLVL", "_", "OLDPWD", job.EnvExecutionID, job.EnvJobName,
+		}, name)
+	}
+}
+
+func TestSandboxWithoutShim(t *testing.T) {
+	t.Parallel()
+	nice := 5
+	client := &mocks.Client{}
+	client.On("JobStart", mock.Anything, mock.Anything).
+		Return(&restapi.JobStartOut{ID: uuid.New(), Sandbox: &job.Sandbox{Nice: &nice}}, nil)
+	client.On("JobFinish", mock.Anything, mock.Anything, mock.MatchedBy(func(in *restapi.JobFinishIn) bool {
+		return *in.ExitCode == executor.ExitCannotExecute
+	})).Return(nil).Once()
+
+	exitCode, err := executor.NewExecutor(client).StartAndWatch(context.Background(), "job", []string{"true"})
+	assert.Error(t, err)
+	assert.Equal(t, executor.ExitCannotExecute, exitCode)
+	client.AssertExpectations(t)
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+// +build !linux
+
+package executor
+
+import (
+	"fmt"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/google/uuid"
+)
+
+// DefaultCgroupRoot is empty, there are no cgroups on this platform.
+const DefaultCgroupRoot = ""
+
+func applySandbox(spec *shimSpec) error {
+	return fmt.Errorf("apply sandbox: %w", errSandboxUnsupported)
+}
+
+func execCommand(path string, args []string, env []string) error {
+	return fmt.Errorf("exec: %w", errSandboxUnsupported)
+}
+
+func newCgroup(root string, executionID uuid.UUID, limits job.CgroupLimits) (*cgroup, error) {
+	return nil, fmt.Errorf("cgroup: %w", errSandboxUnsupported)
+}
@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/golang/glog"
+)
+
+// UnavailablePolicy defines what to do when the server can't be reached at start.
+type UnavailablePolicy string
+
+const (
+	// UnavailableFail doesn't run the command.
+	UnavailableFail UnavailablePolicy = "fail"
+	// UnavailableRun runs the command without the lock, the execution is buffered on disk
+	// and replayed to the server when it comes back.
+	UnavailableRun UnavailablePolicy = "run"
+	// UnavailableRetry retries the start with backoff until the budget is spent, then fails.
+	UnavailableRetry UnavailablePolicy = "retry"
+)
+
+var errUndefinedPolicy = errors.New("undefined policy")
+
+func ParseUnavailablePolicy(policy string) (UnavailablePolicy, error) {
+	switch UnavailablePolicy(policy) {
+	case UnavailableFail, UnavailableRun, UnavailableRetry:
+		return UnavailablePolicy(policy), nil
+	default:
+		return "", fmt.Errorf("%w: %s, can be `fail`, `run` or `retry`", errUndefinedPolicy, policy)
+	}
+}
+
+// Backoff doubles the delay between retries from Initial up to Max, retries stop after Budget.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	Budget  time.Duration
+}
+
+var DefaultBackoff = Backoff{
+	Initial: time.Second,
+	Max:     30 * time.Second,
+	Budget:  5 * time.Minute,
+}
+
+func WithUnavailablePolicy(policy UnavailablePolicy, backoff Backoff) Option {
+	return func(o *options) {
+		o.unavailablePolicy = policy
+		o.backoff = backoff
+	}
+}
+
+// WithBuffer sets where executions are kept while the server is unavailable.
+func WithBuffer(buffer *Buffer) Option {
+	return func(o *options) {
+		o.buffer = buffer
+	}
+}
+
+// start registers the execution. With the retry policy unavailable server is retried,
+// with lock wait the start waits in the server queue until the lock is got.
+func (e *Executor) start(ctx context.Context, startIn *restapi.JobStartIn) (*restapi.JobStartOut, error) {
+	delay := e.backoff.Initial
+	deadline := time.Now().Add(e.backoff.Budget)
+	waitDeadline := time.Now().Add(e.lockWait.Timeout)
+
+	for {
+		if e.lockWait.Enabled {
+			poll := lockWaitPoll
+			if e.lockWait.Timeout > 0 && time.Until(waitDeadline) < poll {
+				poll = time.Until(waitDeadline)
+			}
+			startIn.Wait = job.Duration(poll)
+		}
+
+		out, err := e.client.JobStart(ctx, startIn)
+		if err == nil {
+			return out, nil
+		}
+
+		var waitErr *restapi.LockWaitError
+		if errors.As(err, &waitErr) {
+			if e.lockWait.Timeout == 0 || time.Now().Before(waitDeadline) {
+				glog.Infof("job is locked, waiting in the queue, position %d", waitErr.Waiter.Position)
+				startIn.WaiterID = &waitErr.Waiter.ID
+
+				continue
+			}
+			// Let the next waiter go without waiting for expiration of our place.
+			if err := e.client.WaiterLeave(ctx, waitErr.Waiter.ID); err != nil {
+				glog.Warningf("leave lock queue: %v", err)
+			}
+		}
+
+		if e.unavailablePolicy != UnavailableRetry || !errors.Is(err, restapi.ErrUnavailable) ||
+			time.Now().Add(delay).After(deadline) {
+			return nil, fmt.Errorf("send job start to api: %w", err)
+		}
+
+		glog.Warningf("server is unavailable, retry in %s: %v", delay, err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("retry job start: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > e.backoff.Max {
+			delay = e.backoff.Max
+		}
+	}
+}
+
+func (e *Executor) replay(ctx context.Context) {
+	if e.buffer == nil {
+		return
+	}
+
+	replayed, err := e.buffer.Replay(ctx, e.client)
+	if err != nil {
+		glog.Warningf("replay buffered executions: %v", err)
+
+		return
+	}
+	if replayed > 0 {
+		glog.Infof("replayed %d buffered executions", replayed)
+	}
+}
@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/golang/glog"
+)
+
+var errSamplingUnsupported = errors.New("usage sampling is unsupported on this platform")
+
+// WithUsageSampling samples RSS of the command's process group every interval during the run,
+// zero interval disables sampling. Usage at exit is reported anyway.
+func WithUsageSampling(interval time.Duration) Option {
+	return func(o *options) {
+		o.sampleInterval = interval
+	}
+}
+
+// processUsage returns the resource usage of the finished process and its waited children.
+func processUsage(state *os.ProcessState) *job.Usage {
+	if state == nil {
+		return nil
+	}
+	usage := &job.Usage{
+		UserCPU:   job.Duration(state.UserTime()),
+		SystemCPU: job.Duration(state.SystemTime()),
+	}
+	setSysUsage(state, usage)
+
+	return usage
+}
+
+// sampler keeps the peak RSS of the process group while the command is running.
+type sampler struct {
+	pgid     int
+	interval time.Duration
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+
+	peakRSS int64
+	samples int
+}
+
+func startSampler(pgid int, interval time.Duration) *sampler {
+	smplr := &sampler{pgid: pgid, interval: interval, stopCh: make(chan struct{})}
+	smplr.wg.Add(1)
+	go smplr.run()
+
+	return smplr
+}
+
+func (s *sampler) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if !s.sample() {
+			return
+		}
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample returns false if sampling can't be continued.
+func (s *sampler) sample() bool {
+	rss, err := groupRSS(s.pgid)
+	if err != nil {
+		if errors.Is(err, errSamplingUnsupported) {
+			glog.Warningf("sample usage: %v", err)
+
+			return false
+		}
+
+		return true
+	}
+	s.samples++
+	if rss > s.peakRSS {
+		s.peakRSS = rss
+	}
+
+	return true
+}
+
+// stop stops sampling and adds its result to the usage.
+func (s *sampler) stop(usage *job.Usage) {
+	close(s.stopCh)
+	s.wg.Wait()
+	if usage != nil && s.samples > 0 {
+		usage.PeakGroupRSS = s.peakRSS
+		usage.Samples = s.samples
+	}
+}
@@ -0,0 +1,81 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/antgubarev/jobs/internal/job"
+)
+
+// rss and pgrp fields of /proc/<pid>/stat, counted from the field after the command name.
+const (
+	statPgrpField = 2
+	statRSSField  = 21
+)
+
+// setSysUsage adds rusage of the process, Maxrss is in kilobytes on Linux.
+func setSysUsage(state *os.ProcessState, usage *job.Usage) {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || rusage == nil {
+		return
+	}
+	usage.MaxRSS = rusage.Maxrss * 1024
+	usage.InBlock = rusage.Inblock
+	usage.OutBlock = rusage.Oublock
+}
+
+// groupRSS sums RSS of all processes of the process group in bytes.
+func groupRSS(pgid int) (int64, error) {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return 0, fmt.Errorf("group rss: %w", err)
+	}
+
+	var total int64
+	found := false
+	for _, path := range stats {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// The process has gone.
+			continue
+		}
+		pgrp, rss, err := parseStat(data)
+		if err != nil || pgrp != pgid {
+			continue
+		}
+		found = true
+		total += rss * int64(os.Getpagesize())
+	}
+	if !found {
+		return 0, fmt.Errorf("group rss: process group %d not found", pgid)
+	}
+
+	return total, nil
+}
+
+// parseStat returns the process group and RSS in pages from /proc/<pid>/stat.
+// The command name can contain spaces and parentheses, so fields are counted after the last ')'.
+func parseStat(data []byte) (pgrp int, rss int64, err error) {
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("parse stat: %w", errInvalidArguments)
+	}
+	fields := bytes.Fields(data[end+1:])
+	if len(fields) <= statRSSField {
+		return 0, 0, fmt.Errorf("parse stat: %w", errInvalidArguments)
+	}
+	pgrp, err = strconv.Atoi(string(fields[statPgrpField]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse stat: %w", err)
+	}
+	rss, err = strconv.ParseInt(string(fields[statRSSField]), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse stat: %w", err)
+	}
+
+	return pgrp, rss, nil
+}
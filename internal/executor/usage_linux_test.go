@@ -0,0 +1,42 @@
+package executor
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStat(t *testing.T) {
+	t.Parallel()
+	stat := "4242 (my (odd) cmd) S 1 4200 4200 0 -1 4194560 100 0 0 0 1 2 0 0 20 0 1 0 100 10000000 321 " +
+		"18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0\n"
+	pgrp, rss, err := parseStat([]byte(stat))
+	require.NoError(t, err)
+	assert.Equal(t, 4200, pgrp)
+	assert.Equal(t, int64(321), rss)
+
+	_, _, err = parseStat([]byte("4242 (cmd) S 1"))
+	assert.Error(t, err)
+}
+
+func TestSampler(t *testing.T) {
+	t.Parallel()
+	cmd := exec.Command("sleep", "1")
+	setProcessGroup(cmd)
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = killGroup(cmd)
+		_ = cmd.Wait()
+	}()
+
+	smplr := startSampler(cmd.Process.Pid, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	usage := &job.Usage{}
+	smplr.stop(usage)
+	assert.Greater(t, usage.Samples, 0)
+	assert.Greater(t, usage.PeakGroupRSS, int64(0))
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package executor
+
+import (
+	"os"
+
+	"github.com/antgubarev/jobs/internal/job"
+)
+
+// setSysUsage does nothing, only CPU times are reported on this platform.
+func setSysUsage(state *os.ProcessState, usage *job.Usage) {}
+
+func groupRSS(pgid int) (int64, error) {
+	return 0, errSamplingUnsupported
+}
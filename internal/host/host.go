@@ -0,0 +1,184 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+type Status string
+
+const (
+	StatusHealthy Status = "healthy"
+	// StatusUnhealthy is set after missed heartbeats, the next heartbeat makes the host healthy again.
+	StatusUnhealthy Status = "unhealthy"
+)
+
+// Host is a machine where executions run, it's registered by the agent running there.
+// Registrations are repeated by the agent as heartbeats.
+type Host struct {
+	Name         string            `json:"name"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	AgentVersion string            `json:"agentVersion,omitempty"`
+	// Capacity is the max number of executions run by the agent at once, zero means no limit.
+	Capacity     int       `json:"capacity,omitempty"`
+	Status       Status    `json:"status"`
+	RegisteredAt time.Time `json:"registeredAt"`
+	LastSeen     time.Time `json:"lastSeen"`
+}
+
+// Storage keeps hosts across restarts of the server.
+type Storage interface {
+	Store(host *Host) error
+	GetAll() ([]Host, error)
+}
+
+// Registry keeps registered hosts in memory and writes them to the storage if it's set.
+type Registry struct {
+	mu      sync.Mutex
+	hosts   map[string]*Host
+	storage Storage
+}
+
+func NewRegistry() *Registry {
+	return &Registry{hosts: make(map[string]*Host)}
+}
+
+// NewStoredRegistry loads hosts from the storage. Healthy hosts are seen at now, so agents have
+// the heartbeat timeout to register again before their executions are lost.
+func NewStoredRegistry(storage Storage, now time.Time) (*Registry, error) {
+	hosts, err := storage.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("load hosts: %w", err)
+	}
+
+	registry := &Registry{hosts: make(map[string]*Host, len(hosts)), storage: storage}
+	for i := range hosts {
+		known := hosts[i]
+		if known.Status == StatusHealthy {
+			known.LastSeen = now
+		}
+		registry.hosts[known.Name] = &known
+	}
+
+	return registry, nil
+}
+
+// store writes the host to the storage, r.mu is held. Failures are logged, the host is kept in memory anyway.
+func (r *Registry) store(known *Host) {
+	if r.storage == nil {
+		return
+	}
+	if err := r.storage.Store(known); err != nil {
+		glog.Errorf("store host %s: %v", known.Name, err)
+	}
+}
+
+// Register adds the host or updates the known one, the first registration time is kept.
+func (r *Registry) Register(registered Host, now time.Time) Host {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registered.RegisteredAt = now
+	if known, ok := r.hosts[registered.Name]; ok {
+		registered.RegisteredAt = known.RegisteredAt
+		if known.Status == StatusUnhealthy {
+			glog.Infof("host %s is healthy again", registered.Name)
+		}
+	}
+	registered.Status = StatusHealthy
+	registered.LastSeen = now
+	r.hosts[registered.Name] = &registered
+	r.store(&registered)
+
+	return registered
+}
+
+func (r *Registry) Get(name string) (Host, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	known, ok := r.hosts[name]
+	if !ok {
+		return Host{}, false
+	}
+
+	return *known, true
+}
+
+// List returns hosts sorted by name.
+func (r *Registry) List() []Host {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hosts := make([]Host, 0, len(r.hosts))
+	for _, known := range r.hosts {
+		hosts = append(hosts, *known)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Name < hosts[j].Name
+	})
+
+	return hosts
+}
+
+// MarkUnhealthy marks healthy hosts which haven't been seen for timeout and returns them.
+func (r *Registry) MarkUnhealthy(timeout time.Duration, now time.Time) []Host {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var marked []Host
+	for _, known := range r.hosts {
+		if known.Status == StatusHealthy && now.Sub(known.LastSeen) > timeout {
+			known.Status = StatusUnhealthy
+			r.store(known)
+			marked = append(marked, *known)
+		}
+	}
+	sort.Slice(marked, func(i, j int) bool {
+		return marked[i].Name < marked[j].Name
+	})
+
+	return marked
+}
+
+// Checker marks hosts with missed heartbeats as unhealthy, onUnhealthy is called once per each of them.
+type Checker struct {
+	registry    *Registry
+	timeout     time.Duration
+	onUnhealthy func(Host)
+}
+
+func NewChecker(registry *Registry, timeout time.Duration, onUnhealthy func(Host)) *Checker {
+	return &Checker{
+		registry:    registry,
+		timeout:     timeout,
+		onUnhealthy: onUnhealthy,
+	}
+}
+
+func (c *Checker) Check(now time.Time) {
+	for _, unhealthy := range c.registry.MarkUnhealthy(c.timeout, now) {
+		glog.Warningf("host %s is unhealthy, last seen at %s", unhealthy.Name, unhealthy.LastSeen.Format(time.RFC3339))
+		c.onUnhealthy(unhealthy)
+	}
+}
+
+// Run checks hosts every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(time.Now())
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package host_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/host"
+	"github.com/antgubarev/jobs/internal/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+	registry := host.NewRegistry()
+	registeredAt := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	registered := registry.Register(host.Host{Name: "node2", AgentVersion: "v0.1.0"}, registeredAt)
+	assert.Equal(t, registeredAt, registered.RegisteredAt)
+	assert.Equal(t, registeredAt, registered.LastSeen)
+	assert.Equal(t, host.StatusHealthy, registered.Status)
+
+	registry.Register(host.Host{Name: "node1"}, registeredAt)
+	registered = registry.Register(host.Host{Name: "node2", AgentVersion: "v0.2.0"}, registeredAt.Add(time.Minute))
+	assert.Equal(t, registeredAt, registered.RegisteredAt)
+	assert.Equal(t, registeredAt.Add(time.Minute), registered.LastSeen)
+	assert.Equal(t, "v0.2.0", registered.AgentVersion)
+
+	known, ok := registry.Get("node2")
+	assert.True(t, ok)
+	assert.Equal(t, registered, known)
+	_, ok = registry.Get("node3")
+	assert.False(t, ok)
+
+	hosts := registry.List()
+	assert.Len(t, hosts, 2)
+	assert.Equal(t, "node1", hosts[0].Name)
+}
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+	registry := host.NewRegistry()
+	now := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+	registry.Register(host.Host{Name: "node1"}, now)
+	registry.Register(host.Host{Name: "node2"}, now.Add(time.Minute))
+
+	var unhealthy []string
+	checker := host.NewChecker(registry, 90*time.Second, func(h host.Host) {
+		unhealthy = append(unhealthy, h.Name)
+	})
+
+	checker.Check(now.Add(time.Minute))
+	assert.Empty(t, unhealthy)
+
+	checker.Check(now.Add(2 * time.Minute))
+	assert.Equal(t, []string{"node1"}, unhealthy)
+	known, _ := registry.Get("node1")
+	assert.Equal(t, host.StatusUnhealthy, known.Status)
+
+	// The host is reported once, the heartbeat makes it healthy again.
+	checker.Check(now.Add(2 * time.Minute))
+	assert.Equal(t, []string{"node1"}, unhealthy)
+	registry.Register(host.Host{Name: "node1"}, now.Add(2*time.Minute))
+	known, _ = registry.Get("node1")
+	assert.Equal(t, host.StatusHealthy, known.Status)
+
+	checker.Check(now.Add(5 * time.Minute))
+	assert.Equal(t, []string{"node1", "node1", "node2"}, unhealthy)
+}
+
+func TestStoredRegistry(t *testing.T) {
+	t.Parallel()
+	storage := memory.NewHostStorage()
+	now := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+	registry, err := host.NewStoredRegistry(storage, now)
+	require.NoError(t, err)
+	registry.Register(host.Host{Name: "node1"}, now)
+	registry.Register(host.Host{Name: "node2"}, now.Add(time.Minute))
+	registry.MarkUnhealthy(90*time.Second, now.Add(2*time.Minute))
+
+	// The server restarts later, the healthy host has the timeout to register again.
+	restartedAt := now.Add(time.Hour)
+	registry, err = host.NewStoredRegistry(storage, restartedAt)
+	require.NoError(t, err)
+	hosts := registry.List()
+	require.Len(t, hosts, 2)
+	assert.Equal(t, host.StatusUnhealthy, hosts[0].Status)
+	assert.Equal(t, now, hosts[0].LastSeen)
+	assert.Equal(t, host.StatusHealthy, hosts[1].Status)
+	assert.Equal(t, restartedAt, hosts[1].LastSeen)
+	assert.Equal(t, now.Add(time.Minute), hosts[1].RegisteredAt)
+
+	assert.Empty(t, registry.MarkUnhealthy(90*time.Second, restartedAt.Add(time.Minute)))
+	assert.Len(t, registry.MarkUnhealthy(90*time.Second, restartedAt.Add(2*time.Minute)), 1)
+}
@@ -0,0 +1,233 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/metrics"
+	"github.com/golang/glog"
+)
+
+// clockSkewTolerance is how early the run may start before the schedule's tick and still be the run of it,
+// starts are reported by executors with their hosts' clocks.
+const clockSkewTolerance = 30 * time.Second
+
+// Cadence is how often the job is expected to run, it's checked by the server's watchdog regardless of
+// what starts executions. Schedule is the cron expression of expected starts, MaxInterval is the longest
+// time between runs, the earliest of them is due. Grace is how late the run may be before the job is overdue.
+// Successful executions are runs, running ones are runs until the job with them would be overdue,
+// failed and lost ones aren't.
+type Cadence struct {
+	Schedule    string   `json:"schedule,omitempty"`
+	MaxInterval Duration `json:"maxInterval,omitempty"`
+	Grace       Duration `json:"grace,omitempty"`
+}
+
+// Overdue is set in listed jobs which have missed their expected run, it isn't stored.
+type Overdue struct {
+	// DueAt is when the run was expected, the job is overdue after the grace since it.
+	DueAt time.Time `json:"dueAt"`
+	// LastRunAt is the start of the latest run, it's nil if the job hasn't run since its creation.
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+}
+
+func (c *Cadence) Validate() error {
+	if c.Schedule == "" && c.MaxInterval == 0 {
+		return fmt.Errorf("%w: cadence: schedule or maxInterval is required", ErrInvalidSchedule)
+	}
+	if c.MaxInterval < 0 || c.Grace < 0 {
+		return fmt.Errorf("%w: cadence: maxInterval and grace must not be negative", ErrInvalidSchedule)
+	}
+	if c.Schedule != "" {
+		if _, err := ParseSchedule(c.Schedule); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Due returns when the next run is expected after the run at the time, it's zero if it's never expected.
+// The run within clockSkewTolerance before the schedule's tick is the run of the tick.
+func (c *Cadence) Due(lastRunAt time.Time) (time.Time, error) {
+	var due time.Time
+	if c.Schedule != "" {
+		schedule, err := ParseSchedule(c.Schedule)
+		if err != nil {
+			return time.Time{}, err
+		}
+		due = schedule.Next(lastRunAt.Add(clockSkewTolerance))
+	}
+	if c.MaxInterval > 0 {
+		if byInterval := lastRunAt.Add(time.Duration(c.MaxInterval)); due.IsZero() || byInterval.Before(due) {
+			due = byInterval
+		}
+	}
+
+	return due, nil
+}
+
+// isRun reports whether the execution is a run at now. The running execution is a run within the cadence
+// since its start, so the hung one doesn't hide missed runs.
+func (c *Cadence) isRun(execution *Execution, now time.Time) (bool, error) {
+	switch execution.Status {
+	case StatusSuccessed:
+		return true, nil
+	case StatusRunning:
+		due, err := c.Due(execution.StartedAt)
+		if err != nil {
+			return false, err
+		}
+
+		return due.IsZero() || !now.After(due.Add(time.Duration(c.Grace))), nil
+	default:
+		return false, nil
+	}
+}
+
+// CheckOverdue returns the overdue of the job with the executions at now, it's nil if the job has no cadence,
+// is paused or isn't late. The job's creation is the last run if it hasn't run yet.
+func (j *Job) CheckOverdue(executions []Execution, now time.Time) (*Overdue, error) {
+	if j.Cadence == nil || j.Status == JobStatusPaused {
+		return nil, nil
+	}
+
+	var lastRunAt *time.Time
+	for i := range executions {
+		execution := &executions[i]
+		if lastRunAt != nil && !execution.StartedAt.After(*lastRunAt) {
+			continue
+		}
+		isRun, err := j.Cadence.isRun(execution, now)
+		if err != nil {
+			return nil, fmt.Errorf("check overdue of %s: %w", j.Name, err)
+		}
+		if isRun {
+			lastRunAt = &execution.StartedAt
+		}
+	}
+	since := j.CreatedAt
+	if lastRunAt != nil {
+		since = *lastRunAt
+	}
+
+	due, err := j.Cadence.Due(since)
+	if err != nil {
+		return nil, fmt.Errorf("check overdue of %s: %w", j.Name, err)
+	}
+	if due.IsZero() || !now.After(due.Add(time.Duration(j.Cadence.Grace))) {
+		return nil, nil
+	}
+
+	return &Overdue{DueAt: due, LastRunAt: lastRunAt}, nil
+}
+
+// Watchdog periodically flags jobs which have missed their expected runs.
+type Watchdog struct {
+	jobStorage       Storage
+	executionStorage ExecutionStorage
+	// onChange is called when the job becomes overdue and with nil overdue when it runs again.
+	onChange func(jobName string, overdue *Overdue)
+
+	mu      sync.RWMutex
+	overdue map[string]Overdue
+}
+
+func NewWatchdog(jobStorage Storage, executionStorage ExecutionStorage,
+	onChange func(jobName string, overdue *Overdue),
+) *Watchdog {
+	return &Watchdog{
+		jobStorage:       jobStorage,
+		executionStorage: executionStorage,
+		onChange:         onChange,
+		overdue:          make(map[string]Overdue),
+	}
+}
+
+// Overdue returns the overdue of the job found by the latest check, it's nil if the job isn't overdue.
+func (w *Watchdog) Overdue(jobName string) *Overdue {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	overdue, ok := w.overdue[jobName]
+	if !ok {
+		return nil
+	}
+
+	return &overdue
+}
+
+// Check finds overdue jobs at now. Jobs with invalid cadences are skipped, deleted jobs are forgotten silently.
+func (w *Watchdog) Check(now time.Time) error {
+	jobs, err := w.jobStorage.GetAll()
+	if err != nil {
+		return fmt.Errorf("check overdue: %w", err)
+	}
+
+	found := make(map[string]Overdue)
+	existing := make(map[string]bool, len(jobs))
+	for i := range jobs {
+		jb := &jobs[i]
+		existing[jb.Name] = true
+		if jb.Cadence == nil {
+			continue
+		}
+		executions, err := w.executionStorage.GetByJobName(jb.Name)
+		if err != nil {
+			return fmt.Errorf("check overdue of %s: %w", jb.Name, err)
+		}
+		overdue, err := jb.CheckOverdue(executions, now)
+		if err != nil {
+			glog.Errorf("watchdog: %v", err)
+
+			continue
+		}
+		if overdue != nil {
+			found[jb.Name] = *overdue
+		}
+	}
+
+	w.mu.Lock()
+	previous := w.overdue
+	w.overdue = found
+	w.mu.Unlock()
+	metrics.OverdueJobs.Set(int64(len(found)))
+
+	for name, overdue := range found {
+		if _, ok := previous[name]; ok {
+			continue
+		}
+		overdue := overdue
+		metrics.OverdueDetections.Add(1)
+		glog.Warningf("job %s is overdue, the run was due at %s", name, overdue.DueAt.Format(time.RFC3339))
+		w.onChange(name, &overdue)
+	}
+	for name := range previous {
+		if _, ok := found[name]; ok || !existing[name] {
+			continue
+		}
+		glog.Infof("job %s isn't overdue anymore", name)
+		w.onChange(name, nil)
+	}
+
+	return nil
+}
+
+// Run checks jobs at start and every interval until ctx is done.
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Check(time.Now()); err != nil {
+			glog.Errorf("watchdog: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package job_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/job/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCadenceValidate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name    string
+		cadence job.Cadence
+		valid   bool
+	}{
+		{name: "schedule", cadence: job.Cadence{Schedule: "@daily", Grace: job.Duration(time.Hour)}, valid: true},
+		{name: "max interval", cadence: job.Cadence{MaxInterval: job.Duration(time.Hour)}, valid: true},
+		{name: "empty", cadence: job.Cadence{Grace: job.Duration(time.Hour)}},
+		{name: "invalid schedule", cadence: job.Cadence{Schedule: "daily"}},
+		{name: "negative interval", cadence: job.Cadence{MaxInterval: job.Duration(-time.Hour)}},
+		{name: "negative grace", cadence: job.Cadence{Schedule: "@daily", Grace: job.Duration(-time.Hour)}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			err := testCase.cadence.Validate()
+			if testCase.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckOverdue(t *testing.T) {
+	t.Parallel()
+	createdAt := time.Date(2022, 1, 10, 0, 30, 0, 0, time.UTC)
+	execution := func(startedAt time.Time, status job.ExecutionStatus) job.Execution {
+		exec := job.NewRunningExecution(TestJobName)
+		exec.SetStartedAt(startedAt)
+		if status != job.StatusRunning {
+			exec.Finish(status, startedAt.Add(time.Minute), "")
+		}
+
+		return *exec
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2022, 1, 10, hour, minute, 0, 0, time.UTC)
+	}
+	hourly := &job.Cadence{Schedule: "0 * * * *", Grace: job.Duration(10 * time.Minute)}
+
+	testCases := []struct {
+		name       string
+		cadence    *job.Cadence
+		paused     bool
+		executions []job.Execution
+		now        time.Time
+		dueAt      *time.Time
+		lastRunAt  *time.Time
+	}{
+		{name: "no cadence", now: at(12, 0)},
+		{name: "never run within grace", cadence: hourly, now: at(1, 10)},
+		{name: "never run", cadence: hourly, now: at(1, 11), dueAt: timePtr(at(1, 0))},
+		{name: "paused", cadence: hourly, paused: true, now: at(12, 0)},
+		{
+			name:    "failed runs don't count",
+			cadence: hourly,
+			executions: []job.Execution{
+				execution(at(2, 0), job.StatusSuccessed),
+				execution(at(3, 0), job.StatusFailed),
+				execution(at(4, 0), job.StatusLost),
+			},
+			now:       at(4, 30),
+			dueAt:     timePtr(at(3, 0)),
+			lastRunAt: timePtr(at(2, 0)),
+		},
+		{
+			name:       "running",
+			cadence:    hourly,
+			executions: []job.Execution{execution(at(2, 0), job.StatusSuccessed), execution(at(3, 1), job.StatusRunning)},
+			now:        at(4, 5),
+		},
+		{
+			name:       "running longer than the cadence",
+			cadence:    hourly,
+			executions: []job.Execution{execution(at(2, 0), job.StatusSuccessed), execution(at(2, 30), job.StatusRunning)},
+			now:        at(3, 45),
+			dueAt:      timePtr(at(3, 0)),
+			lastRunAt:  timePtr(at(2, 0)),
+		},
+		{
+			name:       "only running longer than the cadence",
+			cadence:    hourly,
+			executions: []job.Execution{execution(at(1, 30), job.StatusRunning)},
+			now:        at(2, 11),
+			dueAt:      timePtr(at(1, 0)),
+		},
+		{
+			name:       "run reported before the tick",
+			cadence:    hourly,
+			executions: []job.Execution{execution(at(2, 0).Add(-time.Second), job.StatusSuccessed)},
+			now:        at(2, 30),
+		},
+		{
+			name:       "run long before the tick",
+			cadence:    hourly,
+			executions: []job.Execution{execution(at(1, 59), job.StatusSuccessed)},
+			now:        at(2, 11),
+			dueAt:      timePtr(at(2, 0)),
+			lastRunAt:  timePtr(at(1, 59)),
+		},
+		{
+			name:       "max interval is earlier",
+			cadence:    &job.Cadence{Schedule: "@daily", MaxInterval: job.Duration(2 * time.Hour)},
+			executions: []job.Execution{execution(at(2, 0), job.StatusSuccessed)},
+			now:        at(4, 1),
+			dueAt:      timePtr(at(4, 0)),
+			lastRunAt:  timePtr(at(2, 0)),
+		},
+		{
+			name:       "schedule is earlier",
+			cadence:    &job.Cadence{Schedule: "0 3 * * *", MaxInterval: job.Duration(24 * time.Hour)},
+			executions: []job.Execution{execution(at(2, 0), job.StatusSuccessed)},
+			now:        at(3, 1),
+			dueAt:      timePtr(at(3, 0)),
+			lastRunAt:  timePtr(at(2, 0)),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			jb := job.NewJob(TestJobName)
+			jb.CreatedAt = createdAt
+			jb.Cadence = testCase.cadence
+			if testCase.paused {
+				jb.Pause()
+			}
+
+			overdue, err := jb.CheckOverdue(testCase.executions, testCase.now)
+			require.NoError(t, err)
+			if testCase.dueAt == nil {
+				assert.Nil(t, overdue)
+
+				return
+			}
+			require.NotNil(t, overdue)
+			assert.Equal(t, &job.Overdue{DueAt: *testCase.dueAt, LastRunAt: testCase.lastRunAt}, overdue)
+		})
+	}
+}
+
+func TestWatchdogCheck(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	watchedJob := job.NewJob("watched")
+	watchedJob.CreatedAt = now.Add(-2 * time.Hour)
+	watchedJob.Cadence = &job.Cadence{MaxInterval: job.Duration(time.Hour)}
+	unwatchedJob := job.NewJob("unwatched")
+	run := job.NewRunningExecution("watched")
+
+	jobStorage := new(mocks.Storage)
+	executionStorage := new(mocks.ExecutionStorage)
+	// Overdue twice, runs, overdue again and deleted.
+	jobStorage.On("GetAll").Return([]job.Job{*watchedJob, *unwatchedJob}, nil).Times(4)
+	jobStorage.On("GetAll").Return([]job.Job{}, nil).Once()
+	executionStorage.On("GetByJobName", "watched").Return([]job.Execution{}, nil).Twice()
+	executionStorage.On("GetByJobName", "watched").Return([]job.Execution{*run}, nil).Once()
+	executionStorage.On("GetByJobName", "watched").Return([]job.Execution{}, nil).Once()
+
+	var changes []string
+	watchdog := job.NewWatchdog(jobStorage, executionStorage, func(jobName string, overdue *job.Overdue) {
+		changes = append(changes, fmt.Sprintf("%s overdue: %t", jobName, overdue != nil))
+	})
+
+	require.NoError(t, watchdog.Check(now))
+	require.NoError(t, watchdog.Check(now))
+	require.NotNil(t, watchdog.Overdue("watched"))
+	assert.Equal(t, now.Add(-time.Hour), watchdog.Overdue("watched").DueAt)
+	assert.Nil(t, watchdog.Overdue("unwatched"))
+
+	require.NoError(t, watchdog.Check(now))
+	assert.Nil(t, watchdog.Overdue("watched"))
+	require.NoError(t, watchdog.Check(now))
+	require.NoError(t, watchdog.Check(now))
+	assert.Nil(t, watchdog.Overdue("watched"))
+
+	assert.Equal(t, []string{"watched overdue: true", "watched overdue: false", "watched overdue: true"}, changes)
+	jobStorage.AssertExpectations(t)
+	executionStorage.AssertExpectations(t)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -1,21 +1,30 @@
 package job
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var ErrExecutionNotRunning = errors.New("execution is not running")
+
 //go:generate mockery --case underscore --name ControllerI
 type ControllerI interface {
 	Start(j *Job, args StartArguments) (*Execution, error)
-	Finish(id uuid.UUID) error
+	Finish(id uuid.UUID, args FinishArguments) error
+	RequestStop(id uuid.UUID, now time.Time) (*Execution, error)
 }
 
 type Controller struct {
 	executionStorage ExecutionStorage
 	locker           *Locker
+	resources        *Resources
+	// mu makes lock check and store of the started execution atomic, finish and stop request
+	// don't overwrite each other.
+	mu sync.Mutex
 }
 
 func NewController(executionStorage ExecutionStorage) *Controller {
@@ -25,14 +34,29 @@ func NewController(executionStorage ExecutionStorage) *Controller {
 	}
 }
 
+// SetResources enables shared resources required by jobs, they aren't checked without it.
+func (e *Controller) SetResources(resources *Resources) {
+	e.resources = resources
+}
+
 type StartArguments struct {
 	Command   *string
 	Pid       *int
 	Host      *string
 	StartedAt *time.Time
+	Params    map[string]string
+	// Labels of the host are matched with the job's host selector and keep the lock in `label` lock mode.
+	Labels map[string]string
 }
 
 func (e *Controller) Start(lJob *Job, args StartArguments) (*Execution, error) {
+	if err := lJob.HostSelector.Match(args.Labels); err != nil {
+		return nil, fmt.Errorf("controller start: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	executions, err := e.executionStorage.GetByJobName(lJob.Name)
 	if err != nil {
 		return nil, fmt.Errorf("controller start: %w", err)
@@ -41,10 +65,17 @@ func (e *Controller) Start(lJob *Job, args StartArguments) (*Execution, error) {
 		Pid:       args.Pid,
 		Host:      args.Host,
 		StartedAt: args.StartedAt,
+		Labels:    args.Labels,
 	}, executions)
 	if err != nil {
 		return nil, fmt.Errorf("controller start: %w", err)
 	}
+	resources := sortedResources(lJob.Resources)
+	if len(resources) > 0 && e.resources != nil {
+		if holderID, err := e.resources.acquire(resources); err != nil {
+			return nil, fmt.Errorf("controller start: resource holder %s: %w", holderID, err)
+		}
+	}
 
 	if args.StartedAt == nil {
 		t := time.Now()
@@ -63,6 +94,15 @@ func (e *Controller) Start(lJob *Job, args StartArguments) (*Execution, error) {
 	}
 	exec.SetStartedAt(*args.StartedAt)
 	exec.SetID(executionID)
+	if len(args.Params) > 0 {
+		exec.Params = args.Params
+	}
+	if len(args.Labels) > 0 {
+		exec.Labels = args.Labels
+	}
+	if len(resources) > 0 {
+		exec.Resources = resources
+	}
 	if err := e.executionStorage.Store(&exec); err != nil {
 		return nil, fmt.Errorf("controller start: %w", err)
 	}
@@ -70,14 +110,75 @@ func (e *Controller) Start(lJob *Job, args StartArguments) (*Execution, error) {
 	return &exec, nil
 }
 
-func (e *Controller) Finish(id uuid.UUID) error {
+// FinishArguments are reported by the process runner, nil exit code means success.
+// Status overrides the status given by the exit code, it's set when the execution is finished manually.
+type FinishArguments struct {
+	ExitCode *int
+	Msg      *string
+	Usage    *Usage
+	Status   *ExecutionStatus
+}
+
+// Finish marks the execution as finished, it's kept in the history until pruned. The lost execution
+// may be finished by its returning executor, ErrExecutionNotRunning is returned if it has been finished.
+func (e *Controller) Finish(id uuid.UUID, args FinishArguments) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	execution, err := e.executionStorage.GetByID(id)
 	if err != nil {
 		return fmt.Errorf("finish: %w", err)
 	}
-	if err := e.executionStorage.Delete(execution.ID); err != nil {
+	if execution.Status != StatusRunning && execution.Status != StatusLost {
+		return fmt.Errorf("finish: %w", ErrExecutionNotRunning)
+	}
+
+	status := StatusSuccessed
+	if args.ExitCode != nil {
+		execution.SetExitCode(*args.ExitCode)
+		if *args.ExitCode != 0 {
+			status = StatusFailed
+		}
+	}
+	if args.Status != nil {
+		status = *args.Status
+	}
+	if args.Usage != nil {
+		execution.SetUsage(*args.Usage)
+	}
+	msg := ""
+	if args.Msg != nil {
+		msg = *args.Msg
+	}
+	execution.Finish(status, time.Now(), msg)
+	if err := e.executionStorage.Store(execution); err != nil {
 		return fmt.Errorf("finish: %w", err)
 	}
 
 	return nil
 }
+
+// RequestStop marks the running execution to be stopped by its executor, the repeated request keeps
+// the first time. ErrExecutionNotRunning is returned if the execution has been finished.
+func (e *Controller) RequestStop(id uuid.UUID, now time.Time) (*Execution, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	execution, err := e.executionStorage.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("request stop: %w", err)
+	}
+	if execution.Status != StatusRunning {
+		return execution, fmt.Errorf("request stop: %w", ErrExecutionNotRunning)
+	}
+	if execution.StopRequestedAt != nil {
+		return execution, nil
+	}
+
+	execution.RequestStop(now)
+	if err := e.executionStorage.Store(execution); err != nil {
+		return nil, fmt.Errorf("request stop: %w", err)
+	}
+
+	return execution, nil
+}
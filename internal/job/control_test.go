@@ -1,11 +1,14 @@
 package job_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/antgubarev/jobs/internal"
 	"github.com/antgubarev/jobs/internal/job"
 	"github.com/antgubarev/jobs/internal/job/mocks"
+	"github.com/antgubarev/jobs/internal/memory"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -52,10 +55,113 @@ func TestFinish(t *testing.T) {
 
 		return exec
 	}, nil)
-	executionStorage.On("Delete", mock.MatchedBy(func(passedExecutionID uuid.UUID) bool {
-		return passedExecutionID == executionID
+	executionStorage.On("Store", mock.MatchedBy(func(execution *job.Execution) bool {
+		return execution.ID == executionID &&
+			execution.Status == job.StatusSuccessed &&
+			execution.FinishedAt != nil
 	})).Return(nil)
 	controller := job.NewController(executionStorage)
-	err := controller.Finish(executionID)
+	err := controller.Finish(executionID, job.FinishArguments{})
+	assert.NoError(t, err)
+}
+
+func TestFinishWithExitCode(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		exitCode int
+		status   job.ExecutionStatus
+	}{
+		{name: "success", exitCode: 0, status: job.StatusSuccessed},
+		{name: "failed", exitCode: 3, status: job.StatusFailed},
+		{name: "signalled", exitCode: 137, status: job.StatusFailed},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			executionStorage := new(mocks.ExecutionStorage)
+			exec := job.NewRunningExecution(TestJobName)
+			executionStorage.On("GetByID", exec.ID).Return(exec, nil)
+			executionStorage.On("Store", mock.MatchedBy(func(execution *job.Execution) bool {
+				return execution.Status == testCase.status &&
+					execution.ExitCode != nil &&
+					*execution.ExitCode == testCase.exitCode
+			})).Return(nil).Once()
+
+			controller := job.NewController(executionStorage)
+			err := controller.Finish(exec.ID, job.FinishArguments{ExitCode: &testCase.exitCode})
+			assert.NoError(t, err)
+			executionStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFinishNotRunning(t *testing.T) {
+	t.Parallel()
+	executionStorage := memory.NewExecutionStorage()
+	finished := job.NewRunningExecution(TestJobName)
+	assert.NoError(t, executionStorage.Store(finished))
+	lost := job.NewRunningExecution(TestJobName)
+	lost.Finish(job.StatusLost, time.Now(), "host is unhealthy")
+	assert.NoError(t, executionStorage.Store(lost))
+	controller := job.NewController(executionStorage)
+
+	exitCode := 3
+	assert.NoError(t, controller.Finish(finished.ID, job.FinishArguments{}))
+	err := controller.Finish(finished.ID, job.FinishArguments{ExitCode: &exitCode})
+	assert.True(t, errors.Is(err, job.ErrExecutionNotRunning), "unexpected error: %v", err)
+	stored, err := executionStorage.GetByID(finished.ID)
 	assert.NoError(t, err)
+	assert.Equal(t, job.StatusSuccessed, stored.Status)
+	assert.Nil(t, stored.ExitCode)
+
+	// The returning executor reports the result of the lost execution.
+	assert.NoError(t, controller.Finish(lost.ID, job.FinishArguments{ExitCode: &exitCode}))
+	stored, err = executionStorage.GetByID(lost.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, job.StatusFailed, stored.Status)
+}
+
+func TestStartHostMismatch(t *testing.T) {
+	t.Parallel()
+	controller := job.NewController(new(mocks.ExecutionStorage))
+	_, err := controller.Start(&job.Job{
+		Name:         "job",
+		LockMode:     job.FreeLockMode,
+		HostSelector: job.HostSelector{"zone": {"eu"}},
+	}, job.StartArguments{
+		Command: internal.NewPointerOfString("command"),
+		Host:    internal.NewPointerOfString("host"),
+		Labels:  map[string]string{"zone": "us"},
+	})
+	assert.ErrorIs(t, err, job.ErrHostMismatch)
+}
+
+func TestRequestStop(t *testing.T) {
+	t.Parallel()
+	executionStorage := memory.NewExecutionStorage()
+	running := job.NewRunningExecution(TestJobName)
+	assert.NoError(t, executionStorage.Store(running))
+	controller := job.NewController(executionStorage)
+
+	requestedAt := time.Now()
+	stopped, err := controller.RequestStop(running.ID, requestedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, requestedAt, *stopped.StopRequestedAt)
+	stopped, err = controller.RequestStop(running.ID, requestedAt.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, requestedAt, *stopped.StopRequestedAt)
+
+	// The stop requested after the finish doesn't overwrite it.
+	assert.NoError(t, controller.Finish(running.ID, job.FinishArguments{}))
+	_, err = controller.RequestStop(running.ID, time.Now())
+	assert.True(t, errors.Is(err, job.ErrExecutionNotRunning), "unexpected error: %v", err)
+	finished, err := executionStorage.GetByID(running.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, job.StatusSuccessed, finished.Status)
+
+	_, err = controller.RequestStop(uuid.New(), time.Now())
+	assert.True(t, errors.Is(err, job.ErrExecutionNotFound), "unexpected error: %v", err)
 }
@@ -0,0 +1,29 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// scheduleParser parses standard cron expressions `minute hour day-of-month month day-of-week`
+// and macros like `@daily` or `@every 1h`.
+var scheduleParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ParseSchedule parses the cron expression of the cadence. Times are in UTC unless the expression is prefixed
+// by `CRON_TZ=<zone> `. Next of the schedule is zero if there is no such time within years, e.g. `0 0 30 2 *`.
+func ParseSchedule(expr string) (cron.Schedule, error) {
+	if !strings.HasPrefix(expr, "CRON_TZ=") && !strings.HasPrefix(expr, "TZ=") {
+		expr = "CRON_TZ=UTC " + expr
+	}
+	schedule, err := scheduleParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	return schedule, nil
+}
@@ -0,0 +1,70 @@
+package job_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleNext(t *testing.T) {
+	t.Parallel()
+	// Monday.
+	after := time.Date(2022, 1, 10, 12, 30, 15, 0, time.UTC)
+	testCases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{expr: "* * * * *", expected: time.Date(2022, 1, 10, 12, 31, 0, 0, time.UTC)},
+		{expr: "@hourly", expected: time.Date(2022, 1, 10, 13, 0, 0, 0, time.UTC)},
+		{expr: "30 12 * * *", expected: time.Date(2022, 1, 11, 12, 30, 0, 0, time.UTC)},
+		{expr: "*/20 * * * *", expected: time.Date(2022, 1, 10, 12, 40, 0, 0, time.UTC)},
+		{expr: "5/20 9-17 * * *", expected: time.Date(2022, 1, 10, 12, 45, 0, 0, time.UTC)},
+		{expr: "0 3,15 * * *", expected: time.Date(2022, 1, 10, 15, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * sat,SUN", expected: time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{expr: "@every 90m", expected: time.Date(2022, 1, 10, 14, 0, 15, 0, time.UTC)},
+		{expr: "0 0 1 mar-may *", expected: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches if both are restricted.
+		{expr: "0 0 20 * fri", expected: time.Date(2022, 1, 14, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", expected: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", expected: time.Time{}},
+		{expr: "CRON_TZ=UTC 0 0 * * *", expected: time.Date(2022, 1, 11, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.expr, func(t *testing.T) {
+			t.Parallel()
+			schedule, err := job.ParseSchedule(testCase.expr)
+			require.NoError(t, err)
+			next := schedule.Next(after)
+			assert.True(t, testCase.expected.Equal(next), "expected %s, got %s", testCase.expected, next)
+		})
+	}
+}
+
+func TestScheduleNextInLocation(t *testing.T) {
+	t.Parallel()
+	schedule, err := job.ParseSchedule("TZ=Local 0 3 * * *")
+	require.NoError(t, err)
+	next := schedule.Next(time.Date(2022, 1, 10, 12, 0, 0, 0, time.Local))
+	assert.Equal(t, 3, next.In(time.Local).Hour())
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	t.Parallel()
+	for _, expr := range []string{
+		"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 7",
+		"*/0 * * * *", "5-1 * * * *", "a * * * *", "* * * foo *", "@every", "CRON_TZ=Nowhere/City * * * * *",
+	} {
+		expr := expr
+		t.Run(expr, func(t *testing.T) {
+			t.Parallel()
+			_, err := job.ParseSchedule(expr)
+			assert.True(t, errors.Is(err, job.ErrInvalidSchedule), "unexpected error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package job
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ExecutionFilter selects executions, empty fields match everything. Since and Until are compared
+// with the start time of executions.
+type ExecutionFilter struct {
+	Job    string
+	Host   string
+	Status ExecutionStatus
+	Since  *time.Time
+	Until  *time.Time
+	Limit  int
+}
+
+func (f *ExecutionFilter) Match(execution *Execution) bool {
+	if f.Job != "" && f.Job != execution.Job {
+		return false
+	}
+	if f.Host != "" && (execution.Host == nil || f.Host != *execution.Host) {
+		return false
+	}
+	if f.Status != "" && f.Status != execution.Status {
+		return false
+	}
+	if f.Since != nil && execution.StartedAt.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && execution.StartedAt.After(*f.Until) {
+		return false
+	}
+
+	return true
+}
+
+// FindExecutions returns executions matched by the filter, newest first. Executions of the filter's job
+// are found even if the job is deleted, otherwise executions of existing jobs are scanned.
+func FindExecutions(jobStorage Storage, executionStorage ExecutionStorage, filter ExecutionFilter) ([]Execution, error) {
+	names := []string{filter.Job}
+	if filter.Job == "" {
+		jobs, err := jobStorage.GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("find executions: %w", err)
+		}
+		names = names[:0]
+		for _, jb := range jobs {
+			names = append(names, jb.Name)
+		}
+	}
+
+	found := make([]Execution, 0)
+	for _, name := range names {
+		executions, err := executionStorage.GetByJobName(name)
+		if err != nil {
+			return nil, fmt.Errorf("find executions: %w", err)
+		}
+		for i := range executions {
+			if filter.Match(&executions[i]) {
+				found = append(found, executions[i])
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].StartedAt.After(found[j].StartedAt)
+	})
+	if filter.Limit > 0 && len(found) > filter.Limit {
+		found = found[:filter.Limit]
+	}
+
+	return found, nil
+}
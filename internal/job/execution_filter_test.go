@@ -0,0 +1,79 @@
+package job_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindExecutions(t *testing.T) {
+	t.Parallel()
+	jobStorage, executionStorage := memory.NewJobStorage(), memory.NewExecutionStorage()
+	now := time.Now()
+	require.NoError(t, jobStorage.Store(job.NewJob("backup")))
+	require.NoError(t, jobStorage.Store(job.NewJob("report")))
+
+	ids := make(map[string]uuid.UUID)
+	for _, exec := range []struct {
+		name   string
+		job    string
+		host   string
+		status job.ExecutionStatus
+		ago    time.Duration
+	}{
+		{name: "backup-old", job: "backup", host: "host-1", status: job.StatusSuccessed, ago: 3 * time.Hour},
+		{name: "backup-new", job: "backup", host: "host-2", status: job.StatusFailed, ago: time.Hour},
+		{name: "report", job: "report", host: "host-1", status: job.StatusRunning, ago: 2 * time.Hour},
+		// Executions of deleted jobs are found only by the job filter.
+		{name: "deleted", job: "deleted", host: "host-1", status: job.StatusSuccessed, ago: time.Minute},
+	} {
+		execution := job.NewRunningExecution(exec.job)
+		execution.SetHost(exec.host)
+		execution.SetStartedAt(now.Add(-exec.ago))
+		execution.Status = exec.status
+		require.NoError(t, executionStorage.Store(execution))
+		ids[exec.name] = execution.ID
+	}
+
+	since := now.Add(-150 * time.Minute)
+	until := now.Add(-90 * time.Minute)
+	testCases := []struct {
+		name     string
+		filter   job.ExecutionFilter
+		expected []string
+	}{
+		{name: "all", expected: []string{"backup-new", "report", "backup-old"}},
+		{name: "job", filter: job.ExecutionFilter{Job: "backup"}, expected: []string{"backup-new", "backup-old"}},
+		{name: "deleted job", filter: job.ExecutionFilter{Job: "deleted"}, expected: []string{"deleted"}},
+		{name: "host", filter: job.ExecutionFilter{Host: "host-1"}, expected: []string{"report", "backup-old"}},
+		{name: "status", filter: job.ExecutionFilter{Status: job.StatusFailed}, expected: []string{"backup-new"}},
+		{name: "since", filter: job.ExecutionFilter{Since: &since}, expected: []string{"backup-new", "report"}},
+		{name: "until", filter: job.ExecutionFilter{Until: &until}, expected: []string{"report", "backup-old"}},
+		{name: "limit", filter: job.ExecutionFilter{Limit: 1}, expected: []string{"backup-new"}},
+		{name: "nothing", filter: job.ExecutionFilter{Host: "host-3"}, expected: []string{}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			executions, err := job.FindExecutions(jobStorage, executionStorage, testCase.filter)
+			require.NoError(t, err)
+
+			found := make([]uuid.UUID, 0, len(executions))
+			for _, execution := range executions {
+				found = append(found, execution.ID)
+			}
+			expected := make([]uuid.UUID, 0, len(testCase.expected))
+			for _, name := range testCase.expected {
+				expected = append(expected, ids[name])
+			}
+			assert.Equal(t, expected, found)
+		})
+	}
+}
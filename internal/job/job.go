@@ -14,10 +14,27 @@ const (
 )
 
 type Job struct {
-	Name      string    `json:"name"`
-	LockMode  LockMode  `json:"lockMode"`
-	Status    Status    `json:"status"`
-	CreatedAt time.Time `json:"createdAt"`
+	Name     string   `json:"name"`
+	LockMode LockMode `json:"lockMode"`
+	// LockLabel is the name of the host label which value is locked in `label` lock mode.
+	LockLabel string           `json:"lockLabel,omitempty"`
+	Status    Status           `json:"status"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Retention *RetentionPolicy `json:"retention,omitempty"`
+	Sandbox   *Sandbox         `json:"sandbox,omitempty"`
+	// Env is passed to the command's environment, Params are resolved at start of each execution.
+	Env    map[string]string `json:"env,omitempty"`
+	Params []Param           `json:"params,omitempty"`
+	// HostSelector restricts hosts where the job may run by labels of the host.
+	HostSelector HostSelector `json:"hostSelector,omitempty"`
+	// Resources are names of shared resources held by each execution of the job.
+	Resources []string `json:"resources,omitempty"`
+	// Labels of the job, e.g. `team=data`, jobs are selected by them to pause or resume many at once.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Cadence is the expected frequency of runs, the job isn't watched for missed runs without it.
+	Cadence *Cadence `json:"cadence,omitempty"`
+	// Overdue is set by the server in the jobs list if the job has missed its expected run.
+	Overdue *Overdue `json:"overdue,omitempty"`
 }
 
 func NewJob(name string) *Job {
@@ -43,6 +60,8 @@ const (
 	StatusRunning   ExecutionStatus = "running"
 	StatusSuccessed ExecutionStatus = "successed"
 	StatusFailed    ExecutionStatus = "failed"
+	// StatusLost is set to running executions of the host which has missed heartbeats.
+	StatusLost ExecutionStatus = "lost"
 )
 
 func NewRunningExecution(job string) *Execution {
@@ -69,6 +88,16 @@ type Execution struct {
 	FinishedAt *time.Time      `json:"finishedAt"`
 	Status     ExecutionStatus `json:"status"`
 	Msg        *string         `json:"msg"`
+	ExitCode   *int            `json:"exitCode,omitempty"`
+	Usage      *Usage          `json:"usage,omitempty"`
+	// Params are resolved values of the job's params.
+	Params map[string]string `json:"params,omitempty"`
+	// Labels of the host at start.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Resources held by the execution while it's running.
+	Resources []string `json:"resources,omitempty"`
+	// StopRequestedAt is set by the stop request, the executor of the execution polls it and stops the command.
+	StopRequestedAt *time.Time `json:"stopRequestedAt,omitempty"`
 }
 
 func (e *Execution) SetID(id uuid.UUID) {
@@ -91,6 +120,18 @@ func (e *Execution) SetStartedAt(at time.Time) {
 	e.StartedAt = at
 }
 
+func (e *Execution) SetExitCode(code int) {
+	e.ExitCode = &code
+}
+
+func (e *Execution) SetUsage(usage Usage) {
+	e.Usage = &usage
+}
+
+func (e *Execution) RequestStop(at time.Time) {
+	e.StopRequestedAt = &at
+}
+
 func (e *Execution) Finish(status ExecutionStatus, timeAt time.Time, msg string) {
 	e.Status = status
 	e.FinishedAt = &timeAt
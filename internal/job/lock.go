@@ -3,6 +3,7 @@ package job
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,9 +15,16 @@ const (
 	FreeLockMode    LockMode = "free"
 	HostLockMode    LockMode = "host"
 	ClusterLockMode LockMode = "cluster"
+	// LabelLockMode allows one execution per value of the job's lock label of the host,
+	// e.g. once per zone with `lockLabel: zone`.
+	LabelLockMode LockMode = "label"
 )
 
-var errValidationLockArguments = errors.New("lock argument is invalid")
+var (
+	errValidationLockArguments = errors.New("lock argument is invalid")
+	ErrInvalidLockLabel        = errors.New("invalid lock label")
+	ErrLockLabelRequired       = errors.New("lock label of the host is required")
+)
 
 type Locker struct{}
 
@@ -28,6 +36,8 @@ type LockArguments struct {
 	Pid       *int
 	Host      *string
 	StartedAt *time.Time
+	// Labels of the host, the value of the job's lock label is the lock key in `label` mode.
+	Labels map[string]string
 }
 
 func (l *Locker) Lock(lJob *Job, args LockArguments, executions []Execution) (uuid.UUID, error) {
@@ -37,6 +47,10 @@ func (l *Locker) Lock(lJob *Job, args LockArguments, executions []Execution) (uu
 	if err := l.validateHostPidForLockMode(args.Host, lJob.LockMode); err != nil {
 		return uuid.Nil, err
 	}
+	lockValue, hasLockValue := args.Labels[lJob.LockLabel]
+	if lJob.LockMode == LabelLockMode && !hasLockValue {
+		return uuid.Nil, fmt.Errorf("%w: `%s`", ErrLockLabelRequired, lJob.LockLabel)
+	}
 	for _, exec := range executions {
 		if exec.Status != StatusRunning {
 			continue
@@ -47,6 +61,9 @@ func (l *Locker) Lock(lJob *Job, args LockArguments, executions []Execution) (uu
 		if lJob.LockMode == HostLockMode && *exec.Host == *args.Host {
 			return exec.ID, new(LockedError)
 		}
+		if value, ok := exec.Labels[lJob.LockLabel]; lJob.LockMode == LabelLockMode && ok && value == lockValue {
+			return exec.ID, new(LockedError)
+		}
 	}
 
 	if args.StartedAt == nil {
@@ -64,3 +81,48 @@ func (l *Locker) validateHostPidForLockMode(host *string, mode LockMode) error {
 
 	return nil
 }
+
+// validateLock checks the lock label is set only for `label` mode.
+func (j *Job) validateLock() error {
+	if j.LockMode != LabelLockMode {
+		if j.LockLabel != "" {
+			return fmt.Errorf("%w: lock label is allowed only for `label` lock mode", ErrInvalidLockLabel)
+		}
+
+		return nil
+	}
+	if !labelNameRe.MatchString(j.LockLabel) {
+		return fmt.Errorf("%w: invalid label name `%s`", ErrInvalidLockLabel, j.LockLabel)
+	}
+
+	return nil
+}
+
+// LockScope returns the key of executions which exclude each other: the job for `cluster` mode,
+// the job on the host for `host` mode, the job in the label value for `label` mode,
+// required resources for `free` mode. Empty scope means executions never wait for each other.
+func LockScope(lJob *Job, host *string, labels map[string]string) string {
+	switch lJob.LockMode {
+	case ClusterLockMode:
+		return lJob.Name
+	case HostLockMode:
+		if host == nil {
+			return ""
+		}
+
+		return lJob.Name + "@" + *host
+	case LabelLockMode:
+		value, ok := labels[lJob.LockLabel]
+		if !ok {
+			return ""
+		}
+
+		return lJob.Name + "@" + lJob.LockLabel + "=" + value
+	default:
+		if len(lJob.Resources) == 0 {
+			return ""
+		}
+
+		return "resources:" + strings.Join(sortedResources(lJob.Resources), ",")
+	}
+}
@@ -1,6 +1,7 @@
 package job_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/antgubarev/jobs/internal"
@@ -98,6 +99,69 @@ func TestLock(t *testing.T) {
 			},
 			err: new(job.LockedError),
 		},
+		{
+			name: "Once at label mode and exec in another zone",
+			jb: job.Job{
+				Name:      "job1",
+				LockMode:  job.LabelLockMode,
+				LockLabel: "zone",
+			},
+			lockArgs: job.LockArguments{
+				Pid:    internal.NewPointerOfInt(1),
+				Host:   internal.NewPointerOfString("host1"),
+				Labels: map[string]string{"zone": "eu"},
+			},
+			executions: []func() *job.Execution{
+				func() *job.Execution {
+					exec := job.NewRunningExecution("job1")
+					exec.SetPid(2)
+					exec.SetHost("host2")
+					exec.Labels = map[string]string{"zone": "us"}
+
+					return exec
+				},
+			},
+			err: nil,
+		},
+		{
+			name: "Once at label mode and exec in same zone",
+			jb: job.Job{
+				Name:      "job1",
+				LockMode:  job.LabelLockMode,
+				LockLabel: "zone",
+			},
+			lockArgs: job.LockArguments{
+				Pid:    internal.NewPointerOfInt(1),
+				Host:   internal.NewPointerOfString("host1"),
+				Labels: map[string]string{"zone": "eu"},
+			},
+			executions: []func() *job.Execution{
+				func() *job.Execution {
+					exec := job.NewRunningExecution("job1")
+					exec.SetPid(2)
+					exec.SetHost("host2")
+					exec.Labels = map[string]string{"zone": "eu"}
+
+					return exec
+				},
+			},
+			err: new(job.LockedError),
+		},
+		{
+			name: "Once at label mode and exec without zone",
+			jb: job.Job{
+				Name:      "job1",
+				LockMode:  job.LabelLockMode,
+				LockLabel: "zone",
+			},
+			lockArgs: job.LockArguments{
+				Pid:    internal.NewPointerOfInt(1),
+				Host:   internal.NewPointerOfString("host1"),
+				Labels: map[string]string{"rack": "r1"},
+			},
+			executions: []func() *job.Execution{},
+			err:        job.ErrLockLabelRequired,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -110,10 +174,75 @@ func TestLock(t *testing.T) {
 				executions = append(executions, *execFunc())
 			}
 			_, err := locker.Lock(&testCase.jb, testCase.lockArgs, executions)
-			if testCase.err == nil {
+			var lockedErr *job.LockedError
+			switch {
+			case testCase.err == nil:
+				assert.NoError(t, err)
+			case errors.As(testCase.err, &lockedErr):
+				assert.ErrorAs(t, err, &lockedErr)
+			default:
+				assert.ErrorIs(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestLockScope(t *testing.T) {
+	t.Parallel()
+	host := internal.NewPointerOfString("host1")
+	labels := map[string]string{"zone": "eu"}
+
+	testCases := []struct {
+		name     string
+		jb       job.Job
+		expected string
+	}{
+		{name: "free", jb: job.Job{Name: "job1", LockMode: job.FreeLockMode}, expected: ""},
+		{name: "cluster", jb: job.Job{Name: "job1", LockMode: job.ClusterLockMode}, expected: "job1"},
+		{name: "host", jb: job.Job{Name: "job1", LockMode: job.HostLockMode}, expected: "job1@host1"},
+		{name: "label", jb: job.Job{Name: "job1", LockMode: job.LabelLockMode, LockLabel: "zone"}, expected: "job1@zone=eu"},
+		{name: "label missing", jb: job.Job{Name: "job1", LockMode: job.LabelLockMode, LockLabel: "rack"}, expected: ""},
+		{
+			name: "resources", jb: job.Job{Name: "job1", LockMode: job.FreeLockMode, Resources: []string{"gpu", "db", "gpu"}},
+			expected: "resources:db,gpu",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, job.LockScope(&testCase.jb, host, labels))
+		})
+	}
+}
+
+func TestValidateLockLabel(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		lockMode  job.LockMode
+		lockLabel string
+		valid     bool
+	}{
+		{name: "label mode", lockMode: job.LabelLockMode, lockLabel: "zone", valid: true},
+		{name: "host mode", lockMode: job.HostLockMode, valid: true},
+		{name: "label mode without label", lockMode: job.LabelLockMode},
+		{name: "invalid label", lockMode: job.LabelLockMode, lockLabel: "zone=eu"},
+		{name: "label with cluster mode", lockMode: job.ClusterLockMode, lockLabel: "zone"},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			testJob := job.NewJob("job1")
+			testJob.LockMode, testJob.LockLabel = testCase.lockMode, testCase.lockLabel
+			err := testJob.ValidateConfig()
+			if testCase.valid {
 				assert.NoError(t, err)
 			} else {
-				assert.ErrorIs(t, err, new(job.LockedError))
+				assert.ErrorIs(t, err, job.ErrInvalidLockLabel)
 			}
 		})
 	}
@@ -0,0 +1,36 @@
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/metrics"
+)
+
+// LoseHostExecutions marks running executions of the host as lost, the host has missed its heartbeats.
+// Lost executions don't hold locks anymore, their finish reported later overrides the status.
+func (e *Controller) LoseHostExecutions(host string, now time.Time) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	executions, err := e.executionStorage.GetRunning()
+	if err != nil {
+		return 0, fmt.Errorf("lose executions of %s: %w", host, err)
+	}
+
+	lost := 0
+	for i := range executions {
+		exec := &executions[i]
+		if exec.Status != StatusRunning || exec.Host == nil || *exec.Host != host {
+			continue
+		}
+		exec.Finish(StatusLost, now, "host "+host+" is unhealthy")
+		if err := e.executionStorage.Store(exec); err != nil {
+			return lost, fmt.Errorf("lose executions of %s: %w", host, err)
+		}
+		lost++
+		metrics.LostExecutions.Add(1)
+	}
+
+	return lost, nil
+}
@@ -0,0 +1,31 @@
+package job_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/job/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoseHostExecutions(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	onHost := job.NewRunningExecution(TestJobName)
+	onHost.SetHost("node1")
+	onOtherHost := job.NewRunningExecution(TestJobName)
+	onOtherHost.SetHost("node2")
+
+	executionStorage := new(mocks.ExecutionStorage)
+	executionStorage.On("GetRunning").Return([]job.Execution{*onHost, *onOtherHost}, nil).Once()
+	executionStorage.On("Store", mock.MatchedBy(func(exec *job.Execution) bool {
+		return exec.ID == onHost.ID && exec.Status == job.StatusLost && exec.FinishedAt.Equal(now)
+	})).Return(nil).Once()
+
+	lost, err := job.NewController(executionStorage).LoseHostExecutions("node1", now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lost)
+	executionStorage.AssertExpectations(t)
+}
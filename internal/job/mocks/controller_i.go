@@ -6,6 +6,8 @@ import (
 	job "github.com/antgubarev/jobs/internal/job"
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -14,13 +16,13 @@ type ControllerI struct {
 	mock.Mock
 }
 
-// Finish provides a mock function with given fields: id
-func (_m *ControllerI) Finish(id uuid.UUID) error {
-	ret := _m.Called(id)
+// Finish provides a mock function with given fields: id, args
+func (_m *ControllerI) Finish(id uuid.UUID, args job.FinishArguments) error {
+	ret := _m.Called(id, args)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, job.FinishArguments) error); ok {
+		r0 = rf(id, args)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -28,6 +30,29 @@ func (_m *ControllerI) Finish(id uuid.UUID) error {
 	return r0
 }
 
+// RequestStop provides a mock function with given fields: id, now
+func (_m *ControllerI) RequestStop(id uuid.UUID, now time.Time) (*job.Execution, error) {
+	ret := _m.Called(id, now)
+
+	var r0 *job.Execution
+	if rf, ok := ret.Get(0).(func(uuid.UUID, time.Time) *job.Execution); ok {
+		r0 = rf(id, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*job.Execution)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uuid.UUID, time.Time) error); ok {
+		r1 = rf(id, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields: j, args
 func (_m *ControllerI) Start(j *job.Job, args job.StartArguments) (*job.Execution, error) {
 	ret := _m.Called(j, args)
@@ -88,6 +88,29 @@ func (_m *ExecutionStorage) GetByJobName(jobName string) ([]job.Execution, error
 	return r0, r1
 }
 
+// GetRunning provides a mock function with given fields:
+func (_m *ExecutionStorage) GetRunning() ([]job.Execution, error) {
+	ret := _m.Called()
+
+	var r0 []job.Execution
+	if rf, ok := ret.Get(0).(func() []job.Execution); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]job.Execution)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Store provides a mock function with given fields: execution
 func (_m *ExecutionStorage) Store(execution *job.Execution) error {
 	ret := _m.Called(execution)
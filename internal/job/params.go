@@ -0,0 +1,151 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidParams = errors.New("invalid params")
+	ErrInvalidJob    = errors.New("invalid job")
+)
+
+// Environment variables set by the executor for the command.
+const (
+	EnvExecutionID = "JOBS_EXECUTION_ID"
+	EnvJobName     = "JOBS_JOB_NAME"
+	EnvServerURL   = "JOBS_SERVER_URL"
+	// EnvParamPrefix is the prefix of parameters, `batch-size` is passed as JOBS_PARAM_BATCH_SIZE.
+	EnvParamPrefix = "JOBS_PARAM_"
+)
+
+var (
+	paramNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+	envNameRe   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Param is the named parameter of the job, it's required if it has no default value.
+type Param struct {
+	Name        string  `json:"name"`
+	Default     *string `json:"default,omitempty"`
+	Description string  `json:"description,omitempty"`
+}
+
+// Validate checks the name, the lock mode, the status and the sandbox of the job with ValidateConfig.
+func (j *Job) Validate() error {
+	if j.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidJob)
+	}
+	switch j.LockMode {
+	case FreeLockMode, HostLockMode, ClusterLockMode, LabelLockMode:
+	default:
+		return fmt.Errorf("%w: unknown lock mode `%s` of %s", ErrInvalidJob, j.LockMode, j.Name)
+	}
+	switch j.Status {
+	case JobStatusActive, JobStatusPaused:
+	default:
+		return fmt.Errorf("%w: unknown status `%s` of %s", ErrInvalidJob, j.Status, j.Name)
+	}
+	if j.Sandbox != nil {
+		if err := j.Sandbox.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return j.ValidateConfig()
+}
+
+// ValidateConfig checks env names, params, labels, the lock label, resources, the cadence and the host selector
+// of the job.
+func (j *Job) ValidateConfig() error {
+	if err := j.HostSelector.Validate(); err != nil {
+		return err
+	}
+	if err := j.validateLabels(); err != nil {
+		return err
+	}
+	if err := j.validateLock(); err != nil {
+		return err
+	}
+	if err := j.validateResources(); err != nil {
+		return err
+	}
+	if j.Cadence != nil {
+		if err := j.Cadence.Validate(); err != nil {
+			return err
+		}
+	}
+	if j.Retention != nil {
+		if err := j.Retention.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for name := range j.Env {
+		if !envNameRe.MatchString(name) {
+			return fmt.Errorf("%w: invalid env name `%s`", ErrInvalidParams, name)
+		}
+	}
+
+	names := make(map[string]bool, len(j.Params))
+	for _, param := range j.Params {
+		if !paramNameRe.MatchString(param.Name) {
+			return fmt.Errorf("%w: invalid param name `%s`", ErrInvalidParams, param.Name)
+		}
+		// `a-b` and `a_b` would be passed in the same variable.
+		envName := ParamEnvName(param.Name)
+		if names[envName] {
+			return fmt.Errorf("%w: duplicated param `%s`", ErrInvalidParams, param.Name)
+		}
+		names[envName] = true
+	}
+
+	return nil
+}
+
+// ResolveParams returns values of all params of the job: overrides or defaults.
+// Unknown overrides and missing required params are errors.
+func (j *Job) ResolveParams(overrides map[string]string) (map[string]string, error) {
+	known := make(map[string]bool, len(j.Params))
+	resolved := make(map[string]string, len(j.Params))
+	var missing []string
+	for _, param := range j.Params {
+		known[param.Name] = true
+		if value, ok := overrides[param.Name]; ok {
+			resolved[param.Name] = value
+
+			continue
+		}
+		if param.Default == nil {
+			missing = append(missing, param.Name)
+
+			continue
+		}
+		resolved[param.Name] = *param.Default
+	}
+
+	var unknown []string
+	for name := range overrides {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+
+		return nil, fmt.Errorf("%w: unknown params: %s", ErrInvalidParams, strings.Join(unknown, ", "))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: required params: %s", ErrInvalidParams, strings.Join(missing, ", "))
+	}
+
+	return resolved, nil
+}
+
+// ParamEnvName returns the name of the environment variable of the param.
+func ParamEnvName(name string) string {
+	return EnvParamPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
@@ -0,0 +1,88 @@
+package job_test
+
+import (
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveParams(t *testing.T) {
+	t.Parallel()
+	size := "10"
+	testJob := job.NewJob(TestJobName)
+	testJob.Params = []job.Param{{Name: "batch-size", Default: &size}, {Name: "date"}}
+
+	testCases := []struct {
+		name      string
+		overrides map[string]string
+		expected  map[string]string
+		err       bool
+	}{
+		{
+			name:      "defaults",
+			overrides: map[string]string{"date": "2022-01-01"},
+			expected:  map[string]string{"batch-size": "10", "date": "2022-01-01"},
+		},
+		{
+			name:      "override",
+			overrides: map[string]string{"date": "2022-01-01", "batch-size": "20"},
+			expected:  map[string]string{"batch-size": "20", "date": "2022-01-01"},
+		},
+		{name: "required", err: true},
+		{name: "unknown", overrides: map[string]string{"date": "2022-01-01", "other": "1"}, err: true},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			resolved, err := testJob.ResolveParams(testCase.overrides)
+			if testCase.err {
+				assert.ErrorIs(t, err, job.ErrInvalidParams)
+
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, resolved)
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name   string
+		env    map[string]string
+		params []job.Param
+		valid  bool
+	}{
+		{name: "empty", valid: true},
+		{
+			name:   "valid",
+			env:    map[string]string{"APP_MODE": "prod"},
+			params: []job.Param{{Name: "batch-size"}, {Name: "date"}},
+			valid:  true,
+		},
+		{name: "env name", env: map[string]string{"APP-MODE": "prod"}},
+		{name: "param name", params: []job.Param{{Name: "1st"}}},
+		{name: "same variable", params: []job.Param{{Name: "batch-size"}, {Name: "batch_size"}}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			testJob := job.NewJob(TestJobName)
+			testJob.Env, testJob.Params = testCase.env, testCase.params
+			err := testJob.ValidateConfig()
+			if testCase.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, job.ErrInvalidParams)
+			}
+		})
+	}
+
+	assert.Equal(t, "JOBS_PARAM_BATCH_SIZE", job.ParamEnvName("batch-size"))
+}
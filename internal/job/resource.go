@@ -0,0 +1,165 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidResource = errors.New("invalid resource")
+
+// DefaultResourceCapacity is the capacity of resources which aren't configured, they are mutexes.
+const DefaultResourceCapacity = 1
+
+// Resource is the named semaphore shared by different jobs. Running executions of jobs which require
+// the resource hold it, no more than Capacity executions hold it at once.
+type Resource struct {
+	Name     string           `json:"name"`
+	Capacity int              `json:"capacity"`
+	Holders  []ResourceHolder `json:"holders"`
+}
+
+type ResourceHolder struct {
+	Execution uuid.UUID `json:"execution"`
+	Job       string    `json:"job"`
+	Host      *string   `json:"host"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Resources finds holders of resources by running executions, so resources are released by finished
+// and lost executions and the holders survive restarts of the server.
+type Resources struct {
+	jobStorage       Storage
+	executionStorage ExecutionStorage
+	capacities       map[string]int
+}
+
+// NewResources creates resources with capacities by name, resources which aren't in capacities are mutexes.
+func NewResources(jobStorage Storage, executionStorage ExecutionStorage, capacities map[string]int) *Resources {
+	return &Resources{
+		jobStorage:       jobStorage,
+		executionStorage: executionStorage,
+		capacities:       capacities,
+	}
+}
+
+func (r *Resources) Capacity(name string) int {
+	if capacity, ok := r.capacities[name]; ok {
+		return capacity
+	}
+
+	return DefaultResourceCapacity
+}
+
+// List returns configured resources, resources required by jobs and held ones with their holders, sorted by name.
+func (r *Resources) List() ([]Resource, error) {
+	holders, err := r.holders()
+	if err != nil {
+		return nil, fmt.Errorf("list resources: %w", err)
+	}
+	jobs, err := r.jobStorage.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("list resources: %w", err)
+	}
+
+	names := make(map[string]bool, len(r.capacities)+len(holders))
+	for name := range r.capacities {
+		names[name] = true
+	}
+	for name := range holders {
+		names[name] = true
+	}
+	for _, jb := range jobs {
+		for _, name := range jb.Resources {
+			names[name] = true
+		}
+	}
+
+	resources := make([]Resource, 0, len(names))
+	for name := range names {
+		resourceHolders := holders[name]
+		if resourceHolders == nil {
+			resourceHolders = []ResourceHolder{}
+		}
+		resources = append(resources, Resource{Name: name, Capacity: r.Capacity(name), Holders: resourceHolders})
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Name < resources[j].Name
+	})
+
+	return resources, nil
+}
+
+// acquire checks that all resources have free capacity, the holder of the first busy resource is returned
+// with LockedError. Resources are taken all or nothing in the name order under the lock of the controller,
+// a start never holds a part of them while it waits for others, so starts can't deadlock.
+func (r *Resources) acquire(names []string) (uuid.UUID, error) {
+	holders, err := r.holders()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("acquire resources: %w", err)
+	}
+	for _, name := range names {
+		if len(holders[name]) >= r.Capacity(name) {
+			return holders[name][0].Execution, new(LockedError)
+		}
+	}
+
+	return uuid.Nil, nil
+}
+
+// holders returns running executions by held resources. Only running executions are read,
+// the job may require other resources than its running executions hold.
+func (r *Resources) holders() (map[string][]ResourceHolder, error) {
+	executions, err := r.executionStorage.GetRunning()
+	if err != nil {
+		return nil, fmt.Errorf("resource holders: %w", err)
+	}
+
+	holders := make(map[string][]ResourceHolder)
+	for _, exec := range executions {
+		for _, name := range exec.Resources {
+			holders[name] = append(holders[name], ResourceHolder{
+				Execution: exec.ID,
+				Job:       exec.Job,
+				Host:      exec.Host,
+				StartedAt: exec.StartedAt,
+			})
+		}
+	}
+	for _, resourceHolders := range holders {
+		sort.Slice(resourceHolders, func(i, j int) bool {
+			return resourceHolders[i].StartedAt.Before(resourceHolders[j].StartedAt)
+		})
+	}
+
+	return holders, nil
+}
+
+// sortedResources returns unique resource names in the acquisition order.
+func sortedResources(names []string) []string {
+	sorted := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// validateResources checks names of resources required by the job.
+func (j *Job) validateResources() error {
+	for _, name := range j.Resources {
+		if !labelNameRe.MatchString(name) {
+			return fmt.Errorf("%w: invalid name `%s`", ErrInvalidResource, name)
+		}
+	}
+
+	return nil
+}
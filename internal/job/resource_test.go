@@ -0,0 +1,82 @@
+package job_test
+
+import (
+	"testing"
+
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResourceJob(t *testing.T, jobStorage job.Storage, name string, resources ...string) *job.Job {
+	t.Helper()
+	jb := job.NewJob(name)
+	jb.LockMode = job.FreeLockMode
+	jb.Resources = resources
+	require.NoError(t, jobStorage.Store(jb))
+
+	return jb
+}
+
+func TestControllerResources(t *testing.T) {
+	t.Parallel()
+	jobStorage, executionStorage := memory.NewJobStorage(), memory.NewExecutionStorage()
+	controller := job.NewController(executionStorage)
+	controller.SetResources(job.NewResources(jobStorage, executionStorage, map[string]int{"gpu": 2}))
+
+	backup := newResourceJob(t, jobStorage, "db-backup", "db")
+	vacuum := newResourceJob(t, jobStorage, "db-vacuum", "db")
+	train := newResourceJob(t, jobStorage, "train", "gpu", "gpu")
+	report := newResourceJob(t, jobStorage, "report", "gpu", "db")
+	args := job.StartArguments{Host: internal.NewPointerOfString("host")}
+
+	backupExec, err := controller.Start(backup, args)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db"}, backupExec.Resources)
+
+	var lockedErr *job.LockedError
+	_, err = controller.Start(vacuum, args)
+	assert.ErrorAs(t, err, &lockedErr, "the other job holds db")
+
+	for i := 0; i < 2; i++ {
+		trainExec, err := controller.Start(train, args)
+		require.NoError(t, err, "gpu has capacity 2")
+		assert.Equal(t, []string{"gpu"}, trainExec.Resources)
+	}
+
+	require.NoError(t, controller.Finish(backupExec.ID, job.FinishArguments{}))
+	_, err = controller.Start(report, args)
+	assert.ErrorAs(t, err, &lockedErr, "gpu is busy")
+
+	// report hasn't taken db while gpu was busy.
+	_, err = controller.Start(vacuum, args)
+	assert.NoError(t, err)
+}
+
+func TestResourcesList(t *testing.T) {
+	t.Parallel()
+	jobStorage, executionStorage := memory.NewJobStorage(), memory.NewExecutionStorage()
+	resources := job.NewResources(jobStorage, executionStorage, map[string]int{"gpu": 4})
+	controller := job.NewController(executionStorage)
+	controller.SetResources(resources)
+
+	backup := newResourceJob(t, jobStorage, "db-backup", "db")
+	newResourceJob(t, jobStorage, "db-vacuum", "db", "disk")
+	execution, err := controller.Start(backup, job.StartArguments{Host: internal.NewPointerOfString("host")})
+	require.NoError(t, err)
+
+	list, err := resources.List()
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, "db", list[0].Name)
+	assert.Equal(t, 1, list[0].Capacity)
+	require.Len(t, list[0].Holders, 1)
+	assert.Equal(t, execution.ID, list[0].Holders[0].Execution)
+	assert.Equal(t, "db-backup", list[0].Holders[0].Job)
+	assert.Equal(t, "disk", list[1].Name)
+	assert.Empty(t, list[1].Holders)
+	assert.Equal(t, "gpu", list[2].Name)
+	assert.Equal(t, 4, list[2].Capacity)
+}
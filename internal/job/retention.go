@@ -0,0 +1,215 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/metrics"
+	"github.com/golang/glog"
+)
+
+// Duration is a time.Duration which is represented as a string (`72h`, `30m`) in json.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("parse duration: %w", err)
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("unmarshal duration: %w", err)
+	}
+
+	return d.UnmarshalText([]byte(text))
+}
+
+var ErrInvalidRetention = errors.New("invalid retention")
+
+// RetentionPolicy describes which finished executions are kept in the history.
+// Zero values mean no limit. Failed executions are kept for KeepFailedFor if it's set
+// regardless of other limits and don't count in KeepLast.
+type RetentionPolicy struct {
+	KeepLast      int      `json:"keepLast"`
+	KeepFor       Duration `json:"keepFor"`
+	KeepFailedFor Duration `json:"keepFailedFor"`
+}
+
+func (rp *RetentionPolicy) IsEmpty() bool {
+	return rp.KeepLast == 0 && rp.KeepFor == 0 && rp.KeepFailedFor == 0
+}
+
+func (rp *RetentionPolicy) Validate() error {
+	if rp.KeepLast < 0 || rp.KeepFor < 0 || rp.KeepFailedFor < 0 {
+		return fmt.Errorf("%w: keepLast, keepFor and keepFailedFor must not be negative", ErrInvalidRetention)
+	}
+
+	return nil
+}
+
+// Expired returns finished executions which have to be removed according to the policy.
+func (rp *RetentionPolicy) Expired(executions []Execution, now time.Time) []Execution {
+	finished := make([]Execution, 0, len(executions))
+	for _, exec := range executions {
+		if exec.Status != StatusRunning && exec.FinishedAt != nil {
+			finished = append(finished, exec)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.After(*finished[j].FinishedAt)
+	})
+
+	var expired []Execution
+	kept := 0
+	for _, exec := range finished {
+		age := now.Sub(*exec.FinishedAt)
+		if exec.Status == StatusFailed && rp.KeepFailedFor > 0 {
+			if age > time.Duration(rp.KeepFailedFor) {
+				expired = append(expired, exec)
+			}
+
+			continue
+		}
+		if rp.KeepLast > 0 && kept >= rp.KeepLast {
+			expired = append(expired, exec)
+
+			continue
+		}
+		if rp.KeepFor > 0 && age > time.Duration(rp.KeepFor) {
+			expired = append(expired, exec)
+
+			continue
+		}
+		kept++
+	}
+
+	return expired
+}
+
+type Pruner struct {
+	jobStorage       Storage
+	executionStorage ExecutionStorage
+	rejections       RejectionStorage
+	policy           RetentionPolicy
+}
+
+// NewPruner creates a pruner with the global policy, job's own policy overrides it.
+func NewPruner(jobStorage Storage, executionStorage ExecutionStorage, policy RetentionPolicy) *Pruner {
+	return &Pruner{
+		jobStorage:       jobStorage,
+		executionStorage: executionStorage,
+		policy:           policy,
+	}
+}
+
+// SetRejections enables pruning of lock rejections, they are kept for the largest default stats window
+// or KeepFor of the global policy if it's longer.
+func (p *Pruner) SetRejections(rejections RejectionStorage) {
+	p.rejections = rejections
+}
+
+func (p *Pruner) Prune(now time.Time) (int, error) {
+	jobs, err := p.jobStorage.GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("prune: %w", err)
+	}
+
+	pruned := 0
+	for _, jb := range jobs {
+		policy := p.policy
+		if jb.Retention != nil {
+			policy = *jb.Retention
+		}
+		if policy.IsEmpty() {
+			continue
+		}
+
+		executions, err := p.executionStorage.GetByJobName(jb.Name)
+		if err != nil {
+			return pruned, fmt.Errorf("prune %s: %w", jb.Name, err)
+		}
+		for _, exec := range policy.Expired(executions, now) {
+			if err := p.executionStorage.Delete(exec.ID); err != nil {
+				return pruned, fmt.Errorf("prune %s: %w", jb.Name, err)
+			}
+			pruned++
+		}
+	}
+
+	return pruned, nil
+}
+
+// PruneRejections deletes lock rejections which aren't counted in stats anymore.
+func (p *Pruner) PruneRejections(now time.Time) (int, error) {
+	if p.rejections == nil {
+		return 0, nil
+	}
+
+	keepFor := time.Duration(p.policy.KeepFor)
+	for _, window := range DefaultStatsWindows {
+		if window > keepFor {
+			keepFor = window
+		}
+	}
+	pruned, err := p.rejections.DeleteBefore(now.Add(-keepFor))
+	if err != nil {
+		return pruned, fmt.Errorf("prune rejections: %w", err)
+	}
+
+	return pruned, nil
+}
+
+// Run prunes the history at start and every interval until ctx is done.
+func (p *Pruner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.run(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Pruner) run(now time.Time) {
+	pruned, err := p.Prune(now)
+	metrics.PruneRuns.Add(1)
+	metrics.PrunedExecutions.Add(int64(pruned))
+	if err != nil {
+		metrics.PruneErrors.Add(1)
+		glog.Errorf("prune history: %v", err)
+	} else {
+		glog.Infof("prune history: %d executions removed", pruned)
+	}
+
+	pruned, err = p.PruneRejections(now)
+	metrics.PrunedRejections.Add(int64(pruned))
+	if err != nil {
+		metrics.PruneErrors.Add(1)
+		glog.Errorf("prune history: %v", err)
+
+		return
+	}
+	glog.Infof("prune history: %d lock rejections removed", pruned)
+}
@@ -0,0 +1,172 @@
+package job_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/job/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newFinishedExecution(status job.ExecutionStatus, finishedAt time.Time) job.Execution {
+	exec := job.NewRunningExecution(TestJobName)
+	exec.Finish(status, finishedAt, "")
+
+	return *exec
+}
+
+func TestRetentionPolicyExpired(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	testCases := []struct {
+		name       string
+		policy     job.RetentionPolicy
+		executions []job.Execution
+		expired    []int
+	}{
+		{
+			name:   "empty policy",
+			policy: job.RetentionPolicy{},
+			executions: []job.Execution{
+				newFinishedExecution(job.StatusSuccessed, now.Add(-time.Hour)),
+			},
+			expired: []int{},
+		},
+		{
+			name:   "keep last",
+			policy: job.RetentionPolicy{KeepLast: 1},
+			executions: []job.Execution{
+				newFinishedExecution(job.StatusSuccessed, now.Add(-2*time.Hour)),
+				newFinishedExecution(job.StatusSuccessed, now.Add(-time.Hour)),
+				*job.NewRunningExecution(TestJobName),
+			},
+			expired: []int{0},
+		},
+		{
+			name:   "keep for",
+			policy: job.RetentionPolicy{KeepFor: job.Duration(90 * time.Minute)},
+			executions: []job.Execution{
+				newFinishedExecution(job.StatusSuccessed, now.Add(-2*time.Hour)),
+				newFinishedExecution(job.StatusSuccessed, now.Add(-time.Hour)),
+			},
+			expired: []int{0},
+		},
+		{
+			name: "keep failures longer",
+			policy: job.RetentionPolicy{
+				KeepLast:      1,
+				KeepFor:       job.Duration(time.Hour),
+				KeepFailedFor: job.Duration(24 * time.Hour),
+			},
+			executions: []job.Execution{
+				newFinishedExecution(job.StatusFailed, now.Add(-48*time.Hour)),
+				newFinishedExecution(job.StatusFailed, now.Add(-3*time.Hour)),
+				newFinishedExecution(job.StatusSuccessed, now.Add(-2*time.Hour)),
+				newFinishedExecution(job.StatusSuccessed, now.Add(-time.Minute)),
+			},
+			expired: []int{0, 2},
+		},
+		{
+			name:   "kept failures don't count in keep last",
+			policy: job.RetentionPolicy{KeepLast: 1, KeepFailedFor: job.Duration(24 * time.Hour)},
+			executions: []job.Execution{
+				newFinishedExecution(job.StatusSuccessed, now.Add(-3*time.Hour)),
+				newFinishedExecution(job.StatusSuccessed, now.Add(-2*time.Hour)),
+				newFinishedExecution(job.StatusFailed, now.Add(-time.Hour)),
+			},
+			expired: []int{0},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			expectedIDs := make([]uuid.UUID, 0, len(testCase.expired))
+			for _, i := range testCase.expired {
+				expectedIDs = append(expectedIDs, testCase.executions[i].ID)
+			}
+			actualIDs := make([]uuid.UUID, 0)
+			for _, exec := range testCase.policy.Expired(testCase.executions, now) {
+				actualIDs = append(actualIDs, exec.ID)
+			}
+			assert.ElementsMatch(t, expectedIDs, actualIDs)
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	old := newFinishedExecution(job.StatusSuccessed, now.Add(-2*time.Hour))
+	fresh := newFinishedExecution(job.StatusSuccessed, now.Add(-time.Minute))
+
+	ownPolicyJob := job.NewJob("job2")
+	ownPolicyJob.Retention = &job.RetentionPolicy{KeepLast: 10}
+
+	jobStorage := new(mocks.Storage)
+	jobStorage.On("GetAll").Return([]job.Job{*job.NewJob(TestJobName), *ownPolicyJob}, nil)
+	executionStorage := new(mocks.ExecutionStorage)
+	executionStorage.On("GetByJobName", TestJobName).Return([]job.Execution{old, fresh}, nil)
+	executionStorage.On("GetByJobName", "job2").Return([]job.Execution{old, fresh}, nil)
+	executionStorage.On("Delete", mock.MatchedBy(func(id uuid.UUID) bool {
+		return id == old.ID
+	})).Return(nil).Once()
+
+	pruner := job.NewPruner(jobStorage, executionStorage, job.RetentionPolicy{KeepFor: job.Duration(time.Hour)})
+	pruned, err := pruner.Prune(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	executionStorage.AssertExpectations(t)
+}
+
+func TestPruneRejections(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	testCases := []struct {
+		name    string
+		keepFor time.Duration
+		before  time.Time
+	}{
+		{name: "largest stats window", keepFor: time.Hour, before: now.Add(-30 * 24 * time.Hour)},
+		{name: "longer keepFor", keepFor: 60 * 24 * time.Hour, before: now.Add(-60 * 24 * time.Hour)},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			rejections := new(mocks.RejectionStorage)
+			rejections.On("DeleteBefore", testCase.before).Return(2, nil).Once()
+
+			pruner := job.NewPruner(new(mocks.Storage), new(mocks.ExecutionStorage),
+				job.RetentionPolicy{KeepFor: job.Duration(testCase.keepFor)})
+			pruned, err := pruner.PruneRejections(now)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, pruned)
+
+			pruner.SetRejections(rejections)
+			pruned, err = pruner.PruneRejections(now)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, pruned)
+			rejections.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	t.Parallel()
+	for _, policy := range []job.RetentionPolicy{
+		{KeepLast: -1},
+		{KeepFor: job.Duration(-time.Hour)},
+		{KeepFailedFor: job.Duration(-time.Hour)},
+	} {
+		err := policy.Validate()
+		assert.True(t, errors.Is(err, job.ErrInvalidRetention), "unexpected error: %v", err)
+	}
+	assert.NoError(t, (&job.RetentionPolicy{KeepLast: 1, KeepFor: job.Duration(time.Hour)}).Validate())
+}
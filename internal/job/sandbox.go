@@ -0,0 +1,116 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidSandbox = errors.New("invalid sandbox")
+
+// IONice classes, see ioprio_set(2).
+const (
+	IONiceRealtime   = "realtime"
+	IONiceBestEffort = "best-effort"
+	IONiceIdle       = "idle"
+)
+
+const (
+	minNice        = -20
+	maxNice        = 19
+	maxIONiceLevel = 7
+)
+
+// Sandbox describes how the executor launches the job's command. The executor applies it before exec,
+// zero values mean the executor's own settings are inherited.
+type Sandbox struct {
+	Limits *Limits       `json:"limits,omitempty"`
+	Cgroup *CgroupLimits `json:"cgroup,omitempty"`
+	Nice   *int          `json:"nice,omitempty"`
+	IONice *IONice       `json:"ionice,omitempty"`
+	// WorkDir is the working directory of the command.
+	WorkDir string `json:"workDir,omitempty"`
+	// User and Group are names or numeric ids to run the command as, the executor has to be privileged.
+	User  string `json:"user,omitempty"`
+	Group string `json:"group,omitempty"`
+	// EnvAllow lists environment variables passed to the command, `PREFIX_*` allows all with the prefix.
+	// Empty list passes the whole environment.
+	EnvAllow []string `json:"envAllow,omitempty"`
+}
+
+// Limits are rlimits of the command, soft and hard limits are set to the same value.
+type Limits struct {
+	CPUSeconds   *uint64 `json:"cpuSeconds,omitempty"`
+	AddressSpace *uint64 `json:"addressSpace,omitempty"`
+	OpenFiles    *uint64 `json:"openFiles,omitempty"`
+}
+
+// CgroupLimits are applied with cgroup v2 if it's available. MemoryMax is in bytes,
+// CPUs is the number of CPUs the command may use, e.g. 0.5.
+type CgroupLimits struct {
+	MemoryMax int64   `json:"memoryMax,omitempty"`
+	CPUs      float64 `json:"cpus,omitempty"`
+}
+
+type IONice struct {
+	Class string `json:"class"`
+	Level int    `json:"level,omitempty"`
+}
+
+// NeedsShim returns true if the sandbox can't be applied by setting the command's working directory
+// and environment only, the rest is applied by the executor's shim process right before exec.
+func (s *Sandbox) NeedsShim() bool {
+	return s != nil && (s.Limits != nil || s.Cgroup != nil || s.Nice != nil || s.IONice != nil ||
+		s.User != "" || s.Group != "")
+}
+
+func (s *Sandbox) Validate() error {
+	if s.Nice != nil && (*s.Nice < minNice || *s.Nice > maxNice) {
+		return fmt.Errorf("%w: nice must be in [%d, %d]", ErrInvalidSandbox, minNice, maxNice)
+	}
+	if s.IONice != nil {
+		switch s.IONice.Class {
+		case IONiceRealtime, IONiceBestEffort, IONiceIdle:
+		default:
+			return fmt.Errorf("%w: ionice class must be one of %s, %s, %s",
+				ErrInvalidSandbox, IONiceRealtime, IONiceBestEffort, IONiceIdle)
+		}
+		if s.IONice.Level < 0 || s.IONice.Level > maxIONiceLevel {
+			return fmt.Errorf("%w: ionice level must be in [0, %d]", ErrInvalidSandbox, maxIONiceLevel)
+		}
+	}
+	if s.Cgroup != nil && (s.Cgroup.MemoryMax < 0 || s.Cgroup.CPUs < 0) {
+		return fmt.Errorf("%w: cgroup limits must be positive", ErrInvalidSandbox)
+	}
+	for _, name := range s.EnvAllow {
+		if name == "" || strings.Contains(name, "=") || strings.Contains(strings.TrimSuffix(name, "*"), "*") {
+			return fmt.Errorf("%w: invalid env allow-list entry `%s`", ErrInvalidSandbox, name)
+		}
+	}
+
+	return nil
+}
+
+// FilterEnv returns the variables of environ allowed by EnvAllow.
+func (s *Sandbox) FilterEnv(environ []string) []string {
+	if s == nil || len(s.EnvAllow) == 0 {
+		return environ
+	}
+
+	filtered := make([]string, 0, len(s.EnvAllow))
+	for _, variable := range environ {
+		name := variable
+		if i := strings.IndexByte(variable, '='); i >= 0 {
+			name = variable[:i]
+		}
+		for _, allowed := range s.EnvAllow {
+			if name == allowed || strings.HasSuffix(allowed, "*") && strings.HasPrefix(name, allowed[:len(allowed)-1]) {
+				filtered = append(filtered, variable)
+
+				break
+			}
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,61 @@
+package job_test
+
+import (
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandboxValidate(t *testing.T) {
+	t.Parallel()
+	nice, wrongNice := 10, 20
+	testCases := []struct {
+		name    string
+		sandbox job.Sandbox
+		valid   bool
+	}{
+		{name: "empty", valid: true},
+		{
+			name: "full",
+			sandbox: job.Sandbox{
+				Nice:     &nice,
+				IONice:   &job.IONice{Class: job.IONiceIdle},
+				Cgroup:   &job.CgroupLimits{MemoryMax: 1 << 30, CPUs: 0.5},
+				User:     "nobody",
+				EnvAllow: []string{"PATH", "APP_*"},
+			},
+			valid: true,
+		},
+		{name: "nice", sandbox: job.Sandbox{Nice: &wrongNice}},
+		{name: "ionice class", sandbox: job.Sandbox{IONice: &job.IONice{Class: "fast"}}},
+		{name: "ionice level", sandbox: job.Sandbox{IONice: &job.IONice{Class: job.IONiceBestEffort, Level: 8}}},
+		{name: "cgroup", sandbox: job.Sandbox{Cgroup: &job.CgroupLimits{MemoryMax: -1}}},
+		{name: "env wildcard", sandbox: job.Sandbox{EnvAllow: []string{"A*B"}}},
+		{name: "env value", sandbox: job.Sandbox{EnvAllow: []string{"A=B"}}},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			err := testCase.sandbox.Validate()
+			if testCase.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, job.ErrInvalidSandbox)
+			}
+		})
+	}
+}
+
+func TestSandboxFilterEnv(t *testing.T) {
+	t.Parallel()
+	environ := []string{"PATH=/bin", "HOME=/root", "APP_A=1", "APP_B=2", "APPLE=3"}
+
+	var sandbox *job.Sandbox
+	assert.Equal(t, environ, sandbox.FilterEnv(environ))
+
+	sandbox = &job.Sandbox{EnvAllow: []string{"PATH", "APP_*"}}
+	assert.Equal(t, []string{"PATH=/bin", "APP_A=1", "APP_B=2"}, sandbox.FilterEnv(environ))
+}
@@ -0,0 +1,78 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrHostMismatch    = errors.New("host doesn't match the job's host selector")
+	ErrInvalidSelector = errors.New("invalid host selector")
+	ErrInvalidLabels   = errors.New("invalid labels")
+)
+
+var labelNameRe = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+
+// HostSelector restricts hosts where the job may run: for each label the host must have it
+// with one of the values. `{"zone": ["eu", "us"], "role": ["db-worker"]}` matches db workers in both zones.
+type HostSelector map[string][]string
+
+func (hs HostSelector) Validate() error {
+	for name, values := range hs {
+		if !labelNameRe.MatchString(name) {
+			return fmt.Errorf("%w: invalid label name `%s`", ErrInvalidSelector, name)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("%w: label `%s` has no values", ErrInvalidSelector, name)
+		}
+	}
+
+	return nil
+}
+
+// Match returns ErrHostMismatch with the first unmatched label if labels don't match the selector.
+// Empty selector matches any host.
+func (hs HostSelector) Match(labels map[string]string) error {
+	names := make([]string, 0, len(hs))
+	for name := range hs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, ok := labels[name]
+		if !ok {
+			return fmt.Errorf("%w: label `%s` is required", ErrHostMismatch, name)
+		}
+		if !contains(hs[name], value) {
+			return fmt.Errorf("%w: label `%s=%s`, expected one of: %s",
+				ErrHostMismatch, name, value, strings.Join(hs[name], ", "))
+		}
+	}
+
+	return nil
+}
+
+// validateLabels checks names of the job's labels.
+func (j *Job) validateLabels() error {
+	for name := range j.Labels {
+		if !labelNameRe.MatchString(name) {
+			return fmt.Errorf("%w: invalid label name `%s`", ErrInvalidLabels, name)
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package job_test
+
+import (
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostSelectorMatch(t *testing.T) {
+	t.Parallel()
+	selector := job.HostSelector{"zone": {"eu", "us"}, "role": {"db-worker"}}
+
+	testCases := []struct {
+		name     string
+		selector job.HostSelector
+		labels   map[string]string
+		err      bool
+	}{
+		{name: "empty selector", labels: map[string]string{"zone": "eu"}},
+		{name: "empty selector without labels"},
+		{name: "match", selector: selector, labels: map[string]string{"zone": "us", "role": "db-worker", "os": "linux"}},
+		{name: "missing label", selector: selector, labels: map[string]string{"zone": "eu"}, err: true},
+		{name: "wrong value", selector: selector, labels: map[string]string{"zone": "asia", "role": "db-worker"}, err: true},
+		{name: "no labels", selector: selector, err: true},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			err := testCase.selector.Match(testCase.labels)
+			if testCase.err {
+				assert.ErrorIs(t, err, job.ErrHostMismatch)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHostSelectorValidate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		selector job.HostSelector
+		err      bool
+	}{
+		{name: "valid", selector: job.HostSelector{"zone": {"eu"}, "example.com/role": {"db"}}},
+		{name: "empty", selector: job.HostSelector{}},
+		{name: "no values", selector: job.HostSelector{"zone": {}}, err: true},
+		{name: "invalid name", selector: job.HostSelector{"zone=eu": {"eu"}}, err: true},
+		{name: "empty name", selector: job.HostSelector{"": {"eu"}}, err: true},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			err := testCase.selector.Validate()
+			if testCase.err {
+				assert.ErrorIs(t, err, job.ErrInvalidSelector)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
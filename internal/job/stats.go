@@ -0,0 +1,183 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AllHistory is the window of the whole execution history.
+const AllHistory = "all"
+
+// DefaultStatsWindows are windows of run statistics if they aren't given.
+var DefaultStatsWindows = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+var errInvalidWindow = errors.New("invalid window")
+
+// WindowStats are run statistics of executions started within the window before the time of RunStats.
+// Lost executions are counted as failures, durations are of executions finished by their executors.
+type WindowStats struct {
+	// Window is zero for the whole history.
+	Window     Duration `json:"window"`
+	Executions int      `json:"executions"`
+	Running    int      `json:"running"`
+	Successed  int      `json:"successed"`
+	Failed     int      `json:"failed"`
+	Lost       int      `json:"lost"`
+	// SuccessRate is the share of successful finished executions from 0 to 1, it's nil without them.
+	SuccessRate *float64 `json:"successRate"`
+	P50Duration Duration `json:"p50Duration"`
+	P95Duration Duration `json:"p95Duration"`
+	MaxDuration Duration `json:"maxDuration"`
+	// FailureStreak is the number of the latest finished executions failed in a row,
+	// LongestFailureStreak is the longest such sequence in the window.
+	FailureStreak        int        `json:"failureStreak"`
+	LongestFailureStreak int        `json:"longestFailureStreak"`
+	LastSuccessAt        *time.Time `json:"lastSuccessAt,omitempty"`
+	// LockRejections is the number of starts refused because the job was locked.
+	LockRejections int `json:"lockRejections"`
+}
+
+// LockRejection is a start of the job finally refused because the job was locked: the start didn't wait
+// or its waiter left the queue. Responses of long-polling starts which keep waiting aren't rejections.
+type LockRejection struct {
+	Job string    `json:"job"`
+	At  time.Time `json:"at"`
+}
+
+// RunStats are run statistics of the job over windows.
+type RunStats struct {
+	Job     string        `json:"job"`
+	At      time.Time     `json:"at"`
+	Windows []WindowStats `json:"windows"`
+}
+
+// ComputeRunStats computes statistics of the job's executions and its lock rejections
+// over windows ending at now, zero window is the whole history.
+func ComputeRunStats(jobName string, executions []Execution, rejections []LockRejection, windows []time.Duration,
+	now time.Time,
+) RunStats {
+	sorted := make([]Execution, len(executions))
+	copy(sorted, executions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartedAt.Before(sorted[j].StartedAt)
+	})
+
+	stats := RunStats{Job: jobName, At: now, Windows: make([]WindowStats, 0, len(windows))}
+	for _, window := range windows {
+		var since time.Time
+		if window > 0 {
+			since = now.Add(-window)
+		}
+		windowStats := computeWindowStats(sorted, since)
+		windowStats.Window = Duration(window)
+		for _, rejection := range rejections {
+			if !rejection.At.Before(since) {
+				windowStats.LockRejections++
+			}
+		}
+		stats.Windows = append(stats.Windows, windowStats)
+	}
+
+	return stats
+}
+
+// computeWindowStats computes statistics of executions sorted by start time which started since the time.
+func computeWindowStats(executions []Execution, since time.Time) WindowStats {
+	var (
+		stats     WindowStats
+		durations []time.Duration
+		streak    int
+	)
+	for i := range executions {
+		execution := &executions[i]
+		if execution.StartedAt.Before(since) {
+			continue
+		}
+		stats.Executions++
+
+		switch execution.Status {
+		case StatusRunning:
+			stats.Running++
+
+			continue
+		case StatusSuccessed:
+			stats.Successed++
+			streak = 0
+			// Imported executions may have no finish time.
+			if execution.FinishedAt != nil &&
+				(stats.LastSuccessAt == nil || execution.FinishedAt.After(*stats.LastSuccessAt)) {
+				stats.LastSuccessAt = execution.FinishedAt
+			}
+		case StatusFailed, StatusLost:
+			if execution.Status == StatusLost {
+				stats.Lost++
+			} else {
+				stats.Failed++
+			}
+			streak++
+			if streak > stats.LongestFailureStreak {
+				stats.LongestFailureStreak = streak
+			}
+		}
+		if execution.Status != StatusLost && execution.FinishedAt != nil {
+			durations = append(durations, execution.FinishedAt.Sub(execution.StartedAt))
+		}
+	}
+	stats.FailureStreak = streak
+
+	if finished := stats.Successed + stats.Failed + stats.Lost; finished > 0 {
+		rate := float64(stats.Successed) / float64(finished)
+		stats.SuccessRate = &rate
+	}
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool {
+			return durations[i] < durations[j]
+		})
+		stats.P50Duration = Duration(percentile(durations, 50))
+		stats.P95Duration = Duration(percentile(durations, 95))
+		stats.MaxDuration = Duration(durations[len(durations)-1])
+	}
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// ParseStatsWindow parses the window of run statistics: a duration like `24h`, days like `7d`
+// or `all` for the whole history which is zero.
+func ParseStatsWindow(value string) (time.Duration, error) {
+	if value == AllHistory {
+		return 0, nil
+	}
+
+	var (
+		window time.Duration
+		err    error
+	)
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		var count int
+		count, err = strconv.Atoi(days)
+		window = time.Duration(count) * 24 * time.Hour
+	} else {
+		window, err = time.ParseDuration(value)
+	}
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("%w `%s`: expected a positive duration like `24h`, days like `7d` or `%s`",
+			errInvalidWindow, value, AllHistory)
+	}
+
+	return window, nil
+}
@@ -0,0 +1,132 @@
+package job_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeRunStats(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+	execution := func(startedAgo time.Duration, status job.ExecutionStatus, duration time.Duration) job.Execution {
+		exec := job.NewRunningExecution(TestJobName)
+		exec.SetStartedAt(now.Add(-startedAgo))
+		if status != job.StatusRunning {
+			exec.Finish(status, now.Add(-startedAgo+duration), "")
+		}
+
+		return *exec
+	}
+	// Executions are unordered, streaks are counted by the start time.
+	executions := []job.Execution{
+		execution(10*24*time.Hour, job.StatusFailed, 50*time.Second),
+		execution(9*24*time.Hour, job.StatusFailed, 40*time.Second),
+		execution(8*24*time.Hour, job.StatusFailed, 30*time.Second),
+		execution(5*time.Hour, job.StatusSuccessed, 2*time.Second),
+		execution(7*24*time.Hour, job.StatusSuccessed, 10*time.Second),
+		execution(4*time.Hour, job.StatusFailed, 4*time.Second),
+		execution(3*time.Hour, job.StatusSuccessed, 3*time.Second),
+		execution(2*time.Hour, job.StatusFailed, time.Second),
+		execution(time.Hour, job.StatusLost, 10*time.Minute),
+		execution(time.Minute, job.StatusRunning, 0),
+	}
+	rejections := []job.LockRejection{
+		{Job: TestJobName, At: now.Add(-time.Minute)},
+		{Job: TestJobName, At: now.Add(-2 * 24 * time.Hour)},
+	}
+
+	stats := job.ComputeRunStats(TestJobName, executions, rejections,
+		[]time.Duration{24 * time.Hour, 0, time.Second}, now)
+	assert.Equal(t, TestJobName, stats.Job)
+	assert.Equal(t, now, stats.At)
+	require.Len(t, stats.Windows, 3)
+
+	day := stats.Windows[0]
+	require.NotNil(t, day.SuccessRate)
+	assert.InDelta(t, 0.4, *day.SuccessRate, 0.001)
+	lastSuccessAt := now.Add(-3*time.Hour + 3*time.Second)
+	assert.Equal(t, job.WindowStats{
+		Window:               job.Duration(24 * time.Hour),
+		Executions:           6,
+		Running:              1,
+		Successed:            2,
+		Failed:               2,
+		Lost:                 1,
+		SuccessRate:          day.SuccessRate,
+		P50Duration:          job.Duration(2 * time.Second),
+		P95Duration:          job.Duration(4 * time.Second),
+		MaxDuration:          job.Duration(4 * time.Second),
+		FailureStreak:        2,
+		LongestFailureStreak: 2,
+		LastSuccessAt:        &lastSuccessAt,
+		LockRejections:       1,
+	}, day)
+
+	all := stats.Windows[1]
+	assert.Equal(t, job.Duration(0), all.Window)
+	assert.Equal(t, 10, all.Executions)
+	assert.InDelta(t, 3.0/9, *all.SuccessRate, 0.001)
+	assert.Equal(t, job.Duration(4*time.Second), all.P50Duration)
+	assert.Equal(t, job.Duration(50*time.Second), all.P95Duration)
+	assert.Equal(t, 2, all.FailureStreak)
+	assert.Equal(t, 3, all.LongestFailureStreak)
+	assert.Equal(t, 2, all.LockRejections)
+
+	empty := stats.Windows[2]
+	assert.Equal(t, job.WindowStats{Window: job.Duration(time.Second)}, empty)
+}
+
+func TestComputeRunStatsWithoutFinishedAt(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+	finishedAt := now.Add(-time.Hour)
+	executions := []job.Execution{
+		{Job: TestJobName, Status: job.StatusSuccessed, StartedAt: now.Add(-3 * time.Hour)},
+		{Job: TestJobName, Status: job.StatusSuccessed, StartedAt: now.Add(-2 * time.Hour), FinishedAt: &finishedAt},
+		{Job: TestJobName, Status: job.StatusSuccessed, StartedAt: now.Add(-time.Hour)},
+	}
+
+	stats := job.ComputeRunStats(TestJobName, executions, nil, []time.Duration{0}, now)
+	require.Len(t, stats.Windows, 1)
+	assert.Equal(t, 3, stats.Windows[0].Successed)
+	assert.Equal(t, &finishedAt, stats.Windows[0].LastSuccessAt)
+	assert.Equal(t, job.Duration(time.Hour), stats.Windows[0].MaxDuration)
+}
+
+func TestParseStatsWindow(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		err      bool
+	}{
+		{value: "24h", expected: 24 * time.Hour},
+		{value: "90m", expected: 90 * time.Minute},
+		{value: "7d", expected: 7 * 24 * time.Hour},
+		{value: "all", expected: 0},
+		{value: "0", err: true},
+		{value: "-1h", err: true},
+		{value: "0d", err: true},
+		{value: "d", err: true},
+		{value: "week", err: true},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.value, func(t *testing.T) {
+			t.Parallel()
+			window, err := job.ParseStatsWindow(testCase.value)
+			if testCase.err {
+				assert.Error(t, err)
+
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, window)
+		})
+	}
+}
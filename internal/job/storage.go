@@ -1,6 +1,16 @@
 package job
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrJobNotFound       = errors.New("job not found")
+	ErrExecutionNotFound = errors.New("execution not found")
+)
 
 //go:generate mockery --case underscore --name Storage
 type Storage interface {
@@ -15,6 +25,18 @@ type ExecutionStorage interface {
 	Store(execution *Execution) error
 	GetByJobName(jobName string) ([]Execution, error)
 	GetByID(id uuid.UUID) (*Execution, error)
+	// GetRunning returns running executions of all jobs.
+	GetRunning() ([]Execution, error)
 	DeleteByJobName(jobName string) error
 	Delete(executionID uuid.UUID) error
 }
+
+//go:generate mockery --case underscore --name RejectionStorage
+type RejectionStorage interface {
+	Append(rejection LockRejection) error
+	// Find returns rejections of the job since the time in the order of appending, zero since means all of them.
+	Find(jobName string, since time.Time) ([]LockRejection, error)
+	DeleteByJobName(jobName string) error
+	// DeleteBefore deletes rejections of all jobs older than the time and returns their number.
+	DeleteBefore(before time.Time) (int, error)
+}
@@ -0,0 +1,253 @@
+// Package storagetest contains conformance tests which every job.Storage,
+// job.ExecutionStorage and job.RejectionStorage implementation has to pass.
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage(t *testing.T, newStorage func(t *testing.T) job.Storage) {
+	t.Helper()
+
+	t.Run("store and get by name", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		jb := job.NewJob("job1")
+		assert.NoError(t, store.Store(jb))
+
+		jb.LockMode = job.ClusterLockMode
+		assert.NoError(t, store.Store(jb))
+
+		stored, err := store.GetByName("job1")
+		assert.NoError(t, err)
+		assert.Equal(t, "job1", stored.Name)
+		assert.Equal(t, job.ClusterLockMode, stored.LockMode)
+	})
+
+	t.Run("get by name not found", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		stored, err := store.GetByName("job1")
+		assert.ErrorIs(t, err, job.ErrJobNotFound)
+		assert.Nil(t, stored)
+	})
+
+	t.Run("get all", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		assert.NoError(t, store.Store(job.NewJob("job2")))
+		assert.NoError(t, store.Store(job.NewJob("job1")))
+
+		jobs, err := store.GetAll()
+		assert.NoError(t, err)
+		assert.Len(t, jobs, 2)
+		assert.Equal(t, "job1", jobs[0].Name)
+		assert.Equal(t, "job2", jobs[1].Name)
+	})
+
+	t.Run("delete by name", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		assert.NoError(t, store.Store(job.NewJob("job1")))
+		assert.NoError(t, store.Store(job.NewJob("job2")))
+
+		assert.NoError(t, store.DeleteByName("job1"))
+		assert.NoError(t, store.DeleteByName("undefined"))
+
+		jobs, err := store.GetAll()
+		assert.NoError(t, err)
+		assert.Len(t, jobs, 1)
+		assert.Equal(t, "job2", jobs[0].Name)
+	})
+}
+
+func TestExecutionStorage(t *testing.T, newStorage func(t *testing.T) job.ExecutionStorage) {
+	t.Helper()
+
+	t.Run("store and get by id", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		execution := newExecution("job1", "host1", 1)
+		assert.NoError(t, store.Store(execution))
+
+		execution.Finish(job.StatusFailed, time.Now(), "msg")
+		assert.NoError(t, store.Store(execution))
+
+		stored, err := store.GetByID(execution.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, execution.ID, stored.ID)
+		assert.Equal(t, job.StatusFailed, stored.Status)
+		assert.Equal(t, "msg", *stored.Msg)
+
+		executions, err := store.GetByJobName("job1")
+		assert.NoError(t, err)
+		assert.Len(t, executions, 1)
+	})
+
+	t.Run("get by id not found", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		stored, err := store.GetByID(uuid.New())
+		assert.ErrorIs(t, err, job.ErrExecutionNotFound)
+		assert.Nil(t, stored)
+	})
+
+	t.Run("get by job name", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		assert.NoError(t, store.Store(newExecution("job1", "host1", 1)))
+		assert.NoError(t, store.Store(newExecution("job1", "host2", 1)))
+		assert.NoError(t, store.Store(newExecution("job10", "host1", 1)))
+
+		executions, err := store.GetByJobName("job1")
+		assert.NoError(t, err)
+		assert.Len(t, executions, 2)
+		for _, execution := range executions {
+			assert.Equal(t, "job1", execution.Job)
+		}
+
+		executions, err = store.GetByJobName("undefined")
+		assert.NoError(t, err)
+		assert.Len(t, executions, 0)
+	})
+
+	t.Run("get running", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		running := newExecution("job1", "host1", 1)
+		assert.NoError(t, store.Store(running))
+		finished := newExecution("job2", "host1", 2)
+		assert.NoError(t, store.Store(finished))
+		finished.Finish(job.StatusSuccessed, time.Now(), "")
+		assert.NoError(t, store.Store(finished))
+		deleted := newExecution("job1", "host2", 3)
+		assert.NoError(t, store.Store(deleted))
+		assert.NoError(t, store.Delete(deleted.ID))
+		assert.NoError(t, store.Store(newExecution("job3", "host1", 4)))
+		assert.NoError(t, store.DeleteByJobName("job3"))
+
+		executions, err := store.GetRunning()
+		assert.NoError(t, err)
+		if assert.Len(t, executions, 1) {
+			assert.Equal(t, running.ID, executions[0].ID)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		execution := newExecution("job1", "host1", 1)
+		assert.NoError(t, store.Store(execution))
+		assert.NoError(t, store.Store(newExecution("job1", "host2", 1)))
+
+		assert.NoError(t, store.Delete(execution.ID))
+		assert.NoError(t, store.Delete(uuid.New()))
+
+		executions, err := store.GetByJobName("job1")
+		assert.NoError(t, err)
+		assert.Len(t, executions, 1)
+	})
+
+	t.Run("delete by job name", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		assert.NoError(t, store.Store(newExecution("job1", "host1", 1)))
+		assert.NoError(t, store.Store(newExecution("job1", "host2", 1)))
+		assert.NoError(t, store.Store(newExecution("job2", "host1", 1)))
+
+		assert.NoError(t, store.DeleteByJobName("job1"))
+
+		executions, err := store.GetByJobName("job1")
+		assert.NoError(t, err)
+		assert.Len(t, executions, 0)
+		executions, err = store.GetByJobName("job2")
+		assert.NoError(t, err)
+		assert.Len(t, executions, 1)
+	})
+}
+
+func TestRejectionStorage(t *testing.T, newStorage func(t *testing.T) job.RejectionStorage) {
+	t.Helper()
+	now := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("append and find", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		// "job" is the prefix of "job1", rejections of another job with the prefix aren't found.
+		rejections := []job.LockRejection{
+			{Job: "job", At: now.Add(-time.Hour)},
+			{Job: "job1", At: now.Add(-48 * time.Hour)},
+			{Job: "job1", At: now.Add(-time.Minute)},
+		}
+		for _, rejection := range rejections {
+			assert.NoError(t, store.Append(rejection))
+		}
+
+		found, err := store.Find("job1", time.Time{})
+		assert.NoError(t, err)
+		assert.Len(t, found, 2)
+		for i, rejection := range found {
+			assert.True(t, rejections[i+1].At.Equal(rejection.At))
+			assert.Equal(t, "job1", rejection.Job)
+		}
+
+		found, err = store.Find("job1", now.Add(-24*time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+
+		found, err = store.Find("undefined", time.Time{})
+		assert.NoError(t, err)
+		assert.Empty(t, found)
+	})
+
+	t.Run("delete by job name", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		assert.NoError(t, store.Append(job.LockRejection{Job: "job", At: now}))
+		assert.NoError(t, store.Append(job.LockRejection{Job: "job1", At: now}))
+		assert.NoError(t, store.Append(job.LockRejection{Job: "job1", At: now}))
+
+		assert.NoError(t, store.DeleteByJobName("job1"))
+		assert.NoError(t, store.DeleteByJobName("undefined"))
+
+		found, err := store.Find("job1", time.Time{})
+		assert.NoError(t, err)
+		assert.Empty(t, found)
+		found, err = store.Find("job", time.Time{})
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+	})
+
+	t.Run("delete before", func(t *testing.T) {
+		t.Parallel()
+		store := newStorage(t)
+		assert.NoError(t, store.Append(job.LockRejection{Job: "job", At: now.Add(-48 * time.Hour)}))
+		assert.NoError(t, store.Append(job.LockRejection{Job: "job1", At: now.Add(-48 * time.Hour)}))
+		assert.NoError(t, store.Append(job.LockRejection{Job: "job1", At: now}))
+
+		deleted, err := store.DeleteBefore(now.Add(-24 * time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+
+		found, err := store.Find("job", time.Time{})
+		assert.NoError(t, err)
+		assert.Empty(t, found)
+		found, err = store.Find("job1", time.Time{})
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+	})
+}
+
+func newExecution(jobName string, host string, pid int) *job.Execution {
+	execution := job.NewRunningExecution(jobName)
+	execution.SetHost(host)
+	execution.SetPid(pid)
+	execution.SetCommand("command")
+
+	return execution
+}
@@ -0,0 +1,227 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ConflictStrategy string
+
+const (
+	ConflictSkip      ConflictStrategy = "skip"
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	ConflictFail      ConflictStrategy = "fail"
+)
+
+var (
+	errUndefinedConflictStrategy = errors.New("undefined conflict strategy")
+	ErrInvalidDump               = errors.New("invalid dump")
+)
+
+type Dump struct {
+	Jobs       []Job       `json:"jobs"`
+	Executions []Execution `json:"executions"`
+}
+
+type ImportResult struct {
+	Created     int `json:"created"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+}
+
+type ConflictError struct {
+	Kind string
+	Key  string
+}
+
+func (ce *ConflictError) Error() string {
+	return fmt.Sprintf("%s `%s` already exists", ce.Kind, ce.Key)
+}
+
+func ParseConflictStrategy(strategy string) (ConflictStrategy, error) {
+	switch ConflictStrategy(strategy) {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+		return ConflictStrategy(strategy), nil
+	default:
+		return "", fmt.Errorf("%w: `%s`, can be `skip`, `overwrite` or `fail`", errUndefinedConflictStrategy, strategy)
+	}
+}
+
+type Transfer struct {
+	jobStorage       Storage
+	executionStorage ExecutionStorage
+}
+
+func NewTransfer(jobStorage Storage, executionStorage ExecutionStorage) *Transfer {
+	return &Transfer{
+		jobStorage:       jobStorage,
+		executionStorage: executionStorage,
+	}
+}
+
+func (t *Transfer) Export(withHistory bool) (*Dump, error) {
+	jobs, err := t.jobStorage.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("export jobs: %w", err)
+	}
+
+	dump := &Dump{Jobs: jobs, Executions: []Execution{}}
+	if dump.Jobs == nil {
+		dump.Jobs = []Job{}
+	}
+	if !withHistory {
+		return dump, nil
+	}
+
+	for _, jb := range jobs {
+		executions, err := t.executionStorage.GetByJobName(jb.Name)
+		if err != nil {
+			return nil, fmt.Errorf("export executions of %s: %w", jb.Name, err)
+		}
+		dump.Executions = append(dump.Executions, executions...)
+	}
+
+	return dump, nil
+}
+
+// Import stores jobs and executions from dump. Nothing is written if any job is invalid, an execution
+// belongs to a job which is neither imported nor exists, is running or is finished without finishedAt,
+// with ConflictFail also if at least one record already exists. Empty lock modes and statuses of jobs are defaults of new jobs.
+func (t *Transfer) Import(dump *Dump, strategy ConflictStrategy) (*ImportResult, error) {
+	existJobs, existExecutions, err := t.existing(dump)
+	if err != nil {
+		return nil, fmt.Errorf("import: %w", err)
+	}
+	if err := t.validate(dump, existJobs); err != nil {
+		return nil, fmt.Errorf("import: %w", err)
+	}
+
+	if strategy == ConflictFail {
+		if err := t.checkConflicts(dump, existJobs, existExecutions); err != nil {
+			return nil, fmt.Errorf("import: %w", err)
+		}
+	}
+
+	result := &ImportResult{}
+	for i := range dump.Jobs {
+		if !t.apply(strategy, existJobs[dump.Jobs[i].Name], result) {
+			continue
+		}
+		if err := t.jobStorage.Store(&dump.Jobs[i]); err != nil {
+			return result, fmt.Errorf("import job %s: %w", dump.Jobs[i].Name, err)
+		}
+	}
+
+	for i := range dump.Executions {
+		if !t.apply(strategy, existExecutions[dump.Executions[i].ID.String()], result) {
+			continue
+		}
+		if err := t.executionStorage.Store(&dump.Executions[i]); err != nil {
+			return result, fmt.Errorf("import execution %s: %w", dump.Executions[i].ID, err)
+		}
+	}
+
+	return result, nil
+}
+
+func (t *Transfer) validate(dump *Dump, existJobs map[string]bool) error {
+	imported := make(map[string]bool, len(dump.Jobs))
+	for i := range dump.Jobs {
+		jb := &dump.Jobs[i]
+		if jb.LockMode == "" {
+			jb.LockMode = HostLockMode
+		}
+		if jb.Status == "" {
+			jb.Status = JobStatusActive
+		}
+		jb.Overdue = nil
+		if err := jb.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+		}
+		if imported[jb.Name] {
+			return fmt.Errorf("%w: duplicated job %s", ErrInvalidDump, jb.Name)
+		}
+		imported[jb.Name] = true
+	}
+
+	for i := range dump.Executions {
+		exec := &dump.Executions[i]
+		if !imported[exec.Job] && !existJobs[exec.Job] {
+			return fmt.Errorf("%w: job %s of execution %s doesn't exist", ErrInvalidDump, exec.Job, exec.ID)
+		}
+		switch exec.Status {
+		case StatusSuccessed, StatusFailed, StatusLost:
+			if exec.FinishedAt == nil {
+				return fmt.Errorf("%w: finished execution %s has no finishedAt", ErrInvalidDump, exec.ID)
+			}
+		case StatusRunning:
+			return fmt.Errorf("%w: running execution %s can't be imported", ErrInvalidDump, exec.ID)
+		default:
+			return fmt.Errorf("%w: unknown status `%s` of execution %s", ErrInvalidDump, exec.Status, exec.ID)
+		}
+	}
+
+	return nil
+}
+
+func (t *Transfer) apply(strategy ConflictStrategy, exists bool, result *ImportResult) bool {
+	if !exists {
+		result.Created++
+
+		return true
+	}
+	if strategy == ConflictOverwrite {
+		result.Overwritten++
+
+		return true
+	}
+	result.Skipped++
+
+	return false
+}
+
+func (t *Transfer) existing(dump *Dump) (jobs map[string]bool, executions map[string]bool, err error) {
+	jobs = make(map[string]bool)
+	executions = make(map[string]bool)
+
+	allJobs, err := t.jobStorage.GetAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get jobs: %w", err)
+	}
+	for _, jb := range allJobs {
+		jobs[jb.Name] = true
+	}
+
+	loaded := make(map[string]bool)
+	for _, exec := range dump.Executions {
+		if loaded[exec.Job] {
+			continue
+		}
+		loaded[exec.Job] = true
+
+		jobExecutions, err := t.executionStorage.GetByJobName(exec.Job)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get executions of %s: %w", exec.Job, err)
+		}
+		for _, jobExecution := range jobExecutions {
+			executions[jobExecution.ID.String()] = true
+		}
+	}
+
+	return jobs, executions, nil
+}
+
+func (t *Transfer) checkConflicts(dump *Dump, jobs map[string]bool, executions map[string]bool) error {
+	for _, jb := range dump.Jobs {
+		if jobs[jb.Name] {
+			return &ConflictError{Kind: "job", Key: jb.Name}
+		}
+	}
+	for _, exec := range dump.Executions {
+		if executions[exec.ID.String()] {
+			return &ConflictError{Kind: "execution", Key: exec.ID.String()}
+		}
+	}
+
+	return nil
+}
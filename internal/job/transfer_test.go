@@ -0,0 +1,201 @@
+package job_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/job/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+	jobStorage := new(mocks.Storage)
+	jobStorage.On("GetAll").Return([]job.Job{*job.NewJob(TestJobName)}, nil)
+	executionStorage := new(mocks.ExecutionStorage)
+	executionStorage.On("GetByJobName", TestJobName).
+		Return([]job.Execution{*job.NewRunningExecution(TestJobName)}, nil).Once()
+
+	transfer := job.NewTransfer(jobStorage, executionStorage)
+
+	dump, err := transfer.Export(false)
+	assert.NoError(t, err)
+	assert.Len(t, dump.Jobs, 1)
+	assert.Len(t, dump.Executions, 0)
+
+	dump, err = transfer.Export(true)
+	assert.NoError(t, err)
+	assert.Len(t, dump.Jobs, 1)
+	assert.Len(t, dump.Executions, 1)
+	executionStorage.AssertExpectations(t)
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	existExecution := job.NewRunningExecution(TestJobName)
+	existExecution.Finish(job.StatusSuccessed, time.Now(), "")
+	newExecution := job.NewRunningExecution(TestJobName)
+	newExecution.Finish(job.StatusFailed, time.Now(), "")
+
+	testCases := []struct {
+		name             string
+		strategy         job.ConflictStrategy
+		jobStorage       func() *mocks.Storage
+		executionStorage func() *mocks.ExecutionStorage
+		result           *job.ImportResult
+		err              bool
+	}{
+		{
+			name:     "skip exists",
+			strategy: job.ConflictSkip,
+			jobStorage: func() *mocks.Storage {
+				jobStorage := new(mocks.Storage)
+				jobStorage.On("GetAll").Return([]job.Job{*job.NewJob(TestJobName)}, nil)
+				jobStorage.On("Store", mock.MatchedBy(func(j *job.Job) bool {
+					return j.Name == "job2"
+				})).Return(nil).Once()
+
+				return jobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				executionStorage := new(mocks.ExecutionStorage)
+				executionStorage.On("GetByJobName", TestJobName).Return([]job.Execution{*existExecution}, nil)
+				executionStorage.On("Store", mock.MatchedBy(func(e *job.Execution) bool {
+					return e.ID == newExecution.ID
+				})).Return(nil).Once()
+
+				return executionStorage
+			},
+			result: &job.ImportResult{Created: 2, Skipped: 2},
+		},
+		{
+			name:     "overwrite exists",
+			strategy: job.ConflictOverwrite,
+			jobStorage: func() *mocks.Storage {
+				jobStorage := new(mocks.Storage)
+				jobStorage.On("GetAll").Return([]job.Job{*job.NewJob(TestJobName)}, nil)
+				jobStorage.On("Store", mock.Anything).Return(nil).Twice()
+
+				return jobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				executionStorage := new(mocks.ExecutionStorage)
+				executionStorage.On("GetByJobName", TestJobName).Return([]job.Execution{*existExecution}, nil)
+				executionStorage.On("Store", mock.Anything).Return(nil).Twice()
+
+				return executionStorage
+			},
+			result: &job.ImportResult{Created: 2, Overwritten: 2},
+		},
+		{
+			name:     "fail on exists",
+			strategy: job.ConflictFail,
+			jobStorage: func() *mocks.Storage {
+				jobStorage := new(mocks.Storage)
+				jobStorage.On("GetAll").Return([]job.Job{*job.NewJob(TestJobName)}, nil)
+
+				return jobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				executionStorage := new(mocks.ExecutionStorage)
+				executionStorage.On("GetByJobName", TestJobName).Return([]job.Execution{*existExecution}, nil)
+
+				return executionStorage
+			},
+			err: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			jobStorage := testCase.jobStorage()
+			executionStorage := testCase.executionStorage()
+			transfer := job.NewTransfer(jobStorage, executionStorage)
+
+			result, err := transfer.Import(&job.Dump{
+				Jobs:       []job.Job{*job.NewJob(TestJobName), *job.NewJob("job2")},
+				Executions: []job.Execution{*existExecution, *newExecution},
+			}, testCase.strategy)
+			if testCase.err {
+				assert.ErrorAs(t, err, new(*job.ConflictError))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.result, result)
+			}
+			jobStorage.AssertExpectations(t)
+			executionStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	t.Parallel()
+	unknownStatus := job.NewRunningExecution(TestJobName)
+	unknownStatus.Status = "unknown"
+	unfinished := job.NewRunningExecution(TestJobName)
+	unfinished.Status = job.StatusLost
+
+	testCases := []struct {
+		name string
+		dump job.Dump
+	}{
+		{name: "empty name", dump: job.Dump{Jobs: []job.Job{{}}}},
+		{name: "unknown lock mode", dump: job.Dump{Jobs: []job.Job{{Name: "job2", LockMode: "unknown"}}}},
+		{name: "unknown status", dump: job.Dump{Jobs: []job.Job{{Name: "job2", Status: "unknown"}}}},
+		{name: "invalid config", dump: job.Dump{Jobs: []job.Job{{Name: "job2", Env: map[string]string{"1=": ""}}}}},
+		{name: "duplicated job", dump: job.Dump{Jobs: []job.Job{*job.NewJob("job2"), *job.NewJob("job2")}}},
+		{
+			name: "execution of missing job",
+			dump: job.Dump{Executions: []job.Execution{*job.NewRunningExecution("missing")}},
+		},
+		{
+			name: "unknown execution status",
+			dump: job.Dump{Executions: []job.Execution{*unknownStatus}},
+		},
+		{
+			name: "running execution",
+			dump: job.Dump{Executions: []job.Execution{*job.NewRunningExecution(TestJobName)}},
+		},
+		{
+			name: "finished without finishedAt",
+			dump: job.Dump{Executions: []job.Execution{*unfinished}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			jobStorage := new(mocks.Storage)
+			jobStorage.On("GetAll").Return([]job.Job{*job.NewJob(TestJobName)}, nil)
+			executionStorage := new(mocks.ExecutionStorage)
+			executionStorage.On("GetByJobName", mock.Anything).Return([]job.Execution{}, nil)
+			transfer := job.NewTransfer(jobStorage, executionStorage)
+
+			dump := testCase.dump
+			_, err := transfer.Import(&dump, job.ConflictOverwrite)
+			assert.True(t, errors.Is(err, job.ErrInvalidDump), "unexpected error: %v", err)
+			jobStorage.AssertNotCalled(t, "Store", mock.Anything)
+			executionStorage.AssertNotCalled(t, "Store", mock.Anything)
+		})
+	}
+}
+
+func TestImportDefaults(t *testing.T) {
+	t.Parallel()
+	jobStorage := new(mocks.Storage)
+	jobStorage.On("GetAll").Return([]job.Job{}, nil)
+	jobStorage.On("Store", mock.MatchedBy(func(j *job.Job) bool {
+		return j.LockMode == job.HostLockMode && j.Status == job.JobStatusActive
+	})).Return(nil).Once()
+	transfer := job.NewTransfer(jobStorage, new(mocks.ExecutionStorage))
+
+	_, err := transfer.Import(&job.Dump{Jobs: []job.Job{{Name: "job2"}}}, job.ConflictFail)
+	assert.NoError(t, err)
+	jobStorage.AssertExpectations(t)
+}
@@ -0,0 +1,89 @@
+package job
+
+import (
+	"sort"
+)
+
+// DefaultUsageWindow is the number of the latest executions aggregated by default.
+const DefaultUsageWindow = 100
+
+// Usage is the resource usage of the execution's command, it's reported by the executor at exit.
+type Usage struct {
+	UserCPU   Duration `json:"userCpu"`
+	SystemCPU Duration `json:"systemCpu"`
+	// MaxRSS is the peak resident set size of the largest process of the command, in bytes.
+	MaxRSS int64 `json:"maxRss"`
+	// InBlock and OutBlock are the numbers of block input and output operations.
+	InBlock  int64 `json:"inBlock"`
+	OutBlock int64 `json:"outBlock"`
+	// PeakGroupRSS is the peak sum of RSS of the command's process group in bytes, it's sampled during
+	// the run, Samples is the number of samples.
+	PeakGroupRSS int64 `json:"peakGroupRss,omitempty"`
+	Samples      int   `json:"samples,omitempty"`
+}
+
+// UsageStats aggregates resource usage of the job's finished executions.
+type UsageStats struct {
+	Job string `json:"job"`
+	// Executions is the number of aggregated executions, only the ones with reported usage are counted.
+	Executions   int      `json:"executions"`
+	AvgUserCPU   Duration `json:"avgUserCpu"`
+	MaxUserCPU   Duration `json:"maxUserCpu"`
+	AvgSystemCPU Duration `json:"avgSystemCpu"`
+	MaxSystemCPU Duration `json:"maxSystemCpu"`
+	AvgMaxRSS    int64    `json:"avgMaxRss"`
+	MaxMaxRSS    int64    `json:"maxMaxRss"`
+	AvgInBlock   int64    `json:"avgInBlock"`
+	AvgOutBlock  int64    `json:"avgOutBlock"`
+}
+
+// AggregateUsage aggregates usage of the latest `last` finished executions of the job,
+// zero `last` means all of them.
+func AggregateUsage(jobName string, executions []Execution, last int) UsageStats {
+	finished := make([]Execution, 0, len(executions))
+	for _, exec := range executions {
+		if exec.Status != StatusRunning && exec.FinishedAt != nil && exec.Usage != nil {
+			finished = append(finished, exec)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.After(*finished[j].FinishedAt)
+	})
+	if last > 0 && len(finished) > last {
+		finished = finished[:last]
+	}
+
+	stats := UsageStats{Job: jobName, Executions: len(finished)}
+	if len(finished) == 0 {
+		return stats
+	}
+
+	var userCPU, systemCPU Duration
+	var maxRSS, inBlock, outBlock int64
+	for _, exec := range finished {
+		usage := exec.Usage
+		userCPU += usage.UserCPU
+		systemCPU += usage.SystemCPU
+		maxRSS += usage.MaxRSS
+		inBlock += usage.InBlock
+		outBlock += usage.OutBlock
+		if usage.UserCPU > stats.MaxUserCPU {
+			stats.MaxUserCPU = usage.UserCPU
+		}
+		if usage.SystemCPU > stats.MaxSystemCPU {
+			stats.MaxSystemCPU = usage.SystemCPU
+		}
+		if usage.MaxRSS > stats.MaxMaxRSS {
+			stats.MaxMaxRSS = usage.MaxRSS
+		}
+	}
+
+	count := int64(len(finished))
+	stats.AvgUserCPU = userCPU / Duration(count)
+	stats.AvgSystemCPU = systemCPU / Duration(count)
+	stats.AvgMaxRSS = maxRSS / count
+	stats.AvgInBlock = inBlock / count
+	stats.AvgOutBlock = outBlock / count
+
+	return stats
+}
@@ -0,0 +1,70 @@
+package job_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateUsage(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	finished := func(ago time.Duration, usage *job.Usage) job.Execution {
+		exec := job.NewRunningExecution(TestJobName)
+		exec.Finish(job.StatusSuccessed, now.Add(-ago), "")
+		exec.Usage = usage
+
+		return *exec
+	}
+	executions := []job.Execution{
+		finished(3*time.Hour, &job.Usage{UserCPU: job.Duration(9 * time.Second), MaxRSS: 9000}),
+		finished(2*time.Hour, &job.Usage{
+			UserCPU: job.Duration(2 * time.Second), SystemCPU: job.Duration(time.Second),
+			MaxRSS: 2000, InBlock: 10, OutBlock: 20,
+		}),
+		finished(time.Hour, &job.Usage{
+			UserCPU: job.Duration(4 * time.Second), SystemCPU: job.Duration(3 * time.Second),
+			MaxRSS: 4000, InBlock: 30, OutBlock: 40,
+		}),
+		finished(time.Minute, nil),
+		*job.NewRunningExecution(TestJobName),
+	}
+
+	testCases := []struct {
+		name     string
+		last     int
+		expected job.UsageStats
+	}{
+		{
+			name: "latest",
+			last: 2,
+			expected: job.UsageStats{
+				Job: TestJobName, Executions: 2,
+				AvgUserCPU: job.Duration(3 * time.Second), MaxUserCPU: job.Duration(4 * time.Second),
+				AvgSystemCPU: job.Duration(2 * time.Second), MaxSystemCPU: job.Duration(3 * time.Second),
+				AvgMaxRSS: 3000, MaxMaxRSS: 4000, AvgInBlock: 20, AvgOutBlock: 30,
+			},
+		},
+		{
+			name: "all",
+			expected: job.UsageStats{
+				Job: TestJobName, Executions: 3,
+				AvgUserCPU: job.Duration(5 * time.Second), MaxUserCPU: job.Duration(9 * time.Second),
+				AvgSystemCPU: job.Duration(4 * time.Second / 3), MaxSystemCPU: job.Duration(3 * time.Second),
+				AvgMaxRSS: 5000, MaxMaxRSS: 9000, AvgInBlock: 13, AvgOutBlock: 20,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, job.AggregateUsage(TestJobName, executions, testCase.last))
+		})
+	}
+
+	assert.Equal(t, job.UsageStats{Job: TestJobName}, job.AggregateUsage(TestJobName, nil, 0))
+}
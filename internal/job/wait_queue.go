@@ -0,0 +1,263 @@
+package job
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultWaiterGrace is how long a waiter keeps its place in the queue between polls.
+const DefaultWaiterGrace = 10 * time.Second
+
+type Waiter struct {
+	ID       uuid.UUID `json:"id"`
+	Job      string    `json:"job"`
+	Scope    string    `json:"scope"`
+	Host     *string   `json:"host"`
+	Pid      *int      `json:"pid"`
+	Since    time.Time `json:"since"`
+	Position int       `json:"position"`
+
+	lastSeen time.Time
+	polling  int
+}
+
+// WaitQueue is a FIFO queue of starts waiting for the lock, one queue per lock scope.
+// Waiters are expected to long-poll, a waiter which doesn't poll longer than grace is removed.
+type WaitQueue struct {
+	mu      sync.Mutex
+	grace   time.Duration
+	queues  map[string][]*Waiter
+	changed map[string]chan struct{}
+}
+
+func NewWaitQueue(grace time.Duration) *WaitQueue {
+	return &WaitQueue{
+		grace:   grace,
+		queues:  make(map[string][]*Waiter),
+		changed: make(map[string]chan struct{}),
+	}
+}
+
+// Join adds the waiter to the end of the queue, the known waiter keeps its place.
+// The waiter is polling until Done is called.
+func (q *WaitQueue) Join(scope string, waiter Waiter) Waiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire(scope, time.Now())
+	for _, queued := range q.queues[scope] {
+		if queued.ID == waiter.ID {
+			queued.polling++
+			queued.lastSeen = time.Now()
+
+			return q.copy(scope, queued)
+		}
+	}
+
+	if waiter.ID == uuid.Nil {
+		waiter.ID = uuid.New()
+	}
+	waiter.Scope = scope
+	waiter.Since = time.Now()
+	waiter.lastSeen = waiter.Since
+	waiter.polling = 1
+	q.queues[scope] = append(q.queues[scope], &waiter)
+
+	return q.copy(scope, &waiter)
+}
+
+// Done finishes the poll of the waiter, it keeps the place for the grace period.
+func (q *WaitQueue) Done(scope string, id uuid.UUID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, queued := range q.queues[scope] {
+		if queued.ID == id && queued.polling > 0 {
+			queued.polling--
+			queued.lastSeen = time.Now()
+		}
+	}
+}
+
+// Leave removes the waiter and wakes up the rest of the queue.
+func (q *WaitQueue) Leave(scope string, id uuid.UUID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.leave(scope, id)
+}
+
+// LeaveByID removes the waiter from any queue and returns it, false means there is no such waiter.
+func (q *WaitQueue) LeaveByID(id uuid.UUID) (Waiter, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for scope, queue := range q.queues {
+		for _, queued := range queue {
+			if queued.ID == id {
+				left := q.copy(scope, queued)
+				q.leave(scope, id)
+
+				return left, true
+			}
+		}
+	}
+
+	return Waiter{}, false
+}
+
+func (q *WaitQueue) leave(scope string, id uuid.UUID) bool {
+	queue := q.queues[scope]
+	for i, queued := range queue {
+		if queued.ID != id {
+			continue
+		}
+		if len(queue) == 1 {
+			delete(q.queues, scope)
+		} else {
+			q.queues[scope] = append(queue[:i:i], queue[i+1:]...)
+		}
+		q.notify(scope)
+
+		return true
+	}
+
+	return false
+}
+
+// Get returns the waiter with its current position.
+func (q *WaitQueue) Get(scope string, id uuid.UUID) (Waiter, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, queued := range q.queues[scope] {
+		if queued.ID == id {
+			return q.copy(scope, queued), true
+		}
+	}
+
+	return Waiter{}, false
+}
+
+// CanStart reports whether the start is allowed by the queue: it's empty or the waiter is the first one.
+func (q *WaitQueue) CanStart(scope string, id uuid.UUID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire(scope, time.Now())
+	queue := q.queues[scope]
+
+	return len(queue) == 0 || queue[0].ID == id
+}
+
+// Changed returns the channel which is closed on the next change of the scope: the lock may be released
+// or the queue is moved.
+func (q *WaitQueue) Changed(scope string) <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	changed, ok := q.changed[scope]
+	if !ok {
+		changed = make(chan struct{})
+		q.changed[scope] = changed
+	}
+
+	return changed
+}
+
+// Notify wakes up waiters of the scope.
+func (q *WaitQueue) Notify(scope string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.notify(scope)
+}
+
+// NotifyJob wakes up waiters of all scopes of the job.
+func (q *WaitQueue) NotifyJob(jobName string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for scope, queue := range q.queues {
+		if len(queue) > 0 && queue[0].Job == jobName {
+			q.notify(scope)
+		}
+	}
+}
+
+// NotifyAll wakes up waiters of all scopes, e.g. shared resources are released.
+func (q *WaitQueue) NotifyAll() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for scope := range q.queues {
+		q.notify(scope)
+	}
+}
+
+// List returns waiters of the job in the queue order, all waiters if the job is empty.
+func (q *WaitQueue) List(jobName string) []Waiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	result := make([]Waiter, 0)
+	for scope := range q.queues {
+		q.expire(scope, now)
+		for _, queued := range q.queues[scope] {
+			if jobName == "" || queued.Job == jobName {
+				result = append(result, q.copy(scope, queued))
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Scope != result[j].Scope {
+			return result[i].Scope < result[j].Scope
+		}
+
+		return result[i].Position < result[j].Position
+	})
+
+	return result
+}
+
+func (q *WaitQueue) expire(scope string, now time.Time) {
+	queue := q.queues[scope]
+	alive := queue[:0]
+	for _, queued := range queue {
+		if queued.polling > 0 || now.Sub(queued.lastSeen) <= q.grace {
+			alive = append(alive, queued)
+		}
+	}
+	if len(alive) == len(queue) {
+		return
+	}
+
+	if len(alive) == 0 {
+		delete(q.queues, scope)
+	} else {
+		q.queues[scope] = alive
+	}
+	q.notify(scope)
+}
+
+func (q *WaitQueue) notify(scope string) {
+	if changed, ok := q.changed[scope]; ok {
+		close(changed)
+		delete(q.changed, scope)
+	}
+}
+
+func (q *WaitQueue) copy(scope string, waiter *Waiter) Waiter {
+	result := *waiter
+	for i, queued := range q.queues[scope] {
+		if queued.ID == waiter.ID {
+			result.Position = i + 1
+		}
+	}
+
+	return result
+}
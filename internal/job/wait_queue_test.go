@@ -0,0 +1,76 @@
+package job_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitQueueOrder(t *testing.T) {
+	t.Parallel()
+	queue := job.NewWaitQueue(time.Minute)
+
+	first := queue.Join("job", job.Waiter{Job: "job"})
+	second := queue.Join("job", job.Waiter{Job: "job"})
+	other := queue.Join("other", job.Waiter{Job: "other"})
+	assert.Equal(t, 1, first.Position)
+	assert.Equal(t, 2, second.Position)
+	assert.Equal(t, 1, other.Position)
+
+	// The known waiter keeps its place.
+	again := queue.Join("job", job.Waiter{ID: second.ID, Job: "job"})
+	assert.Equal(t, 2, again.Position)
+
+	assert.True(t, queue.CanStart("job", first.ID))
+	assert.False(t, queue.CanStart("job", second.ID))
+	assert.False(t, queue.CanStart("job", uuid.Nil))
+	assert.True(t, queue.CanStart("free", uuid.Nil))
+
+	changed := queue.Changed("job")
+	assert.True(t, queue.Leave("job", first.ID))
+	assertClosed(t, changed)
+	assert.True(t, queue.CanStart("job", second.ID))
+
+	waiters := queue.List("job")
+	assert.Len(t, waiters, 1)
+	assert.Equal(t, second.ID, waiters[0].ID)
+	assert.Equal(t, 1, waiters[0].Position)
+	assert.Len(t, queue.List(""), 2)
+
+	left, ok := queue.LeaveByID(other.ID)
+	assert.True(t, ok)
+	assert.Equal(t, other.ID, left.ID)
+	assert.Equal(t, other.Job, left.Job)
+	_, ok = queue.LeaveByID(other.ID)
+	assert.False(t, ok)
+}
+
+func TestWaitQueueExpire(t *testing.T) {
+	t.Parallel()
+	queue := job.NewWaitQueue(10 * time.Millisecond)
+
+	gone := queue.Join("job", job.Waiter{Job: "job"})
+	polling := queue.Join("job", job.Waiter{Job: "job"})
+	queue.Done("job", gone.ID)
+	changed := queue.Changed("job")
+
+	time.Sleep(20 * time.Millisecond)
+
+	// The waiter which doesn't poll longer than grace loses its place, the polling one keeps it.
+	assert.True(t, queue.CanStart("job", polling.ID))
+	assertClosed(t, changed)
+	_, found := queue.Get("job", gone.ID)
+	assert.False(t, found)
+}
+
+func assertClosed(t *testing.T, changed <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changed:
+	default:
+		t.Error("channel isn't closed")
+	}
+}
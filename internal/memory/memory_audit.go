@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/antgubarev/jobs/internal/audit"
+)
+
+type AuditStorage struct {
+	mu      sync.RWMutex
+	records []audit.Record
+}
+
+func NewAuditStorage() *AuditStorage {
+	return &AuditStorage{}
+}
+
+func (s *AuditStorage) Append(record *audit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, *record)
+
+	return nil
+}
+
+func (s *AuditStorage) Find(filter audit.Filter) ([]audit.Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []audit.Record
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if !filter.Match(&s.records[i]) {
+			continue
+		}
+		result = append(result, s.records[i])
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,18 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/audit"
+	"github.com/antgubarev/jobs/internal/audit/audittest"
+	"github.com/antgubarev/jobs/internal/memory"
+)
+
+func TestAuditStorage(t *testing.T) {
+	t.Parallel()
+	audittest.TestStorage(t, func(t *testing.T) audit.Storage {
+		t.Helper()
+
+		return memory.NewAuditStorage()
+	})
+}
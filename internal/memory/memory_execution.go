@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/google/uuid"
+)
+
+type ExecutionStorage struct {
+	mu         sync.RWMutex
+	executions map[uuid.UUID]job.Execution
+}
+
+func NewExecutionStorage() *ExecutionStorage {
+	return &ExecutionStorage{executions: make(map[uuid.UUID]job.Execution)}
+}
+
+func (s *ExecutionStorage) Store(execution *job.Execution) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.executions[execution.ID] = *execution
+
+	return nil
+}
+
+func (s *ExecutionStorage) GetByJobName(jobName string) ([]job.Execution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []job.Execution
+	for _, execution := range s.executions {
+		if execution.Job == jobName {
+			result = append(result, execution)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+
+	return result, nil
+}
+
+func (s *ExecutionStorage) GetByID(id uuid.UUID) (*job.Execution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	execution, ok := s.executions[id]
+	if !ok {
+		return nil, fmt.Errorf("GetByID: %w: %s", job.ErrExecutionNotFound, id)
+	}
+
+	return &execution, nil
+}
+
+func (s *ExecutionStorage) GetRunning() ([]job.Execution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []job.Execution
+	for _, execution := range s.executions {
+		if execution.Status == job.StatusRunning {
+			result = append(result, execution)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+
+	return result, nil
+}
+
+func (s *ExecutionStorage) DeleteByJobName(jobName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, execution := range s.executions {
+		if execution.Job == jobName {
+			delete(s.executions, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *ExecutionStorage) Delete(executionID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.executions, executionID)
+
+	return nil
+}
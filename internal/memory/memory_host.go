@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/antgubarev/jobs/internal/host"
+)
+
+type HostStorage struct {
+	mu    sync.RWMutex
+	hosts map[string]host.Host
+}
+
+func NewHostStorage() *HostStorage {
+	return &HostStorage{hosts: make(map[string]host.Host)}
+}
+
+func (s *HostStorage) Store(known *host.Host) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hosts[known.Name] = *known
+
+	return nil
+}
+
+func (s *HostStorage) GetAll() ([]host.Host, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hosts := make([]host.Host, 0, len(s.hosts))
+	for _, known := range s.hosts {
+		hosts = append(hosts, known)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Name < hosts[j].Name
+	})
+
+	return hosts, nil
+}
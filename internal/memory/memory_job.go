@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/antgubarev/jobs/internal/job"
+)
+
+type JobStorage struct {
+	mu   sync.RWMutex
+	jobs map[string]job.Job
+}
+
+func NewJobStorage() *JobStorage {
+	return &JobStorage{jobs: make(map[string]job.Job)}
+}
+
+func (s *JobStorage) Store(jb *job.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[jb.Name] = *jb
+
+	return nil
+}
+
+func (s *JobStorage) GetByName(name string) (*job.Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jb, ok := s.jobs[name]
+	if !ok {
+		return nil, fmt.Errorf("GetByName: %w", job.ErrJobNotFound)
+	}
+
+	return &jb, nil
+}
+
+func (s *JobStorage) GetAll() ([]job.Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []job.Job
+	for _, jb := range s.jobs {
+		result = append(result, jb)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+func (s *JobStorage) DeleteByName(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, name)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/job"
+)
+
+type RejectionStorage struct {
+	mu         sync.RWMutex
+	rejections []job.LockRejection
+}
+
+func NewRejectionStorage() *RejectionStorage {
+	return &RejectionStorage{}
+}
+
+func (s *RejectionStorage) Append(rejection job.LockRejection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rejections = append(s.rejections, rejection)
+
+	return nil
+}
+
+func (s *RejectionStorage) Find(jobName string, since time.Time) ([]job.LockRejection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]job.LockRejection, 0)
+	for _, rejection := range s.rejections {
+		if rejection.Job == jobName && !rejection.At.Before(since) {
+			result = append(result, rejection)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *RejectionStorage) DeleteByJobName(jobName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.rejections[:0]
+	for _, rejection := range s.rejections {
+		if rejection.Job != jobName {
+			kept = append(kept, rejection)
+		}
+	}
+	s.rejections = kept
+
+	return nil
+}
+
+func (s *RejectionStorage) DeleteBefore(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.rejections[:0]
+	for _, rejection := range s.rejections {
+		if !rejection.At.Before(before) {
+			kept = append(kept, rejection)
+		}
+	}
+	deleted := len(s.rejections) - len(kept)
+	s.rejections = kept
+
+	return deleted, nil
+}
@@ -0,0 +1,36 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/job/storagetest"
+	"github.com/antgubarev/jobs/internal/memory"
+)
+
+func TestJobStorage(t *testing.T) {
+	t.Parallel()
+	storagetest.TestStorage(t, func(t *testing.T) job.Storage {
+		t.Helper()
+
+		return memory.NewJobStorage()
+	})
+}
+
+func TestExecutionStorage(t *testing.T) {
+	t.Parallel()
+	storagetest.TestExecutionStorage(t, func(t *testing.T) job.ExecutionStorage {
+		t.Helper()
+
+		return memory.NewExecutionStorage()
+	})
+}
+
+func TestRejectionStorage(t *testing.T) {
+	t.Parallel()
+	storagetest.TestRejectionStorage(t, func(t *testing.T) job.RejectionStorage {
+		t.Helper()
+
+		return memory.NewRejectionStorage()
+	})
+}
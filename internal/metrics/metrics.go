@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+)
+
+var (
+	PruneRuns        = expvar.NewInt("prune_runs_total")
+	PruneErrors      = expvar.NewInt("prune_errors_total")
+	PrunedExecutions = expvar.NewInt("pruned_executions_total")
+	PrunedRejections = expvar.NewInt("pruned_rejections_total")
+	LostExecutions   = expvar.NewInt("lost_executions_total")
+	// OverdueJobs is the number of jobs overdue at the latest check of the watchdog.
+	OverdueJobs       = expvar.NewInt("overdue_jobs")
+	OverdueDetections = expvar.NewInt("overdue_detections_total")
+)
+
+func Handler() http.Handler {
+	return expvar.Handler()
+}
@@ -0,0 +1,138 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/antgubarev/jobs/internal/audit"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+	"github.com/r3labs/diff/v2"
+)
+
+const (
+	// ActorKey is the gin context key of the authenticated request actor.
+	ActorKey    = "actor"
+	ActorHeader = "X-Jobs-Actor"
+
+	auditActionKey = "audit.action"
+	auditTargetKey = "audit.target"
+	auditBeforeKey = "audit.before"
+	auditAfterKey  = "audit.after"
+
+	anonymousActor = "anonymous"
+)
+
+// AuditLog writes the audit record after the handler of mutating request is finished.
+func AuditLog(storage audit.Storage, action string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		record := audit.NewRecord(action)
+		if overridden := ctx.GetString(auditActionKey); overridden != "" {
+			record.Action = overridden
+		}
+		record.Actor = requestActor(ctx)
+		record.SourceIP = ctx.ClientIP()
+		record.Target = auditTarget(ctx)
+		record.Status = ctx.Writer.Status()
+		record.Result = audit.ResultOK
+		if record.Status >= http.StatusBadRequest {
+			record.Result = audit.ResultError
+		}
+
+		changes, err := auditChanges(ctx)
+		if err != nil {
+			glog.Errorf("audit %s: %v", record.Action, err)
+		}
+		record.Changes = changes
+
+		if err := storage.Append(record); err != nil {
+			glog.Errorf("audit %s: %v", record.Action, err)
+		}
+	}
+}
+
+func requestActor(ctx *gin.Context) string {
+	if actor := ctx.GetString(ActorKey); actor != "" {
+		return actor
+	}
+	if actor := ctx.GetHeader(ActorHeader); actor != "" {
+		return actor
+	}
+
+	return anonymousActor
+}
+
+func auditTarget(ctx *gin.Context) string {
+	if target := ctx.GetString(auditTargetKey); target != "" {
+		return target
+	}
+	if name := ctx.Param("name"); name != "" {
+		return name
+	}
+
+	return ctx.Param("id")
+}
+
+func setAuditAction(ctx *gin.Context, action string) {
+	ctx.Set(auditActionKey, action)
+}
+
+func setAuditTarget(ctx *gin.Context, target string) {
+	ctx.Set(auditTargetKey, target)
+}
+
+// setAuditBefore and setAuditAfter snapshot the state immediately,
+// so the value may be changed by the handler later.
+func setAuditBefore(ctx *gin.Context, value interface{}) {
+	setAuditState(ctx, auditBeforeKey, value)
+}
+
+func setAuditAfter(ctx *gin.Context, value interface{}) {
+	setAuditState(ctx, auditAfterKey, value)
+}
+
+func setAuditState(ctx *gin.Context, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		glog.Errorf("audit state: %v", err)
+
+		return
+	}
+	var state map[string]interface{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		glog.Errorf("audit state: %v", err)
+
+		return
+	}
+	ctx.Set(key, state)
+}
+
+func auditChanges(ctx *gin.Context) ([]audit.Change, error) {
+	before, _ := ctx.Get(auditBeforeKey)
+	after, _ := ctx.Get(auditAfterKey)
+	beforeState, _ := before.(map[string]interface{})
+	afterState, _ := after.(map[string]interface{})
+
+	changes := []audit.Change{}
+	changelog, err := diff.Diff(beforeState, afterState)
+	if err != nil {
+		return changes, fmt.Errorf("diff: %w", err)
+	}
+	for _, change := range changelog {
+		changes = append(changes, audit.Change{
+			Path: strings.Join(change.Path, "."),
+			From: change.From,
+			To:   change.To,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes, nil
+}
@@ -0,0 +1,65 @@
+package restapi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/audit"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultAuditLimit = 100
+
+type AuditHandler struct {
+	storage audit.Storage
+}
+
+func NewAuditHandler(storage audit.Storage) *AuditHandler {
+	return &AuditHandler{storage: storage}
+}
+
+func (ah *AuditHandler) ListHandle(ctx *gin.Context) {
+	filter := audit.Filter{
+		Actor:  ctx.Query("actor"),
+		Action: ctx.Query("action"),
+		Target: ctx.Query("target"),
+		Limit:  defaultAuditLimit,
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 0 {
+			writeBadRequestResponse(ctx, "invalid limit")
+
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	for param, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := ctx.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeBadRequestResponse(ctx, "invalid "+param+", RFC3339 expected")
+
+			return
+		}
+		*target = &parsed
+	}
+
+	records, err := ah.storage.Find(filter)
+	if err != nil {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+	if records == nil {
+		records = []audit.Record{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"records": records})
+}
@@ -0,0 +1,139 @@
+package restapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/audit"
+	auditMocks "github.com/antgubarev/jobs/internal/audit/mocks"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/job/mocks"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditLog(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name       string
+		request    string
+		jobStorage func() *mocks.JobStorage
+		record     func(record *audit.Record) bool
+	}{
+		{
+			name:    "pause job",
+			request: "/job/my-job/pause",
+			jobStorage: func() *mocks.JobStorage {
+				jobStorageMock := &mocks.JobStorage{}
+				jobStorageMock.On("GetByName", "my-job").Return(job.NewJob("my-job"), nil).Once()
+				jobStorageMock.On("Store", mock.Anything).Return(nil).Once()
+
+				return jobStorageMock
+			},
+			record: func(record *audit.Record) bool {
+				return record.Action == "job.pause" &&
+					record.Actor == "admin" &&
+					record.Target == "my-job" &&
+					record.Result == audit.ResultOK &&
+					record.Status == http.StatusOK &&
+					len(record.Changes) == 1 &&
+					record.Changes[0].Path == "status" &&
+					record.Changes[0].From == job.JobStatusActive &&
+					record.Changes[0].To == job.JobStatusPaused
+			},
+		},
+		{
+			name:    "job not found",
+			request: "/job/my-job/start",
+			jobStorage: func() *mocks.JobStorage {
+				jobStorageMock := &mocks.JobStorage{}
+				jobStorageMock.On("GetByName", "my-job").Return(nil, nil).Once()
+
+				return jobStorageMock
+			},
+			record: func(record *audit.Record) bool {
+				return record.Action == "job.start" &&
+					record.Result == audit.ResultError &&
+					record.Status == http.StatusNotFound &&
+					len(record.Changes) == 0
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			jobStorageMock := testCase.jobStorage()
+			auditStorageMock := &auditMocks.Storage{}
+			auditStorageMock.On("Append", mock.MatchedBy(testCase.record)).Return(nil).Once()
+
+			testRouter := internal.NewTestRouter()
+			testRouter.POST("/job/:name/:action", restapi.AuditLog(auditStorageMock, "job.status"),
+				restapi.NewJobStatusHandler(jobStorageMock).Action)
+
+			testWriter := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", testCase.request, nil)
+			req.Header.Set(restapi.ActorHeader, "admin")
+			testRouter.ServeHTTP(testWriter, req)
+
+			jobStorageMock.AssertExpectations(t)
+			auditStorageMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuditList(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name    string
+		request string
+		storage func() *auditMocks.Storage
+		status  int
+	}{
+		{
+			name:    "with filter",
+			request: "/audit?actor=admin&action=job.pause&since=2021-11-22T11:22:26Z&limit=10",
+			storage: func() *auditMocks.Storage {
+				storage := &auditMocks.Storage{}
+				storage.On("Find", mock.MatchedBy(func(filter audit.Filter) bool {
+					return filter.Actor == "admin" &&
+						filter.Action == "job.pause" &&
+						filter.Since != nil && filter.Until == nil &&
+						filter.Limit == 10
+				})).Return([]audit.Record{*audit.NewRecord("job.pause")}, nil).Once()
+
+				return storage
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:    "invalid since",
+			request: "/audit?since=yesterday",
+			storage: func() *auditMocks.Storage {
+				return &auditMocks.Storage{}
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			storage := testCase.storage()
+			testRouter := internal.NewTestRouter()
+			testRouter.GET("/audit", restapi.NewAuditHandler(storage).ListHandle)
+
+			testWriter := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", testCase.request, nil)
+			testRouter.ServeHTTP(testWriter, req)
+
+			assert.Equal(t, testCase.status, testWriter.Code, "%s", testWriter.Body.Bytes())
+			storage.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package restapi
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+)
+
+const bearerPrefix = "Bearer "
+
+// TokenAuth rejects requests without a known bearer token, tokens maps actor names to tokens.
+// The actor of the token is written to the context by ActorKey.
+func TokenAuth(tokens map[string]string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) {
+			writeUnauthorizedResponse(ctx, "bearer token is required")
+
+			return
+		}
+		token := []byte(strings.TrimPrefix(header, bearerPrefix))
+
+		for actor, known := range tokens {
+			if subtle.ConstantTimeCompare(token, []byte(known)) == 1 {
+				ctx.Set(ActorKey, actor)
+				ctx.Next()
+
+				return
+			}
+		}
+
+		writeUnauthorizedResponse(ctx, "invalid token")
+	}
+}
+
+func writeUnauthorizedResponse(ctx *gin.Context, msg string) {
+	glog.Infof("http unauthorized response: %s", msg)
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": msg})
+}
@@ -0,0 +1,48 @@
+package restapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenAuth(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		authorization string
+		status        int
+		actor         string
+	}{
+		{name: "valid token", authorization: "Bearer secret", status: http.StatusOK, actor: "alice"},
+		{name: "invalid token", authorization: "Bearer wrong", status: http.StatusUnauthorized},
+		{name: "no token", status: http.StatusUnauthorized},
+		{name: "not bearer", authorization: "Basic secret", status: http.StatusUnauthorized},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			router := gin.New()
+			router.Use(restapi.TokenAuth(map[string]string{"alice": "secret"}))
+			router.GET("/jobs", func(ctx *gin.Context) {
+				assert.Equal(t, testCase.actor, ctx.GetString(restapi.ActorKey))
+				ctx.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+			if testCase.authorization != "" {
+				req.Header.Set("Authorization", testCase.authorization)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, testCase.status, resp.Code)
+		})
+	}
+}
@@ -3,21 +3,41 @@ package restapi
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"strconv"
 	"time"
 
+	"github.com/antgubarev/jobs/internal/audit"
+	"github.com/antgubarev/jobs/internal/host"
 	"github.com/antgubarev/jobs/internal/job"
 	"github.com/google/uuid"
 )
 
 type CreateJobIn struct {
-	Name     string `json:"name" binding:"required"`
-	LockMode string `json:"lockMode" binding:"omitempty,oneof=free host cluster"`
-	Status   string `json:"status" binding:"omitempty,oneof=active paused"`
+	Name      string               `json:"name" binding:"required"`
+	LockMode  string               `json:"lockMode" binding:"omitempty,oneof=free host cluster label"`
+	LockLabel string               `json:"lockLabel,omitempty"`
+	Status    string               `json:"status" binding:"omitempty,oneof=active paused"`
+	Retention *job.RetentionPolicy `json:"retention,omitempty"`
+	Sandbox   *job.Sandbox         `json:"sandbox,omitempty"`
+	Env       map[string]string    `json:"env,omitempty"`
+	Params    []job.Param          `json:"params,omitempty"`
+	// HostSelector restricts hosts where the job may run.
+	HostSelector job.HostSelector `json:"hostSelector,omitempty"`
+	// Resources are names of shared resources held by executions of the job.
+	Resources []string `json:"resources,omitempty"`
+	// Labels of the job, jobs are selected by them in jobsctl.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Cadence is the expected frequency of runs, the server flags the job as overdue if it's missed.
+	Cadence *job.Cadence `json:"cadence,omitempty"`
 }
 
 type JobStartIn struct {
@@ -26,35 +46,176 @@ type JobStartIn struct {
 	Command   *string    `json:"command"`
 	Pid       *int       `json:"pid"`
 	Host      *string    `json:"host"`
+	// Wait is how long the request waits for the lock in the queue, WaiterID keeps the place between requests.
+	Wait     job.Duration `json:"wait,omitempty"`
+	WaiterID *uuid.UUID   `json:"waiterId,omitempty"`
+	// Params override defaults of the job's params.
+	Params map[string]string `json:"params,omitempty"`
+	// Labels of the host are matched with the job's host selector.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// JobStartOut is the started execution, Sandbox is the job's sandbox to launch the command in,
+// Env and resolved Params are passed to the command's environment.
+type JobStartOut struct {
+	ID      uuid.UUID         `json:"id"`
+	Sandbox *job.Sandbox      `json:"sandbox,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+type JobFinishIn struct {
+	ExitCode *int       `json:"exitCode"`
+	Msg      *string    `json:"msg"`
+	Usage    *job.Usage `json:"usage,omitempty"`
+	// Status overrides the status given by the exit code when the execution is finished manually.
+	Status *job.ExecutionStatus `json:"status,omitempty" binding:"omitempty,oneof=successed failed"`
+}
+
+type HostRegisterIn struct {
+	Name         string            `json:"name" binding:"required"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	AgentVersion string            `json:"agentVersion,omitempty"`
+	Capacity     int               `json:"capacity,omitempty"`
+}
+
+// ErrLocked and ErrUnavailable let callers tell "job is already running" from "server can't be reached".
+var (
+	ErrLocked      = errors.New("locked")
+	ErrUnavailable = errors.New("server unavailable")
+	// ErrHostMismatch means the host isn't selected by the job's host selector.
+	ErrHostMismatch = errors.New("host mismatch")
+	ErrJobNotFound  = errors.New("job not found")
+	// ErrStatusUnchanged means the job is already paused or active.
+	ErrStatusUnchanged   = errors.New("status unchanged")
+	ErrExecutionNotFound = errors.New("execution not found")
+	ErrNotRunning        = errors.New("execution is not running")
+)
+
+// LockWaitError is returned when the start has waited for the lock in the queue, but hasn't got it.
+// The start can be repeated with the waiter id to keep the place in the queue.
+type LockWaitError struct {
+	Waiter job.Waiter
+}
+
+func (e *LockWaitError) Error() string {
+	return fmt.Sprintf("%v: waiter %s, position %d", ErrLocked, e.Waiter.ID, e.Waiter.Position)
+}
+
+func (e *LockWaitError) Unwrap() error {
+	return ErrLocked
 }
 
 var (
 	errWrongResponse       = errors.New("wrong response")
-	errJobNotFound         = errors.New("job not found")
 	errInternalServerError = errors.New("internal server error")
-	errLocked              = errors.New("locked")
+	errConflict            = errors.New("conflict")
 )
 
 //go:generate mockery --case underscore --name Client
 type Client interface {
 	JobCreate(ctx context.Context, in *CreateJobIn) error
-	JobDelete(ctx context.Context, name string) error
+	JobDelete(ctx context.Context, name string, force bool) error
+	JobPause(ctx context.Context, name string) error
+	JobResume(ctx context.Context, name string) error
 	JobsList(ctx context.Context) ([]job.Job, error)
 	GetJobByName(ctx context.Context, name string) (*job.Job, error)
-	JobStart(ctx context.Context, in *JobStartIn) (uuid.UUID, error)
-	JobFinish(ctx context.Context, id uuid.UUID) error
+	JobStart(ctx context.Context, in *JobStartIn) (*JobStartOut, error)
+	JobFinish(ctx context.Context, id uuid.UUID, in *JobFinishIn) error
+	WaiterLeave(ctx context.Context, id uuid.UUID) error
+	ExecutionsList(ctx context.Context, filter job.ExecutionFilter) ([]job.Execution, error)
+	ExecutionGet(ctx context.Context, id uuid.UUID) (*job.Execution, error)
+	ExecutionStop(ctx context.Context, id uuid.UUID) (*job.Execution, error)
+	Export(ctx context.Context, withHistory bool) (*job.Dump, error)
+	Import(ctx context.Context, dump *job.Dump, strategy job.ConflictStrategy) (*job.ImportResult, error)
+	AuditList(ctx context.Context, filter audit.Filter) ([]audit.Record, error)
+	HostRegister(ctx context.Context, in *HostRegisterIn) (*host.Host, error)
+	HostsList(ctx context.Context) ([]host.Host, error)
+	ResourcesList(ctx context.Context) ([]job.Resource, error)
+	JobStats(ctx context.Context, name string, windows []string) (*job.RunStats, error)
 }
 
 type ClientHTTP struct {
 	baseURL string
 	client  http.Client
+	actor   string
+	token   string
+}
+
+type ClientOption func(*ClientHTTP)
+
+// WithActor sets the actor name which is written to the server audit log.
+func WithActor(actor string) ClientOption {
+	return func(c *ClientHTTP) {
+		c.actor = actor
+	}
 }
 
-func NewClientHTTP(baseURL string) *ClientHTTP {
-	return &ClientHTTP{
+// WithToken sets the bearer token which is required by the server with enabled auth.
+func WithToken(token string) ClientOption {
+	return func(c *ClientHTTP) {
+		c.token = token
+	}
+}
+
+// WithTLSConfig sets the TLS config of connections to the server, nil keeps the default one.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *ClientHTTP) {
+		defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+		if config == nil || !ok {
+			return
+		}
+		transport := defaultTransport.Clone()
+		transport.TLSClientConfig = config
+		c.client.Transport = transport
+	}
+}
+
+func NewClientHTTP(baseURL string, opts ...ClientOption) *ClientHTTP {
+	client := &ClientHTTP{
 		baseURL: baseURL,
 		client:  http.Client{},
 	}
+
+	for _, optFunc := range opts {
+		optFunc(client)
+	}
+
+	return client
+}
+
+// DefaultActor returns `user@host` of the current process.
+func DefaultActor() string {
+	actor := "unknown"
+	if current, err := user.Current(); err == nil {
+		actor = current.Username
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		actor += "@" + hostname
+	}
+
+	return actor
+}
+
+func (c *ClientHTTP) do(req *http.Request) (*http.Response, error) {
+	if c.actor != "" {
+		req.Header.Set(ActorHeader, c.actor)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", bearerPrefix+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		resp.Body.Close()
+
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	return resp, nil
 }
 
 func (c *ClientHTTP) JobCreate(ctx context.Context, in *CreateJobIn) error {
@@ -68,7 +229,7 @@ func (c *ClientHTTP) JobCreate(ctx context.Context, in *CreateJobIn) error {
 		return fmt.Errorf("JobCreate create request %w", err)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("JobCreate send request: %w", err)
 	}
@@ -91,13 +252,18 @@ func (c *ClientHTTP) JobCreate(ctx context.Context, in *CreateJobIn) error {
 	return fmt.Errorf("internal server error: %w", err)
 }
 
-func (c *ClientHTTP) JobDelete(ctx context.Context, name string) error {
-	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/job/"+name, nil)
+// JobDelete deletes the job, force finishes its running executions, otherwise they fail it with ErrLocked.
+func (c *ClientHTTP) JobDelete(ctx context.Context, name string, force bool) error {
+	query := url.Values{}
+	if force {
+		query.Set("force", "true")
+	}
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/job/"+url.PathEscape(name)+"?"+query.Encode(), nil)
 	if err != nil {
 		return fmt.Errorf("create job delete request %w", err)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("send job delete request %w", err)
 	}
@@ -108,7 +274,13 @@ func (c *ClientHTTP) JobDelete(ctx context.Context, name string) error {
 	}
 
 	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("JobDelete: %w", errJobNotFound)
+		return fmt.Errorf("JobDelete: %w", ErrJobNotFound)
+	}
+
+	if resp.StatusCode == http.StatusLocked {
+		msg, _ := parseResponseBodyMsg(resp)
+
+		return fmt.Errorf("JobDelete %w: %s", ErrLocked, msg)
 	}
 
 	if resp.StatusCode == http.StatusInternalServerError {
@@ -118,13 +290,59 @@ func (c *ClientHTTP) JobDelete(ctx context.Context, name string) error {
 	return fmt.Errorf("JobDelete status %d: %w", resp.StatusCode, errWrongResponse)
 }
 
+// JobPause pauses the job, its executions can't be started until it's resumed.
+func (c *ClientHTTP) JobPause(ctx context.Context, name string) error {
+	if err := c.jobStatusAction(ctx, name, "pause"); err != nil {
+		return fmt.Errorf("JobPause %w", err)
+	}
+
+	return nil
+}
+
+// JobResume makes the paused job active.
+func (c *ClientHTTP) JobResume(ctx context.Context, name string) error {
+	if err := c.jobStatusAction(ctx, name, "start"); err != nil {
+		return fmt.Errorf("JobResume %w", err)
+	}
+
+	return nil
+}
+
+func (c *ClientHTTP) jobStatusAction(ctx context.Context, name, action string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/job/"+url.PathEscape(name)+"/"+action, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrJobNotFound
+	case http.StatusBadRequest:
+		msg, _ := parseResponseBodyMsg(resp)
+
+		return fmt.Errorf("%w: %s", ErrStatusUnchanged, msg)
+	case http.StatusInternalServerError:
+		return errInternalServerError
+	default:
+		return fmt.Errorf("status %d: %w", resp.StatusCode, errWrongResponse)
+	}
+}
+
 func (c *ClientHTTP) JobsList(ctx context.Context) ([]job.Job, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/jobs", nil)
 	if err != nil {
 		return nil, fmt.Errorf("JobList create request: %w", err)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("JobList send request: %w", err)
 	}
@@ -156,7 +374,7 @@ func (c *ClientHTTP) GetJobByName(ctx context.Context, name string) (*job.Job, e
 		return nil, fmt.Errorf("GetJobByName create request: %w", err)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("GetJobByName send request: %w", err)
 	}
@@ -176,7 +394,7 @@ func (c *ClientHTTP) GetJobByName(ctx context.Context, name string) (*job.Job, e
 	}
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("GetJobByName %w", errJobNotFound)
+		return nil, fmt.Errorf("GetJobByName %w", ErrJobNotFound)
 	}
 
 	if resp.StatusCode == http.StatusInternalServerError {
@@ -186,61 +404,95 @@ func (c *ClientHTTP) GetJobByName(ctx context.Context, name string) (*job.Job, e
 	return nil, fmt.Errorf("GetJobByName status %d: %w", resp.StatusCode, errWrongResponse)
 }
 
-func (c *ClientHTTP) JobStart(ctx context.Context, in *JobStartIn) (uuid.UUID, error) {
+func (c *ClientHTTP) JobStart(ctx context.Context, in *JobStartIn) (*JobStartOut, error) {
 	inData, err := json.Marshal(in)
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("marshal job start arguments: %w", err)
+		return nil, fmt.Errorf("marshal job start arguments: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/executions", bytes.NewBuffer(inData))
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("JobStart create request: %w", err)
+		return nil, fmt.Errorf("JobStart create request: %w", err)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("JobStart send request: %w", err)
+		return nil, fmt.Errorf("JobStart send request: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusOK {
-		return uuid.UUID{}, nil
+		var out JobStartOut
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, fmt.Errorf("JobStart decode response: %w", err)
+		}
+
+		return &out, nil
 	}
 
 	if resp.StatusCode == http.StatusNotFound {
-		return uuid.UUID{}, fmt.Errorf("JobStart %w", errJobNotFound)
+		return nil, fmt.Errorf("JobStart %w", ErrJobNotFound)
 	}
 
 	if resp.StatusCode == http.StatusLocked {
-		return uuid.UUID{}, fmt.Errorf("JobStart %w", errLocked)
+		response := struct {
+			Waiter *job.Waiter `json:"waiter"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err == nil && response.Waiter != nil {
+			return nil, fmt.Errorf("JobStart %w", &LockWaitError{Waiter: *response.Waiter})
+		}
+
+		return nil, fmt.Errorf("JobStart %w", ErrLocked)
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		// The error is kept distinct even without the message.
+		msg, _ := parseResponseBodyMsg(resp)
+
+		return nil, fmt.Errorf("JobStart %w: %s", ErrHostMismatch, msg)
 	}
 
 	if resp.StatusCode == http.StatusBadRequest {
-		msg, err := parseResponseBodyErr(resp)
+		msg, err := parseResponseBodyMsg(resp)
 		if err != nil {
-			return uuid.UUID{}, err
+			return nil, err
 		}
 
-		return uuid.UUID{}, fmt.Errorf("JobStart %w: %s", errWrongResponse, msg)
+		return nil, fmt.Errorf("JobStart %w: %s", errWrongResponse, msg)
 	}
 
-	return uuid.UUID{}, fmt.Errorf("JobStart code %d: %w", resp.StatusCode, errWrongResponse)
+	return nil, fmt.Errorf("JobStart code %d: %w", resp.StatusCode, errWrongResponse)
 }
 
-func (c *ClientHTTP) JobFinish(ctx context.Context, id uuid.UUID) error {
-	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/executions/"+id.String(), nil)
+func (c *ClientHTTP) JobFinish(ctx context.Context, id uuid.UUID, in *JobFinishIn) error {
+	inData, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("marshal job finish arguments: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/execution/"+id.String(), bytes.NewBuffer(inData))
 	if err != nil {
 		return fmt.Errorf("JobFinish create request: %w", err)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("JobFinish send request: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusOK {
 		return nil
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("JobFinish %s: %w", id, ErrExecutionNotFound)
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		return fmt.Errorf("JobFinish %s: %w", id, ErrNotRunning)
+	}
+
 	if resp.StatusCode == http.StatusInternalServerError {
 		msg, err := parseResponseBodyErr(resp)
 		if err != nil {
@@ -250,7 +502,166 @@ func (c *ClientHTTP) JobFinish(ctx context.Context, id uuid.UUID) error {
 		return fmt.Errorf("JobFinish %w: %s", errInternalServerError, msg)
 	}
 
-	return nil
+	return fmt.Errorf("JobFinish code %d: %w", resp.StatusCode, errWrongResponse)
+}
+
+// WaiterLeave removes the start from the lock queue, unknown waiter isn't an error.
+func (c *ClientHTTP) WaiterLeave(ctx context.Context, id uuid.UUID) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/waiter/"+id.String(), nil)
+	if err != nil {
+		return fmt.Errorf("WaiterLeave create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("WaiterLeave send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	return fmt.Errorf("WaiterLeave code %d: %w", resp.StatusCode, errWrongResponse)
+}
+
+func (c *ClientHTTP) Export(ctx context.Context, withHistory bool) (*job.Dump, error) {
+	exportURL := c.baseURL + "/export"
+	if withHistory {
+		exportURL += "?history=true"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", exportURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Export create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Export send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Export status %d: %w", resp.StatusCode, errWrongResponse)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Export parse response body: %w", err)
+	}
+	dump := &job.Dump{}
+	if err := json.Unmarshal(body, dump); err != nil {
+		return nil, fmt.Errorf("Export unmarshal response %w", err)
+	}
+
+	return dump, nil
+}
+
+func (c *ClientHTTP) Import(ctx context.Context, dump *job.Dump, strategy job.ConflictStrategy) (*job.ImportResult, error) {
+	inData, err := json.Marshal(dump)
+	if err != nil {
+		return nil, fmt.Errorf("Import marshal dump: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/import?strategy="+string(strategy), bytes.NewBuffer(inData))
+	if err != nil {
+		return nil, fmt.Errorf("Import create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Import send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("Import parse response body: %w", err)
+		}
+		responseData := struct {
+			Result job.ImportResult `json:"result"`
+		}{}
+		if err := json.Unmarshal(body, &responseData); err != nil {
+			return nil, fmt.Errorf("Import unmarshal response %w", err)
+		}
+
+		return &responseData.Result, nil
+	}
+
+	if resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusBadRequest {
+		msg, err := parseResponseBodyMsg(resp)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusConflict {
+			return nil, fmt.Errorf("Import %w: %s", errConflict, msg)
+		}
+
+		return nil, fmt.Errorf("Import %w: %s", errWrongResponse, msg)
+	}
+
+	if resp.StatusCode == http.StatusInternalServerError {
+		return nil, fmt.Errorf("Import %w", errInternalServerError)
+	}
+
+	return nil, fmt.Errorf("Import status %d: %w", resp.StatusCode, errWrongResponse)
+}
+
+func (c *ClientHTTP) AuditList(ctx context.Context, filter audit.Filter) ([]audit.Record, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{"actor": filter.Actor, "action": filter.Action, "target": filter.Target} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if filter.Since != nil {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		query.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/audit?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("AuditList create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("AuditList send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("AuditList parse response body: %w", err)
+		}
+		responseData := struct {
+			Records []audit.Record `json:"records"`
+		}{}
+		if err := json.Unmarshal(body, &responseData); err != nil {
+			return nil, fmt.Errorf("AuditList unmarshal response %w", err)
+		}
+
+		return responseData.Records, nil
+	}
+
+	if resp.StatusCode == http.StatusBadRequest {
+		msg, err := parseResponseBodyMsg(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("AuditList %w: %s", errWrongResponse, msg)
+	}
+
+	return nil, fmt.Errorf("AuditList status %d: %w", resp.StatusCode, errWrongResponse)
 }
 
 func parseResponseBodyErr(resp *http.Response) (string, error) {
@@ -268,3 +679,246 @@ func parseResponseBodyErr(resp *http.Response) (string, error) {
 
 	return response.Err, nil
 }
+
+func parseResponseBodyMsg(resp *http.Response) (string, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("parseResponseBodyMsg: %w", err)
+	}
+	response := struct {
+		Msg string `json:"msg"`
+	}{Msg: ""}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return "", fmt.Errorf("parseResponseBodyMsg: %w", err)
+	}
+
+	return response.Msg, nil
+}
+
+func (c *ClientHTTP) HostRegister(ctx context.Context, in *HostRegisterIn) (*host.Host, error) {
+	inData, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("HostRegister marshal in: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/host", bytes.NewBuffer(inData))
+	if err != nil {
+		return nil, fmt.Errorf("HostRegister create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HostRegister send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		var registered host.Host
+		if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil {
+			return nil, fmt.Errorf("HostRegister decode response: %w", err)
+		}
+
+		return &registered, nil
+	}
+
+	if resp.StatusCode == http.StatusBadRequest {
+		msg, err := parseResponseBodyErr(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("HostRegister %w: %s", errWrongResponse, msg)
+	}
+
+	return nil, fmt.Errorf("HostRegister code %d: %w", resp.StatusCode, errWrongResponse)
+}
+
+func (c *ClientHTTP) HostsList(ctx context.Context) ([]host.Host, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/hosts", nil)
+	if err != nil {
+		return nil, fmt.Errorf("HostsList create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HostsList send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		responseData := struct {
+			Hosts []host.Host `json:"hosts"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&responseData); err != nil {
+			return nil, fmt.Errorf("HostsList decode response: %w", err)
+		}
+
+		return responseData.Hosts, nil
+	}
+
+	return nil, fmt.Errorf("HostsList code %d: %w", resp.StatusCode, errWrongResponse)
+}
+
+func (c *ClientHTTP) ResourcesList(ctx context.Context) ([]job.Resource, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/resources", nil)
+	if err != nil {
+		return nil, fmt.Errorf("ResourcesList create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ResourcesList send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		responseData := struct {
+			Resources []job.Resource `json:"resources"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&responseData); err != nil {
+			return nil, fmt.Errorf("ResourcesList decode response: %w", err)
+		}
+
+		return responseData.Resources, nil
+	}
+
+	return nil, fmt.Errorf("ResourcesList code %d: %w", resp.StatusCode, errWrongResponse)
+}
+
+// JobStats returns run statistics of the job over windows like `24h`, `7d` or `all`,
+// the server's default windows are used if they are empty.
+func (c *ClientHTTP) JobStats(ctx context.Context, name string, windows []string) (*job.RunStats, error) {
+	query := url.Values{"window": windows}
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		c.baseURL+"/job/"+url.PathEscape(name)+"/stats?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("JobStats create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("JobStats send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		stats := &job.RunStats{}
+		if err := json.NewDecoder(resp.Body).Decode(stats); err != nil {
+			return nil, fmt.Errorf("JobStats decode response: %w", err)
+		}
+
+		return stats, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("JobStats: %w", ErrJobNotFound)
+	case http.StatusBadRequest:
+		msg, _ := parseResponseBodyMsg(resp)
+
+		return nil, fmt.Errorf("JobStats %w: %s", errWrongResponse, msg)
+	}
+
+	return nil, fmt.Errorf("JobStats code %d: %w", resp.StatusCode, errWrongResponse)
+}
+
+func (c *ClientHTTP) ExecutionsList(ctx context.Context, filter job.ExecutionFilter) ([]job.Execution, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{"job": filter.Job, "host": filter.Host, "status": string(filter.Status)} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if filter.Since != nil {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		query.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/executions?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("ExecutionsList create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ExecutionsList send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		responseData := struct {
+			Executions []job.Execution `json:"executions"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&responseData); err != nil {
+			return nil, fmt.Errorf("ExecutionsList decode response: %w", err)
+		}
+
+		return responseData.Executions, nil
+	}
+
+	if resp.StatusCode == http.StatusBadRequest {
+		msg, _ := parseResponseBodyMsg(resp)
+
+		return nil, fmt.Errorf("ExecutionsList %w: %s", errWrongResponse, msg)
+	}
+
+	return nil, fmt.Errorf("ExecutionsList code %d: %w", resp.StatusCode, errWrongResponse)
+}
+
+func (c *ClientHTTP) ExecutionGet(ctx context.Context, id uuid.UUID) (*job.Execution, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/execution/"+id.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("ExecutionGet create request: %w", err)
+	}
+
+	execution, err := c.doExecution(req)
+	if err != nil {
+		return nil, fmt.Errorf("ExecutionGet %w", err)
+	}
+
+	return execution, nil
+}
+
+// ExecutionStop requests stop of the running execution, its executor stops the command.
+func (c *ClientHTTP) ExecutionStop(ctx context.Context, id uuid.UUID) (*job.Execution, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/execution/"+id.String()+"/stop", nil)
+	if err != nil {
+		return nil, fmt.Errorf("ExecutionStop create request: %w", err)
+	}
+
+	execution, err := c.doExecution(req)
+	if err != nil {
+		return nil, fmt.Errorf("ExecutionStop %w", err)
+	}
+
+	return execution, nil
+}
+
+// doExecution sends the request which responds with the execution.
+func (c *ClientHTTP) doExecution(req *http.Request) (*job.Execution, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var execution job.Execution
+		if err := json.NewDecoder(resp.Body).Decode(&execution); err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+
+		return &execution, nil
+	case http.StatusNotFound:
+		return nil, ErrExecutionNotFound
+	case http.StatusConflict:
+		return nil, ErrNotRunning
+	default:
+		return nil, fmt.Errorf("code %d: %w", resp.StatusCode, errWrongResponse)
+	}
+}
@@ -2,12 +2,19 @@ package restapi_test
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/audit"
+	"github.com/antgubarev/jobs/internal/host"
 	"github.com/antgubarev/jobs/internal/job"
 	"github.com/antgubarev/jobs/internal/restapi"
 	"github.com/google/uuid"
@@ -78,7 +85,7 @@ func TestDeleteJob(t *testing.T) {
 	defer ts.Close()
 
 	httpClient := restapi.NewClientHTTP(ts.URL)
-	err := httpClient.JobDelete(context.Background(), "job")
+	err := httpClient.JobDelete(context.Background(), "job", false)
 	assert.NoError(t, err)
 }
 
@@ -90,7 +97,7 @@ func TestDeleteJobNotFound(t *testing.T) {
 	defer ts.Close()
 
 	httpClient := restapi.NewClientHTTP(ts.URL)
-	err := httpClient.JobDelete(context.Background(), "job")
+	err := httpClient.JobDelete(context.Background(), "job", false)
 	assert.Error(t, err)
 }
 
@@ -102,7 +109,7 @@ func TestDeleteJobInternalServerError(t *testing.T) {
 	defer ts.Close()
 
 	httpClient := restapi.NewClientHTTP(ts.URL)
-	err := httpClient.JobDelete(context.Background(), "job")
+	err := httpClient.JobDelete(context.Background(), "job", false)
 	assert.Error(t, err)
 }
 
@@ -114,7 +121,7 @@ func TestDeleteJobUndefinedStatus(t *testing.T) {
 	defer ts.Close()
 
 	httpClient := restapi.NewClientHTTP(ts.URL)
-	err := httpClient.JobDelete(context.Background(), "job")
+	err := httpClient.JobDelete(context.Background(), "job", false)
 	assert.Error(t, err)
 }
 
@@ -164,6 +171,28 @@ func TestGetAllJobs(t *testing.T) {
 	}, jobs)
 }
 
+func TestClientTLSConfig(t *testing.T) {
+	t.Parallel()
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+		_, _ = writer.Write([]byte(`{"jobs": []}`))
+	}))
+	defer testServer.Close()
+
+	_, err := restapi.NewClientHTTP(testServer.URL).JobsList(context.Background())
+	assert.True(t, errors.Is(err, restapi.ErrUnavailable), "unexpected error: %v", err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(testServer.Certificate())
+	httpClient := restapi.NewClientHTTP(testServer.URL, restapi.WithTLSConfig(&tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}))
+	jobs, err := httpClient.JobsList(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
 func TestGetAllJobsUndefinedStatus(t *testing.T) {
 	t.Parallel()
 	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
@@ -242,23 +271,29 @@ func TestJobByNameUndefinedStatus(t *testing.T) {
 
 func TestJobStart(t *testing.T) {
 	t.Parallel()
+	executionID := uuid.New()
 	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
 		writer.WriteHeader(http.StatusOK)
+		if _, err := writer.Write([]byte(`{"id":"` + executionID.String() + `","sandbox":{"workDir":"/srv"}}`)); err != nil {
+			t.Fatal(err)
+		}
 	}))
 	defer ts.Close()
 
 	httpClient := restapi.NewClientHTTP(ts.URL)
-	_, err := httpClient.JobStart(context.Background(), &restapi.JobStartIn{Job: "job"})
+	out, err := httpClient.JobStart(context.Background(), &restapi.JobStartIn{Job: "job"})
 	assert.NoError(t, err, "job start %v", err)
+	assert.Equal(t, executionID, out.ID)
+	assert.Equal(t, &job.Sandbox{WorkDir: "/srv"}, out.Sandbox)
 }
 
 func TestJobStartBadRequest(t *testing.T) {
 	t.Parallel()
 	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		body := struct {
-			Err string `json:"err"`
+			Msg string `json:"msg"`
 		}{
-			Err: "invalid arguments",
+			Msg: "invalid arguments",
 		}
 		writer.WriteHeader(http.StatusBadRequest)
 		bodyData, err := json.Marshal(body)
@@ -274,6 +309,7 @@ func TestJobStartBadRequest(t *testing.T) {
 	httpClient := restapi.NewClientHTTP(testServer.URL)
 	_, err := httpClient.JobStart(context.Background(), &restapi.JobStartIn{Job: "job"})
 	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid arguments")
 }
 
 func jobStartWithResponseCode(t *testing.T, responseCode int) error {
@@ -298,17 +334,354 @@ func TestJobStartNotFound(t *testing.T) {
 func TestJobStartLocked(t *testing.T) {
 	t.Parallel()
 	err := jobStartWithResponseCode(t, http.StatusLocked)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, restapi.ErrLocked)
+}
+
+func TestJobStartHostMismatch(t *testing.T) {
+	t.Parallel()
+	err := jobStartWithResponseCode(t, http.StatusForbidden)
+	assert.ErrorIs(t, err, restapi.ErrHostMismatch)
+}
+
+func TestJobStartUnavailable(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {}))
+	ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	_, err := httpClient.JobStart(context.Background(), &restapi.JobStartIn{Job: "job"})
+	assert.ErrorIs(t, err, restapi.ErrUnavailable)
 }
 
 func TestJobFinish(t *testing.T) {
 	t.Parallel()
+	executionID := uuid.New()
 	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/execution/"+executionID.String(), r.URL.Path)
+		var in restapi.JobFinishIn
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		assert.Equal(t, 3, *in.ExitCode)
 		writer.WriteHeader(http.StatusOK)
 	}))
 	defer ts.Close()
 
 	httpClient := restapi.NewClientHTTP(ts.URL)
-	err := httpClient.JobFinish(context.Background(), uuid.New())
-	assert.NoError(t, err, "job start %v", err)
+	err := httpClient.JobFinish(context.Background(), executionID, &restapi.JobFinishIn{
+		ExitCode: internal.NewPointerOfInt(3),
+	})
+	assert.NoError(t, err, "job finish %v", err)
+}
+
+func TestJobFinishNotRunning(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+		writer.WriteHeader(http.StatusConflict)
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	err := httpClient.JobFinish(context.Background(), uuid.New(), &restapi.JobFinishIn{})
+	assert.ErrorIs(t, err, restapi.ErrNotRunning)
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("history"))
+		writer.WriteHeader(http.StatusOK)
+		if _, err := writer.Write([]byte(`{"jobs":[{"name":"job"}],"executions":[]}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	dump, err := httpClient.Export(context.Background(), true)
+	assert.NoError(t, err)
+	assert.Len(t, dump.Jobs, 1)
+	assert.Equal(t, "job", dump.Jobs[0].Name)
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "skip", r.URL.Query().Get("strategy"))
+		writer.WriteHeader(http.StatusOK)
+		if _, err := writer.Write([]byte(`{"result":{"created":1,"overwritten":0,"skipped":2}}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	result, err := httpClient.Import(context.Background(), &job.Dump{}, job.ConflictSkip)
+	assert.NoError(t, err)
+	assert.Equal(t, &job.ImportResult{Created: 1, Skipped: 2}, result)
+}
+
+func TestImportConflict(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+		writer.WriteHeader(http.StatusConflict)
+		if _, err := writer.Write([]byte(`{"msg":"job already exists"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	_, err := httpClient.Import(context.Background(), &job.Dump{}, job.ConflictFail)
+	assert.Error(t, err)
+}
+
+func TestClientAuditList(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/audit", request.URL.Path)
+		assert.Equal(t, "bob", request.URL.Query().Get("actor"))
+		assert.Equal(t, "job.pause", request.URL.Query().Get("action"))
+		assert.Equal(t, "10", request.URL.Query().Get("limit"))
+		assert.Equal(t, "alice", request.Header.Get(restapi.ActorHeader))
+		if _, err := writer.Write([]byte(`{"records":[{"actor":"bob","action":"job.pause","target":"job"}]}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL, restapi.WithActor("alice"))
+	records, err := httpClient.AuditList(context.Background(), audit.Filter{
+		Actor:  "bob",
+		Action: "job.pause",
+		Limit:  10,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "job", records[0].Target)
+}
+
+func TestClientHostRegister(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/host", request.URL.Path)
+		var in restapi.HostRegisterIn
+		assert.NoError(t, json.NewDecoder(request.Body).Decode(&in))
+		assert.Equal(t, "host-1", in.Name)
+		if _, err := writer.Write([]byte(`{"name":"host-1","labels":{"zone":"a"}}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	registered, err := httpClient.HostRegister(context.Background(), &restapi.HostRegisterIn{
+		Name:   "host-1",
+		Labels: map[string]string{"zone": "a"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", registered.Labels["zone"])
+}
+
+func TestClientHostsList(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/hosts", request.URL.Path)
+		if _, err := writer.Write([]byte(`{"hosts":[{"name":"host-1","status":"unhealthy"}]}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	hosts, err := httpClient.HostsList(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, hosts, 1)
+	assert.Equal(t, host.StatusUnhealthy, hosts[0].Status)
+}
+
+func TestClientResourcesList(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/resources", request.URL.Path)
+		if _, err := writer.Write([]byte(`{"resources":[{"name":"db","capacity":1,"holders":[{"job":"db-backup"}]}]}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	resources, err := httpClient.ResourcesList(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, resources, 1)
+	assert.Equal(t, "db-backup", resources[0].Holders[0].Job)
+}
+
+func TestDeleteJobLocked(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Query().Get("force") == "true" {
+			writer.WriteHeader(http.StatusOK)
+
+			return
+		}
+		writer.WriteHeader(http.StatusLocked)
+		if _, err := writer.Write([]byte(`{"msg":"stop all job's execution and try again"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	assert.ErrorIs(t, httpClient.JobDelete(context.Background(), "job", false), restapi.ErrLocked)
+	assert.NoError(t, httpClient.JobDelete(context.Background(), "job", true))
+}
+
+func TestClientJobPauseResume(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name   string
+		status int
+		err    error
+	}{
+		{name: "changed", status: http.StatusOK},
+		{name: "not found", status: http.StatusNotFound, err: restapi.ErrJobNotFound},
+		{name: "unchanged", status: http.StatusBadRequest, err: restapi.ErrStatusUnchanged},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			var paths []string
+			ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				paths = append(paths, request.Method+" "+request.URL.Path)
+				writer.WriteHeader(testCase.status)
+			}))
+			defer ts.Close()
+
+			httpClient := restapi.NewClientHTTP(ts.URL)
+			pauseErr := httpClient.JobPause(context.Background(), "job")
+			resumeErr := httpClient.JobResume(context.Background(), "job")
+			if testCase.err == nil {
+				assert.NoError(t, pauseErr)
+				assert.NoError(t, resumeErr)
+			} else {
+				assert.ErrorIs(t, pauseErr, testCase.err)
+				assert.ErrorIs(t, resumeErr, testCase.err)
+			}
+			assert.Equal(t, []string{"POST /job/job/pause", "POST /job/job/start"}, paths)
+		})
+	}
+}
+
+func TestClientExecutionsList(t *testing.T) {
+	t.Parallel()
+	since := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/executions", request.URL.Path)
+		assert.Equal(t, "host=host-1&job=job&limit=10&since=2021-01-02T03%3A04%3A05Z&status=failed",
+			request.URL.RawQuery)
+		if _, err := writer.Write([]byte(`{"executions":[{"job":"job","status":"failed"}]}`)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	httpClient := restapi.NewClientHTTP(ts.URL)
+	executions, err := httpClient.ExecutionsList(context.Background(), job.ExecutionFilter{
+		Job: "job", Host: "host-1", Status: job.StatusFailed, Since: &since, Limit: 10,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, executions, 1)
+	assert.Equal(t, job.StatusFailed, executions[0].Status)
+}
+
+func TestClientExecutionGetAndStop(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name   string
+		status int
+		body   string
+		err    error
+	}{
+		{name: "ok", status: http.StatusOK, body: `{"job":"job","status":"running"}`},
+		{name: "not found", status: http.StatusNotFound, body: `{"msg":"execution not found"}`,
+			err: restapi.ErrExecutionNotFound},
+		{name: "not running", status: http.StatusConflict, body: `{"msg":"execution is not running"}`,
+			err: restapi.ErrNotRunning},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			id := uuid.New()
+			ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.WriteHeader(testCase.status)
+				if _, err := writer.Write([]byte(testCase.body)); err != nil {
+					t.Fatal(err)
+				}
+			}))
+			defer ts.Close()
+
+			httpClient := restapi.NewClientHTTP(ts.URL)
+			_, stopErr := httpClient.ExecutionStop(context.Background(), id)
+			if testCase.err == nil {
+				assert.NoError(t, stopErr)
+			} else {
+				assert.ErrorIs(t, stopErr, testCase.err)
+			}
+			if testCase.status != http.StatusConflict {
+				execution, getErr := httpClient.ExecutionGet(context.Background(), id)
+				if testCase.err == nil {
+					assert.NoError(t, getErr)
+					assert.Equal(t, "job", execution.Job)
+				} else {
+					assert.ErrorIs(t, getErr, testCase.err)
+				}
+			}
+		})
+	}
+}
+
+func TestClientJobStats(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name   string
+		status int
+		body   string
+		err    bool
+	}{
+		{name: "ok", status: http.StatusOK, body: `{"job":"job","windows":[{"window":"24h0m0s","executions":3}]}`},
+		{name: "not found", status: http.StatusNotFound, body: `{"msg":"job not found"}`, err: true},
+		{name: "bad request", status: http.StatusBadRequest, body: `{"msg":"invalid window"}`, err: true},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				assert.Equal(t, "/job/job/stats", request.URL.Path)
+				assert.Equal(t, "window=24h&window=all", request.URL.RawQuery)
+				writer.WriteHeader(testCase.status)
+				if _, err := writer.Write([]byte(testCase.body)); err != nil {
+					t.Fatal(err)
+				}
+			}))
+			defer ts.Close()
+
+			stats, err := restapi.NewClientHTTP(ts.URL).JobStats(context.Background(), "job", []string{"24h", "all"})
+			if testCase.err {
+				assert.Error(t, err)
+				if testCase.status == http.StatusNotFound {
+					assert.True(t, errors.Is(err, restapi.ErrJobNotFound))
+				}
+
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "job", stats.Job)
+			assert.Equal(t, []job.WindowStats{{Window: job.Duration(24 * time.Hour), Executions: 3}}, stats.Windows)
+		})
+	}
 }
@@ -1,24 +1,44 @@
 package restapi
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/antgubarev/jobs/internal/job"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
 	"github.com/google/uuid"
 )
 
+const (
+	// maxStartWait limits one long-polling start request, the client repeats it with the waiter id.
+	maxStartWait    = time.Minute
+	lockRecheckTime = time.Second
+	// defaultExecutionsLimit is the number of the latest executions in the list without `limit`.
+	defaultExecutionsLimit = 100
+)
+
 type ExecutionHandler struct {
 	jobStorage       job.Storage
 	executionStorage job.ExecutionStorage
 	controller       job.ControllerI
+	waitQueue        *job.WaitQueue
+	rejectionStorage job.RejectionStorage
 }
 
+// NewExecutionHandler creates the handler with the controller of shared resources with default capacities.
 func NewExecutionHandler(jobStorage job.Storage, executionStorage job.ExecutionStorage) *ExecutionHandler {
+	controller := job.NewController(executionStorage)
+	controller.SetResources(job.NewResources(jobStorage, executionStorage, nil))
+
 	return &ExecutionHandler{
 		jobStorage:       jobStorage,
 		executionStorage: executionStorage,
-		controller:       job.NewController(executionStorage),
+		controller:       controller,
+		waitQueue:        job.NewWaitQueue(job.DefaultWaiterGrace),
 	}
 }
 
@@ -26,6 +46,15 @@ func (eh *ExecutionHandler) SetController(controller job.ControllerI) {
 	eh.controller = controller
 }
 
+func (eh *ExecutionHandler) SetWaitQueue(waitQueue *job.WaitQueue) {
+	eh.waitQueue = waitQueue
+}
+
+// SetRejections sets the storage which refused starts of locked jobs are appended to.
+func (eh *ExecutionHandler) SetRejections(storage job.RejectionStorage) {
+	eh.rejectionStorage = storage
+}
+
 func (eh *ExecutionHandler) StartHandle(ctx *gin.Context) {
 	var jobStartIn JobStartIn
 	if err := ctx.ShouldBindJSON(&jobStartIn); err != nil {
@@ -34,6 +63,8 @@ func (eh *ExecutionHandler) StartHandle(ctx *gin.Context) {
 		return
 	}
 
+	setAuditTarget(ctx, jobStartIn.Job)
+
 	testJob, found := eh.findJobByName(ctx, jobStartIn.Job)
 	if !found {
 		writeNotFoundResponse(ctx, "job not found")
@@ -47,24 +78,139 @@ func (eh *ExecutionHandler) StartHandle(ctx *gin.Context) {
 		return
 	}
 
-	execution, err := eh.controller.Start(testJob, job.StartArguments{
-		Command:   jobStartIn.Command,
-		Pid:       jobStartIn.Pid,
-		Host:      jobStartIn.Host,
-		StartedAt: jobStartIn.StartedAt,
-	})
+	params, err := testJob.ResolveParams(jobStartIn.Params)
 	if err != nil {
-		if _, ok := err.(*job.LockedError); ok {
-			ctx.JSON(http.StatusLocked, nil)
+		writeBadRequestResponse(ctx, err.Error())
+
+		return
+	}
+
+	execution, waiter, err := eh.start(ctx, testJob, &jobStartIn, params)
+	if errors.Is(err, job.ErrHostMismatch) {
+		writeForbiddenResponse(ctx, err.Error())
+
+		return
+	}
+	if errors.Is(err, job.ErrLockLabelRequired) {
+		writeBadRequestResponse(ctx, err.Error())
+
+		return
+	}
+	if err != nil {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+	if execution == nil {
+		if waiter != nil {
+			glog.Infof("http locked response: waiter %s position %d", waiter.ID, waiter.Position)
+			ctx.JSON(http.StatusLocked, gin.H{"msg": "job is locked", "waiter": waiter})
 
 			return
 		}
-		writeInternalServerErrorResponse(ctx, err)
+		// The queued start is refused when its waiter leaves, the one gone with the request isn't refused.
+		if ctx.Request.Context().Err() == nil {
+			appendLockRejection(eh.rejectionStorage, testJob.Name)
+		}
+		writeLockResponse(ctx, "job is locked")
 
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"id": execution.ID.String()})
+	setAuditAfter(ctx, execution)
+
+	ctx.JSON(http.StatusOK, JobStartOut{
+		ID:      execution.ID,
+		Sandbox: testJob.Sandbox,
+		Env:     testJob.Env,
+		Params:  execution.Params,
+	})
+}
+
+// start starts the execution. If the job is locked and the request waits, it's queued and polls
+// until it's the first in the queue and the lock is released or wait is over. Nil execution means
+// the job is locked, the waiter is returned if the request is queued.
+func (eh *ExecutionHandler) start(ctx *gin.Context, lJob *job.Job, in *JobStartIn,
+	params map[string]string,
+) (*job.Execution, *job.Waiter, error) {
+	args := job.StartArguments{
+		Command:   in.Command,
+		Pid:       in.Pid,
+		Host:      in.Host,
+		StartedAt: in.StartedAt,
+		Params:    params,
+		Labels:    in.Labels,
+	}
+	scope := job.LockScope(lJob, in.Host, in.Labels)
+	if scope == "" {
+		execution, err := eh.controller.Start(lJob, args)
+
+		return execution, nil, eh.lockedIsNotError(err)
+	}
+
+	wait := time.Duration(in.Wait)
+	if wait > maxStartWait {
+		wait = maxStartWait
+	}
+	waiterID := uuid.Nil
+	if wait > 0 {
+		joined := eh.waitQueue.Join(scope, job.Waiter{Job: lJob.Name, Host: in.Host, Pid: in.Pid, ID: idOrNil(in.WaiterID)})
+		waiterID = joined.ID
+		defer eh.waitQueue.Done(scope, waiterID)
+	}
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	recheck := time.NewTicker(lockRecheckTime)
+	defer recheck.Stop()
+
+	for {
+		changed := eh.waitQueue.Changed(scope)
+		if eh.waitQueue.CanStart(scope, waiterID) {
+			execution, err := eh.controller.Start(lJob, args)
+			if err = eh.lockedIsNotError(err); err != nil {
+				return nil, nil, err
+			}
+			if execution != nil {
+				if waiterID != uuid.Nil {
+					eh.waitQueue.Leave(scope, waiterID)
+				}
+
+				return execution, nil, nil
+			}
+		}
+		if waiterID == uuid.Nil {
+			return nil, nil, nil
+		}
+
+		select {
+		case <-changed:
+		case <-recheck.C:
+		case <-timeout.C:
+			waiter, _ := eh.waitQueue.Get(scope, waiterID)
+
+			return nil, &waiter, nil
+		case <-ctx.Request.Context().Done():
+			return nil, nil, nil
+		}
+	}
+}
+
+func (eh *ExecutionHandler) lockedIsNotError(err error) error {
+	var lockedErr *job.LockedError
+	if err == nil || errors.As(err, &lockedErr) {
+		return nil
+	}
+
+	return err
+}
+
+func idOrNil(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+
+	return *id
 }
 
 func (eh *ExecutionHandler) FinishHandle(ctx *gin.Context) {
@@ -75,27 +221,174 @@ func (eh *ExecutionHandler) FinishHandle(ctx *gin.Context) {
 		return
 	}
 
-	if err := eh.controller.Finish(uid); err != nil {
-		writeInternalServerErrorResponse(ctx, err)
+	// The body is optional, finish without it means success.
+	var jobFinishIn JobFinishIn
+	if ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+		if err := ctx.ShouldBindJSON(&jobFinishIn); err != nil && !errors.Is(err, io.EOF) {
+			writeBadRequestResponse(ctx, err.Error())
+
+			return
+		}
+	}
+
+	if before, err := eh.executionStorage.GetByID(uid); err == nil {
+		setAuditBefore(ctx, before)
+	}
+
+	if err := eh.controller.Finish(uid, job.FinishArguments{
+		ExitCode: jobFinishIn.ExitCode,
+		Msg:      jobFinishIn.Msg,
+		Usage:    jobFinishIn.Usage,
+		Status:   jobFinishIn.Status,
+	}); err != nil {
+		switch {
+		case errors.Is(err, job.ErrExecutionNotFound):
+			writeNotFoundResponse(ctx, "execution not found")
+		case errors.Is(err, job.ErrExecutionNotRunning):
+			writeConflictResponse(ctx, "execution has been finished")
+		default:
+			writeInternalServerErrorResponse(ctx, err)
+		}
 
 		return
 	}
 
+	if after, err := eh.executionStorage.GetByID(uid); err == nil {
+		setAuditAfter(ctx, after)
+		eh.waitQueue.NotifyJob(after.Job)
+		if len(after.Resources) > 0 {
+			eh.waitQueue.NotifyAll()
+		}
+	}
+
 	ctx.JSON(http.StatusOK, nil)
 }
 
-func (eh *ExecutionHandler) findJobByName(ctx *gin.Context, name string) (*job.Job, bool) {
-	job, err := eh.jobStorage.GetByName(name)
+// ListHandle returns executions matched by `job`, `host`, `status`, `since` and `until` (RFC3339), newest first.
+func (eh *ExecutionHandler) ListHandle(ctx *gin.Context) {
+	filter := job.ExecutionFilter{
+		Job:    ctx.Query("job"),
+		Host:   ctx.Query("host"),
+		Status: job.ExecutionStatus(ctx.Query("status")),
+		Limit:  defaultExecutionsLimit,
+	}
+
+	switch filter.Status {
+	case "", job.StatusRunning, job.StatusSuccessed, job.StatusFailed, job.StatusLost:
+	default:
+		writeBadRequestResponse(ctx, "invalid status")
+
+		return
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 0 {
+			writeBadRequestResponse(ctx, "invalid limit")
+
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	for param, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := ctx.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeBadRequestResponse(ctx, "invalid "+param+", RFC3339 expected")
+
+			return
+		}
+		*target = &parsed
+	}
+
+	executions, err := job.FindExecutions(eh.jobStorage, eh.executionStorage, filter)
 	if err != nil {
 		writeInternalServerErrorResponse(ctx, err)
 
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"executions": executions})
+}
+
+func (eh *ExecutionHandler) GetHandle(ctx *gin.Context) {
+	execution, ok := eh.findExecution(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, execution)
+}
+
+// StopHandle requests stop of the running execution. The executor of the execution polls the request,
+// stops the command and finishes the execution.
+func (eh *ExecutionHandler) StopHandle(ctx *gin.Context) {
+	before, ok := eh.findExecution(ctx)
+	if !ok {
+		return
+	}
+	setAuditTarget(ctx, before.Job)
+	setAuditBefore(ctx, before)
+
+	// The execution is read again by the controller, it may be finished meanwhile.
+	execution, err := eh.controller.RequestStop(before.ID, time.Now())
+	switch {
+	case errors.Is(err, job.ErrExecutionNotRunning):
+		writeConflictResponse(ctx, "execution is not running")
+
+		return
+	case errors.Is(err, job.ErrExecutionNotFound):
+		writeNotFoundResponse(ctx, "execution not found")
+
+		return
+	case err != nil:
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+	setAuditAfter(ctx, execution)
+
+	ctx.JSON(http.StatusOK, execution)
+}
+
+// findExecution writes the error response if the execution of `id` param isn't found.
+func (eh *ExecutionHandler) findExecution(ctx *gin.Context) (*job.Execution, bool) {
+	uid, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		writeBadRequestResponse(ctx, "invalid id")
+
 		return nil, false
 	}
-	if job == nil {
-		writeNotFoundResponse(ctx, "job not found")
 
+	execution, err := eh.executionStorage.GetByID(uid)
+	if errors.Is(err, job.ErrExecutionNotFound) {
+		writeNotFoundResponse(ctx, "execution not found")
+
+		return nil, false
+	}
+	if err != nil {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return nil, false
+	}
+
+	return execution, true
+}
+
+func (eh *ExecutionHandler) findJobByName(ctx *gin.Context, name string) (*job.Job, bool) {
+	foundJob, err := eh.jobStorage.GetByName(name)
+	if err != nil && !errors.Is(err, job.ErrJobNotFound) {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return nil, false
+	}
+	if foundJob == nil {
 		return nil, false
 	}
 
-	return job, true
+	return foundJob, true
 }
@@ -2,6 +2,8 @@ package restapi_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,6 +12,7 @@ import (
 	"github.com/antgubarev/jobs/internal"
 	"github.com/antgubarev/jobs/internal/job"
 	"github.com/antgubarev/jobs/internal/job/mocks"
+	"github.com/antgubarev/jobs/internal/memory"
 	"github.com/antgubarev/jobs/internal/restapi"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -73,6 +76,41 @@ func TestStartJob(t *testing.T) {
 			request: "/executions",
 			status:  http.StatusOK,
 		},
+		{
+			name: "params are resolved",
+			jobStorage: func() *mocks.JobStorage {
+				jobStorage := new(mocks.JobStorage)
+				jobToStart := job.NewJob("job")
+				jobToStart.LockMode = job.FreeLockMode
+				jobToStart.Params = []job.Param{{Name: "size", Default: internal.NewPointerOfString("10")}, {Name: "date"}}
+				jobStorage.On("GetByName", "job").Return(jobToStart, nil)
+
+				return jobStorage
+			},
+			controller: func() *mocks.ControllerI {
+				controller := new(mocks.ControllerI)
+				controller.On("Start", mock.Anything, mock.MatchedBy(func(args job.StartArguments) bool {
+					return args.Params["size"] == "10" && args.Params["date"] == "today"
+				})).Return(&job.Execution{}, nil)
+
+				return controller
+			},
+			body:    `{"job":"job","params":{"date":"today"}}`,
+			request: "/executions",
+			status:  http.StatusOK,
+		},
+		{
+			name: "unknown param",
+			jobStorage: func() *mocks.JobStorage {
+				jobStorage := new(mocks.JobStorage)
+				jobStorage.On("GetByName", "job").Return(job.NewJob("job"), nil)
+
+				return jobStorage
+			},
+			body:    `{"job":"job","params":{"date":"today"}}`,
+			request: "/executions",
+			status:  http.StatusBadRequest,
+		},
 		{
 			name: "job is paused",
 			jobStorage: func() *mocks.JobStorage {
@@ -87,6 +125,64 @@ func TestStartJob(t *testing.T) {
 			request: "/executions",
 			status:  http.StatusBadRequest,
 		},
+		{
+			name: "job is locked",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", "job").Return(job.NewJob("job"), nil).Once()
+
+				return mockJobStorage
+			},
+			controller: func() *mocks.ControllerI {
+				controller := new(mocks.ControllerI)
+				controller.On("Start", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("controller start: %w", &job.LockedError{}))
+
+				return controller
+			},
+			body:    `{"job": "job"}`,
+			request: "/executions",
+			status:  http.StatusLocked,
+		},
+		{
+			name: "lock label is missing",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", "job").Return(job.NewJob("job"), nil).Once()
+
+				return mockJobStorage
+			},
+			controller: func() *mocks.ControllerI {
+				controller := new(mocks.ControllerI)
+				controller.On("Start", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("controller start: %w", job.ErrLockLabelRequired))
+
+				return controller
+			},
+			body:    `{"job": "job"}`,
+			request: "/executions",
+			status:  http.StatusBadRequest,
+		},
+		{
+			name: "host mismatch",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", "job").Return(job.NewJob("job"), nil).Once()
+
+				return mockJobStorage
+			},
+			controller: func() *mocks.ControllerI {
+				controller := new(mocks.ControllerI)
+				controller.On("Start", mock.Anything, mock.MatchedBy(func(args job.StartArguments) bool {
+					return args.Labels["zone"] == "eu"
+				})).Return(nil, fmt.Errorf("controller start: %w", job.ErrHostMismatch))
+
+				return controller
+			},
+			body:    `{"job": "job","labels":{"zone":"eu"}}`,
+			request: "/executions",
+			status:  http.StatusForbidden,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -132,10 +228,12 @@ func TestFinishJobNotFound(t *testing.T) {
 	t.Parallel()
 	executionID := uuid.New()
 	controller := new(mocks.ControllerI)
-	controller.On("Finish", executionID).Return(nil)
+	controller.On("Finish", executionID, job.FinishArguments{}).Return(nil)
+	executionStorage := new(mocks.ExecutionStorage)
+	executionStorage.On("GetByID", executionID).Return(job.NewRunningExecution(TestJobName), nil)
 
 	testWriter := httptest.NewRecorder()
-	handler := restapi.NewExecutionHandler(new(mocks.JobStorage), new(mocks.ExecutionStorage))
+	handler := restapi.NewExecutionHandler(new(mocks.JobStorage), executionStorage)
 	handler.SetController(controller)
 	testRouter := internal.NewTestRouter()
 	testRouter.DELETE("/execution/:id", handler.FinishHandle)
@@ -148,3 +246,182 @@ func TestFinishJobNotFound(t *testing.T) {
 	assert.Equal(t, 200, testWriter.Code, "%s", testWriter.Body.Bytes())
 	controller.AssertExpectations(t)
 }
+
+func TestFinishWithExitCode(t *testing.T) {
+	t.Parallel()
+	executionID := uuid.New()
+	controller := new(mocks.ControllerI)
+	controller.On("Finish", executionID, mock.MatchedBy(func(args job.FinishArguments) bool {
+		return args.ExitCode != nil && *args.ExitCode == 143 && *args.Msg == "signal: terminated"
+	})).Return(nil)
+	executionStorage := new(mocks.ExecutionStorage)
+	executionStorage.On("GetByID", executionID).Return(job.NewRunningExecution(TestJobName), nil)
+
+	testWriter := httptest.NewRecorder()
+	handler := restapi.NewExecutionHandler(new(mocks.JobStorage), executionStorage)
+	handler.SetController(controller)
+	testRouter := internal.NewTestRouter()
+	testRouter.DELETE("/execution/:id", handler.FinishHandle)
+
+	req, _ := http.NewRequest("DELETE", "/execution/"+executionID.String(),
+		bytes.NewReader([]byte(`{"exitCode":143,"msg":"signal: terminated"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	testRouter.ServeHTTP(testWriter, req)
+
+	assert.Equal(t, 200, testWriter.Code, "%s", testWriter.Body.Bytes())
+	controller.AssertExpectations(t)
+}
+
+func TestFinishWithStatus(t *testing.T) {
+	t.Parallel()
+	executionID := uuid.New()
+	controller := new(mocks.ControllerI)
+	controller.On("Finish", executionID, mock.MatchedBy(func(args job.FinishArguments) bool {
+		return args.Status != nil && *args.Status == job.StatusSuccessed
+	})).Return(nil)
+	executionStorage := new(mocks.ExecutionStorage)
+	executionStorage.On("GetByID", executionID).Return(job.NewRunningExecution(TestJobName), nil)
+
+	testWriter := httptest.NewRecorder()
+	handler := restapi.NewExecutionHandler(new(mocks.JobStorage), executionStorage)
+	handler.SetController(controller)
+	testRouter := internal.NewTestRouter()
+	testRouter.DELETE("/execution/:id", handler.FinishHandle)
+
+	req, _ := http.NewRequest("DELETE", "/execution/"+executionID.String(),
+		bytes.NewReader([]byte(`{"exitCode":1,"status":"successed"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	testRouter.ServeHTTP(testWriter, req)
+
+	assert.Equal(t, 200, testWriter.Code, "%s", testWriter.Body.Bytes())
+	controller.AssertExpectations(t)
+}
+
+func TestFinishNotRunning(t *testing.T) {
+	t.Parallel()
+	executionStorage := memory.NewExecutionStorage()
+	execution := job.NewRunningExecution(TestJobName)
+	assert.NoError(t, executionStorage.Store(execution))
+
+	handler := restapi.NewExecutionHandler(memory.NewJobStorage(), executionStorage)
+	testRouter := internal.NewTestRouter()
+	testRouter.DELETE("/execution/:id", handler.FinishHandle)
+
+	for _, status := range []int{http.StatusOK, http.StatusConflict} {
+		req, _ := http.NewRequest("DELETE", "/execution/"+execution.ID.String(),
+			bytes.NewReader([]byte(`{"exitCode":1}`)))
+		req.Header.Set("Content-Type", "application/json")
+		testWriter := httptest.NewRecorder()
+		testRouter.ServeHTTP(testWriter, req)
+		assert.Equal(t, status, testWriter.Code, "%s", testWriter.Body.Bytes())
+	}
+
+	finished, err := executionStorage.GetByID(execution.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, job.StatusFailed, finished.Status)
+}
+
+func TestExecutionsList(t *testing.T) {
+	t.Parallel()
+	jobStorage, executionStorage := memory.NewJobStorage(), memory.NewExecutionStorage()
+	assert.NoError(t, jobStorage.Store(job.NewJob(TestJobName)))
+	for _, host := range []string{"host-1", "host-2"} {
+		execution := job.NewRunningExecution(TestJobName)
+		execution.SetHost(host)
+		assert.NoError(t, executionStorage.Store(execution))
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		status   int
+		expected int
+	}{
+		{name: "all", query: "", status: http.StatusOK, expected: 2},
+		{name: "host", query: "?host=host-1", status: http.StatusOK, expected: 1},
+		{name: "job and status", query: "?job=" + TestJobName + "&status=running", status: http.StatusOK, expected: 2},
+		{name: "limit", query: "?limit=1", status: http.StatusOK, expected: 1},
+		{name: "since", query: "?since=2100-01-01T00:00:00Z", status: http.StatusOK, expected: 0},
+		{name: "invalid limit", query: "?limit=-1", status: http.StatusBadRequest},
+		{name: "invalid since", query: "?since=yesterday", status: http.StatusBadRequest},
+		{name: "invalid status", query: "?status=done", status: http.StatusBadRequest},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			handler := restapi.NewExecutionHandler(jobStorage, executionStorage)
+			testRouter := internal.NewTestRouter()
+			testRouter.GET("/executions", handler.ListHandle)
+
+			testWriter := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/executions"+testCase.query, nil)
+			testRouter.ServeHTTP(testWriter, req)
+
+			assert.Equal(t, testCase.status, testWriter.Code, "%s", testWriter.Body.Bytes())
+			if testCase.status != http.StatusOK {
+				return
+			}
+			var out struct {
+				Executions []job.Execution `json:"executions"`
+			}
+			assert.NoError(t, json.Unmarshal(testWriter.Body.Bytes(), &out))
+			assert.Len(t, out.Executions, testCase.expected)
+		})
+	}
+}
+
+func TestExecutionGetAndStop(t *testing.T) {
+	t.Parallel()
+	executionStorage := memory.NewExecutionStorage()
+	running := job.NewRunningExecution(TestJobName)
+	assert.NoError(t, executionStorage.Store(running))
+	finished := job.NewRunningExecution(TestJobName)
+	finished.Status = job.StatusSuccessed
+	assert.NoError(t, executionStorage.Store(finished))
+
+	handler := restapi.NewExecutionHandler(memory.NewJobStorage(), executionStorage)
+	testRouter := internal.NewTestRouter()
+	testRouter.GET("/execution/:id", handler.GetHandle)
+	testRouter.POST("/execution/:id/stop", handler.StopHandle)
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{name: "get", method: "GET", path: "/execution/" + running.ID.String(), status: http.StatusOK},
+		{name: "get not found", method: "GET", path: "/execution/" + uuid.NewString(), status: http.StatusNotFound},
+		{name: "get invalid id", method: "GET", path: "/execution/id", status: http.StatusBadRequest},
+		{name: "stop", method: "POST", path: "/execution/" + running.ID.String() + "/stop", status: http.StatusOK},
+		{
+			name:   "stop finished",
+			method: "POST",
+			path:   "/execution/" + finished.ID.String() + "/stop",
+			status: http.StatusConflict,
+		},
+		{
+			name:   "stop not found",
+			method: "POST",
+			path:   "/execution/" + uuid.NewString() + "/stop",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testWriter := httptest.NewRecorder()
+		req, _ := http.NewRequest(testCase.method, testCase.path, nil)
+		testRouter.ServeHTTP(testWriter, req)
+
+		assert.Equal(t, testCase.status, testWriter.Code, "%s: %s", testCase.name, testWriter.Body.Bytes())
+	}
+
+	stopped, err := executionStorage.GetByID(running.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, stopped.StopRequestedAt)
+	assert.Equal(t, job.StatusRunning, stopped.Status)
+}
@@ -0,0 +1,146 @@
+package restapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/memory"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWaitRouter(t *testing.T, rejections job.RejectionStorage) *gin.Engine {
+	t.Helper()
+	jobStorage := memory.NewJobStorage()
+	clusterJob := job.NewJob("job")
+	clusterJob.LockMode = job.ClusterLockMode
+	assert.NoError(t, jobStorage.Store(clusterJob))
+
+	handler := restapi.NewExecutionHandler(jobStorage, memory.NewExecutionStorage())
+	handler.SetRejections(rejections)
+	router := internal.NewTestRouter()
+	router.POST("/executions", handler.StartHandle)
+	router.DELETE("/execution/:id", handler.FinishHandle)
+
+	return router
+}
+
+func startExecution(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/executions", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestStartWaitsForLock(t *testing.T) {
+	t.Parallel()
+	router := newWaitRouter(t, nil)
+
+	holder := startExecution(router, `{"job":"job"}`)
+	assert.Equal(t, http.StatusOK, holder.Code)
+	var started struct {
+		ID string `json:"id"`
+	}
+	assert.NoError(t, json.Unmarshal(holder.Body.Bytes(), &started))
+
+	waited := make(chan *httptest.ResponseRecorder)
+	go func() {
+		waited <- startExecution(router, `{"job":"job","wait":"5s"}`)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// The queued waiter goes first, the start without wait is refused.
+	assert.Equal(t, http.StatusLocked, startExecution(router, `{"job":"job"}`).Code)
+
+	req, _ := http.NewRequest("DELETE", "/execution/"+started.ID, nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case resp := <-waited:
+		assert.Equal(t, http.StatusOK, resp.Code, "%s", resp.Body.Bytes())
+	case <-time.After(3 * time.Second):
+		t.Fatal("waiter hasn't got the lock")
+	}
+}
+
+func TestStartWaitTimeout(t *testing.T) {
+	t.Parallel()
+	router := newWaitRouter(t, nil)
+	assert.Equal(t, http.StatusOK, startExecution(router, `{"job":"job"}`).Code)
+
+	resp := startExecution(router, `{"job":"job","wait":"50ms"}`)
+	assert.Equal(t, http.StatusLocked, resp.Code)
+	var locked struct {
+		Waiter job.Waiter `json:"waiter"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &locked))
+	assert.Equal(t, 1, locked.Waiter.Position)
+
+	// The second waiter queues after the first one, which keeps its place by the id.
+	second := startExecution(router, `{"job":"job","wait":"50ms"}`)
+	assert.NoError(t, json.Unmarshal(second.Body.Bytes(), &locked))
+	assert.Equal(t, 2, locked.Waiter.Position)
+}
+
+func TestStartLockRejections(t *testing.T) {
+	t.Parallel()
+	rejections := memory.NewRejectionStorage()
+	router := newWaitRouter(t, rejections)
+	assert.Equal(t, http.StatusOK, startExecution(router, `{"job":"job"}`).Code)
+
+	// The waiting start isn't refused yet, it's repeated by the client.
+	assert.Equal(t, http.StatusLocked, startExecution(router, `{"job":"job","wait":"50ms"}`).Code)
+	found, err := rejections.Find("job", time.Time{})
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+
+	assert.Equal(t, http.StatusLocked, startExecution(router, `{"job":"job"}`).Code)
+	found, err = rejections.Find("job", time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+}
+
+func TestWaitersList(t *testing.T) {
+	t.Parallel()
+	queue := job.NewWaitQueue(time.Minute)
+	waiter := queue.Join("job", job.Waiter{Job: "job"})
+	queue.Join("other", job.Waiter{Job: "other"})
+
+	router := internal.NewTestRouter()
+	rejections := memory.NewRejectionStorage()
+	handler := restapi.NewWaitersHandler(queue)
+	handler.SetRejections(rejections)
+	router.GET("/waiters", handler.ListHandle)
+	router.DELETE("/waiter/:id", handler.LeaveHandle)
+
+	req, _ := http.NewRequest("GET", "/waiters?job=job", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var list struct {
+		Waiters []job.Waiter `json:"waiters"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	assert.Len(t, list.Waiters, 1)
+	assert.Equal(t, waiter.ID, list.Waiters[0].ID)
+
+	for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+		req, _ = http.NewRequest("DELETE", "/waiter/"+waiter.ID.String(), nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, status, resp.Code)
+	}
+	// The start is refused once the waiter leaves.
+	found, err := rejections.Find("job", time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+}
@@ -2,7 +2,9 @@ package restapi
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/antgubarev/jobs/internal/job"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/glog"
 )
@@ -26,3 +28,23 @@ func writeBadRequestResponse(ctx *gin.Context, msg string) {
 	glog.Infof("bad request: %s", msg)
 	ctx.JSON(http.StatusBadRequest, gin.H{"msg": msg})
 }
+
+func writeConflictResponse(ctx *gin.Context, msg string) {
+	glog.Infof("http conflict response: %s", msg)
+	ctx.JSON(http.StatusConflict, gin.H{"msg": msg})
+}
+
+func writeForbiddenResponse(ctx *gin.Context, msg string) {
+	glog.Infof("http forbidden response: %s", msg)
+	ctx.JSON(http.StatusForbidden, gin.H{"msg": msg})
+}
+
+// appendLockRejection stores the refused start of the locked job for run statistics, failures are only logged.
+func appendLockRejection(storage job.RejectionStorage, jobName string) {
+	if storage == nil {
+		return
+	}
+	if err := storage.Append(job.LockRejection{Job: jobName, At: time.Now()}); err != nil {
+		glog.Errorf("append lock rejection of job %s: %v", jobName, err)
+	}
+}
@@ -0,0 +1,40 @@
+package restapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/antgubarev/jobs/internal/host"
+	"github.com/gin-gonic/gin"
+)
+
+type HostHandler struct {
+	registry *host.Registry
+}
+
+func NewHostHandler(registry *host.Registry) *HostHandler {
+	return &HostHandler{registry: registry}
+}
+
+// RegisterHandle registers the host of the agent, agents repeat it periodically as heartbeats.
+func (hh *HostHandler) RegisterHandle(ctx *gin.Context) {
+	var in HostRegisterIn
+	if err := ctx.ShouldBindJSON(&in); err != nil {
+		writeBadRequestResponse(ctx, err.Error())
+
+		return
+	}
+
+	registered := hh.registry.Register(host.Host{
+		Name:         in.Name,
+		Labels:       in.Labels,
+		AgentVersion: in.AgentVersion,
+		Capacity:     in.Capacity,
+	}, time.Now())
+
+	ctx.JSON(http.StatusOK, registered)
+}
+
+func (hh *HostHandler) ListHandle(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"hosts": hh.registry.List()})
+}
@@ -0,0 +1,79 @@
+package restapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/host"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostRegister(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name string
+		body string
+		code int
+	}{
+		{name: "registered", body: `{"name":"host-1","labels":{"zone":"a"},"agentVersion":"1.0"}`, code: http.StatusOK},
+		{name: "name is required", body: `{"labels":{"zone":"a"}}`, code: http.StatusBadRequest},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			registry := host.NewRegistry()
+			testRouter := internal.NewTestRouter()
+			testRouter.POST("/host", restapi.NewHostHandler(registry).RegisterHandle)
+
+			testWriter := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/host", bytes.NewBufferString(testCase.body))
+			req.Header.Set("Content-Type", "application/json")
+			testRouter.ServeHTTP(testWriter, req)
+
+			assert.Equal(t, testCase.code, testWriter.Code, "%s", testWriter.Body.Bytes())
+			if testCase.code != http.StatusOK {
+				assert.Empty(t, registry.List())
+
+				return
+			}
+
+			var registered host.Host
+			assert.NoError(t, json.Unmarshal(testWriter.Body.Bytes(), &registered))
+			assert.Equal(t, "host-1", registered.Name)
+			assert.Equal(t, "1.0", registered.AgentVersion)
+			assert.False(t, registered.LastSeen.IsZero())
+			assert.Len(t, registry.List(), 1)
+		})
+	}
+}
+
+func TestHostsList(t *testing.T) {
+	t.Parallel()
+	registry := host.NewRegistry()
+	registry.Register(host.Host{Name: "host-2"}, time.Now())
+	registry.Register(host.Host{Name: "host-1", Capacity: 4}, time.Now())
+	testRouter := internal.NewTestRouter()
+	testRouter.GET("/hosts", restapi.NewHostHandler(registry).ListHandle)
+
+	testWriter := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/hosts", nil)
+	testRouter.ServeHTTP(testWriter, req)
+
+	assert.Equal(t, http.StatusOK, testWriter.Code, "%s", testWriter.Body.Bytes())
+	var out struct {
+		Hosts []host.Host `json:"hosts"`
+	}
+	assert.NoError(t, json.Unmarshal(testWriter.Body.Bytes(), &out))
+	assert.Len(t, out.Hosts, 2)
+	assert.Equal(t, "host-1", out.Hosts[0].Name)
+	assert.Equal(t, 4, out.Hosts[0].Capacity)
+	assert.Equal(t, host.StatusHealthy, out.Hosts[0].Status)
+}
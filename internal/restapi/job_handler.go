@@ -4,22 +4,32 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/antgubarev/jobs/internal/boltdb"
 	"github.com/antgubarev/jobs/internal/job"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/glog"
 )
 
+// maxStatsWindows limits windows of one stats request.
+const maxStatsWindows = 10
+
 type JobHandler struct {
 	jobStorage       job.Storage
 	executuonStorage job.ExecutionStorage
+	rejectionStorage job.RejectionStorage
 }
 
 func NewJobHandler(jobStorage job.Storage, executionStorage job.ExecutionStorage) *JobHandler {
 	return &JobHandler{jobStorage: jobStorage, executuonStorage: executionStorage}
 }
 
+// SetRejections sets the storage of lock rejections of stats, they are zero without it.
+func (jh *JobHandler) SetRejections(storage job.RejectionStorage) {
+	jh.rejectionStorage = storage
+}
+
 func (jh *JobHandler) CreateHandle(ctx *gin.Context) {
 	var createJobIn CreateJobIn
 	if err := ctx.ShouldBindJSON(&createJobIn); err != nil {
@@ -28,8 +38,18 @@ func (jh *JobHandler) CreateHandle(ctx *gin.Context) {
 		return
 	}
 
+	setAuditTarget(ctx, createJobIn.Name)
+
+	if createJobIn.Sandbox != nil {
+		if err := createJobIn.Sandbox.Validate(); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+
+			return
+		}
+	}
+
 	existJob, err := jh.jobStorage.GetByName(createJobIn.Name)
-	if err != nil {
+	if err != nil && !errors.Is(err, job.ErrJobNotFound) {
 		glog.Errorf("CreateHandle: %v", err)
 		ctx.JSON(http.StatusInternalServerError, nil)
 
@@ -48,6 +68,20 @@ func (jh *JobHandler) CreateHandle(ctx *gin.Context) {
 	if createJobIn.LockMode != "" {
 		testJob.LockMode = job.LockMode(createJobIn.LockMode)
 	}
+	testJob.LockLabel = createJobIn.LockLabel
+	testJob.Retention = createJobIn.Retention
+	testJob.Sandbox = createJobIn.Sandbox
+	testJob.Env = createJobIn.Env
+	testJob.Params = createJobIn.Params
+	testJob.HostSelector = createJobIn.HostSelector
+	testJob.Labels = createJobIn.Labels
+	testJob.Resources = createJobIn.Resources
+	testJob.Cadence = createJobIn.Cadence
+	if err := testJob.ValidateConfig(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+
+		return
+	}
 
 	if err := jh.jobStorage.Store(testJob); err != nil {
 		glog.Errorf("CreateHandle: %v", err)
@@ -55,18 +89,33 @@ func (jh *JobHandler) CreateHandle(ctx *gin.Context) {
 
 		return
 	}
+	setAuditAfter(ctx, testJob)
 
 	ctx.JSON(http.StatusCreated, nil)
 }
 
+// DeleteHandle deletes the job with its executions. The job with running executions isn't deleted
+// unless `force=true`, executors of deleted running executions stop their commands.
 func (jh *JobHandler) DeleteHandle(ctx *gin.Context) {
+	force := false
+	if value := ctx.Query("force"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeBadRequestResponse(ctx, "force must be a boolean")
+
+			return
+		}
+		force = parsed
+	}
+
 	jobName := ctx.Param("name")
-	_, ok := jh.findJobByName(ctx, jobName)
+	jobToDelete, ok := jh.findJobByName(ctx, jobName)
 	if !ok {
 		writeNotFoundResponse(ctx, "not found")
 
 		return
 	}
+	setAuditBefore(ctx, jobToDelete)
 
 	executuons, err := jh.executuonStorage.GetByJobName(jobName)
 	if err != nil {
@@ -75,16 +124,29 @@ func (jh *JobHandler) DeleteHandle(ctx *gin.Context) {
 		return
 	}
 
-	for _, executuon := range executuons {
-		if executuon.Status == job.StatusRunning {
+	for i := range executuons {
+		if executuons[i].Status == job.StatusRunning && !force {
 			writeLockResponse(ctx, "stop all job's execution and try again")
 
 			return
 		}
 	}
 
+	// Executions and rejections are deleted first, so the failed delete can be repeated.
+	if err := jh.executuonStorage.DeleteByJobName(jobName); err != nil {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+	if jh.rejectionStorage != nil {
+		if err := jh.rejectionStorage.DeleteByJobName(jobName); err != nil {
+			writeInternalServerErrorResponse(ctx, err)
+
+			return
+		}
+	}
 	if err := jh.jobStorage.DeleteByName(jobName); err != nil {
-		ctx.JSON(http.StatusOK, nil)
+		writeInternalServerErrorResponse(ctx, err)
 
 		return
 	}
@@ -92,17 +154,122 @@ func (jh *JobHandler) DeleteHandle(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, nil)
 }
 
+// UsageHandle responds with resource usage aggregated over the latest `last` executions of the job.
+func (jh *JobHandler) UsageHandle(ctx *gin.Context) {
+	last := job.DefaultUsageWindow
+	if value := ctx.Query("last"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			writeBadRequestResponse(ctx, "last must be a non-negative number")
+
+			return
+		}
+		last = parsed
+	}
+
+	jobName := ctx.Param("name")
+	if _, ok := jh.findJobByName(ctx, jobName); !ok {
+		if !ctx.Writer.Written() {
+			writeNotFoundResponse(ctx, "job not found")
+		}
+
+		return
+	}
+
+	executions, err := jh.executuonStorage.GetByJobName(jobName)
+	if err != nil {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, job.AggregateUsage(jobName, executions, last))
+}
+
+// StatsHandle responds with run statistics of the job over `window` query values,
+// e.g. `24h`, `7d` or `all`, default windows are 24h, 7d and 30d.
+func (jh *JobHandler) StatsHandle(ctx *gin.Context) {
+	windows := job.DefaultStatsWindows
+	if values := ctx.QueryArray("window"); len(values) > 0 {
+		if len(values) > maxStatsWindows {
+			writeBadRequestResponse(ctx, fmt.Sprintf("at most %d windows are allowed", maxStatsWindows))
+
+			return
+		}
+		windows = make([]time.Duration, 0, len(values))
+		for _, value := range values {
+			window, err := job.ParseStatsWindow(value)
+			if err != nil {
+				writeBadRequestResponse(ctx, err.Error())
+
+				return
+			}
+			windows = append(windows, window)
+		}
+	}
+
+	jobName := ctx.Param("name")
+	if _, ok := jh.findJobByName(ctx, jobName); !ok {
+		if !ctx.Writer.Written() {
+			writeNotFoundResponse(ctx, "job not found")
+		}
+
+		return
+	}
+
+	executions, err := jh.executuonStorage.GetByJobName(jobName)
+	if err != nil {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+	now := time.Now()
+	rejections, err := jh.lockRejections(jobName, windows, now)
+	if err != nil {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, job.ComputeRunStats(jobName, executions, rejections, windows, now))
+}
+
+// lockRejections returns lock rejections of the job within the longest window.
+func (jh *JobHandler) lockRejections(jobName string, windows []time.Duration, now time.Time) ([]job.LockRejection, error) {
+	if jh.rejectionStorage == nil {
+		return nil, nil
+	}
+	var since time.Time
+	for _, window := range windows {
+		if window == 0 {
+			since = time.Time{}
+
+			break
+		}
+		if windowSince := now.Add(-window); since.IsZero() || windowSince.Before(since) {
+			since = windowSince
+		}
+	}
+
+	rejections, err := jh.rejectionStorage.Find(jobName, since)
+	if err != nil {
+		return nil, fmt.Errorf("lock rejections: %w", err)
+	}
+
+	return rejections, nil
+}
+
 func (jh *JobHandler) findJobByName(ctx *gin.Context, name string) (*job.Job, bool) {
-	job, err := jh.jobStorage.GetByName(name)
-	if err != nil && !errors.Is(err, boltdb.ErrJobNotFound) {
+	foundJob, err := jh.jobStorage.GetByName(name)
+	if err != nil && !errors.Is(err, job.ErrJobNotFound) {
 		glog.Errorf("findJobByName: %v", err)
 		ctx.JSON(http.StatusInternalServerError, nil)
 
 		return nil, false
 	}
-	if job == nil {
+	if foundJob == nil {
 		return nil, false
 	}
 
-	return job, true
+	return foundJob, true
 }
@@ -3,10 +3,13 @@ package restapi_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/antgubarev/jobs/internal"
 	"github.com/antgubarev/jobs/internal/boltdb"
@@ -101,6 +104,131 @@ func TestJobCreate(t *testing.T) {
 			body:    `{"lockMode":"undefined","status":"undefined"}`,
 			status:  http.StatusBadRequest,
 		},
+		{
+			name: "create with sandbox",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("Store", mock.MatchedBy(func(jobModel *job.Job) bool {
+					return jobModel.Sandbox != nil && jobModel.Sandbox.WorkDir == "/srv" &&
+						*jobModel.Sandbox.Limits.OpenFiles == 1024
+				})).Return(nil).Once()
+				mockJobStorage.On("GetByName", TestJobName).Return(nil, nil).Once()
+
+				return mockJobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				return &mocks.ExecutionStorage{}
+			},
+			request: "/job",
+			body:    `{"name":"job","sandbox":{"workDir":"/srv","limits":{"openFiles":1024}}}`,
+			status:  http.StatusCreated,
+		},
+		{
+			name: "invalid sandbox",
+			jobStorage: func() *mocks.JobStorage {
+				return &mocks.JobStorage{}
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				return &mocks.ExecutionStorage{}
+			},
+			request: "/job",
+			body:    `{"name":"job","sandbox":{"nice":42}}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name: "invalid env",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", TestJobName).Return(nil, nil).Once()
+
+				return mockJobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				return &mocks.ExecutionStorage{}
+			},
+			request: "/job",
+			body:    `{"name":"job","env":{"A=B":"1"}}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name: "invalid host selector",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", TestJobName).Return(nil, nil).Once()
+
+				return mockJobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				return &mocks.ExecutionStorage{}
+			},
+			request: "/job",
+			body:    `{"name":"job","hostSelector":{"zone":[]}}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name: "invalid resource",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", TestJobName).Return(nil, nil).Once()
+
+				return mockJobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				return &mocks.ExecutionStorage{}
+			},
+			request: "/job",
+			body:    `{"name":"job","resources":["db lock"]}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name: "create with cadence",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", TestJobName).Return(nil, nil).Once()
+				mockJobStorage.On("Store", mock.MatchedBy(func(jobModel *job.Job) bool {
+					return jobModel.Cadence != nil && jobModel.Cadence.Schedule == "0 3 * * *" &&
+						jobModel.Cadence.Grace == job.Duration(10*time.Minute)
+				})).Return(nil).Once()
+
+				return mockJobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				return &mocks.ExecutionStorage{}
+			},
+			request: "/job",
+			body:    `{"name":"job","cadence":{"schedule":"0 3 * * *","grace":"10m"}}`,
+			status:  http.StatusCreated,
+		},
+		{
+			name: "invalid cadence",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", TestJobName).Return(nil, nil).Once()
+
+				return mockJobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				return &mocks.ExecutionStorage{}
+			},
+			request: "/job",
+			body:    `{"name":"job","cadence":{"schedule":"0 25 * * *"}}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name: "negative retention",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", TestJobName).Return(nil, nil).Once()
+
+				return mockJobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				return &mocks.ExecutionStorage{}
+			},
+			request: "/job",
+			body:    `{"name":"job","retention":{"keepFor":"-1h"}}`,
+			status:  http.StatusBadRequest,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -137,6 +265,7 @@ func TestJobDelete(t *testing.T) {
 	testCases := []struct {
 		caseName             string
 		jobName              string
+		query                string
 		mockJobStorage       func() *mocks.JobStorage
 		mockExecutionStorage func() *mocks.ExecutionStorage
 		responseStatus       int
@@ -164,6 +293,7 @@ func TestJobDelete(t *testing.T) {
 			mockExecutionStorage: func() *mocks.ExecutionStorage {
 				mockExecutionStorage := &mocks.ExecutionStorage{}
 				mockExecutionStorage.On("GetByJobName", TestJobName).Return(nil, nil)
+				mockExecutionStorage.On("DeleteByJobName", TestJobName).Return(nil).Once()
 
 				return mockExecutionStorage
 			},
@@ -221,6 +351,64 @@ func TestJobDelete(t *testing.T) {
 				return &body
 			},
 		},
+		{
+			caseName: "force delete deletes running executions",
+			jobName:  TestJobName,
+			query:    "?force=true",
+			mockJobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", TestJobName).Return(job.NewJob(TestJobName), nil).Once()
+				mockJobStorage.On("DeleteByName", TestJobName).Return(nil).Once()
+
+				return mockJobStorage
+			},
+			mockExecutionStorage: func() *mocks.ExecutionStorage {
+				finished := job.NewRunningExecution(TestJobName)
+				finished.Finish(job.StatusSuccessed, time.Now(), "")
+				mockExecutionStorage := &mocks.ExecutionStorage{}
+				mockExecutionStorage.On("GetByJobName", TestJobName).Return([]job.Execution{
+					*job.NewRunningExecution(TestJobName), *finished,
+				}, nil)
+				mockExecutionStorage.On("DeleteByJobName", TestJobName).Return(nil).Once()
+
+				return mockExecutionStorage
+			},
+			responseStatus: http.StatusOK,
+			responseBody:   func() *string { return nil },
+		},
+		{
+			caseName: "invalid force",
+			jobName:  TestJobName,
+			query:    "?force=yes-please",
+			mockJobStorage: func() *mocks.JobStorage {
+				return &mocks.JobStorage{}
+			},
+			mockExecutionStorage: func() *mocks.ExecutionStorage {
+				return nil
+			},
+			responseStatus: http.StatusBadRequest,
+			responseBody:   func() *string { return nil },
+		},
+		{
+			caseName: "delete error",
+			jobName:  TestJobName,
+			mockJobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetByName", TestJobName).Return(job.NewJob(TestJobName), nil).Once()
+				mockJobStorage.On("DeleteByName", TestJobName).Return(errors.New("disk is full")).Once()
+
+				return mockJobStorage
+			},
+			mockExecutionStorage: func() *mocks.ExecutionStorage {
+				mockExecutionStorage := &mocks.ExecutionStorage{}
+				mockExecutionStorage.On("GetByJobName", TestJobName).Return(nil, nil)
+				mockExecutionStorage.On("DeleteByJobName", TestJobName).Return(nil).Once()
+
+				return mockExecutionStorage
+			},
+			responseStatus: http.StatusInternalServerError,
+			responseBody:   func() *string { return nil },
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -234,7 +422,7 @@ func TestJobDelete(t *testing.T) {
 			testRouter.DELETE("/job/:name", jobHandler.DeleteHandle)
 
 			writer := httptest.NewRecorder()
-			req, err := http.NewRequest("DELETE", "/job/"+testCase.jobName, nil)
+			req, err := http.NewRequest("DELETE", "/job/"+testCase.jobName+testCase.query, nil)
 			if err != nil {
 				t.Fatalf("send request %v", err)
 			}
@@ -254,3 +442,232 @@ func TestJobDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestJobUsage(t *testing.T) {
+	t.Parallel()
+	finished := func(userCPU time.Duration, maxRSS int64) job.Execution {
+		exec := job.NewRunningExecution(TestJobName)
+		exec.Finish(job.StatusSuccessed, time.Now(), "")
+		exec.SetUsage(job.Usage{UserCPU: job.Duration(userCPU), MaxRSS: maxRSS})
+
+		return *exec
+	}
+
+	testCases := []struct {
+		name             string
+		query            string
+		jobStorage       func() *mocks.JobStorage
+		executionStorage func() *mocks.ExecutionStorage
+		status           int
+		body             string
+	}{
+		{
+			name:  "aggregates",
+			query: "?last=10",
+			jobStorage: func() *mocks.JobStorage {
+				jobStorage := &mocks.JobStorage{}
+				jobStorage.On("GetByName", TestJobName).Return(job.NewJob(TestJobName), nil).Once()
+
+				return jobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				executionStorage := &mocks.ExecutionStorage{}
+				executionStorage.On("GetByJobName", TestJobName).Return([]job.Execution{
+					finished(time.Second, 1000),
+					finished(3*time.Second, 3000),
+				}, nil).Once()
+
+				return executionStorage
+			},
+			status: http.StatusOK,
+			body: `{"job":"job","executions":2,"avgUserCpu":"2s","maxUserCpu":"3s","avgSystemCpu":"0s",` +
+				`"maxSystemCpu":"0s","avgMaxRss":2000,"maxMaxRss":3000,"avgInBlock":0,"avgOutBlock":0}`,
+		},
+		{
+			name: "job not found",
+			jobStorage: func() *mocks.JobStorage {
+				jobStorage := &mocks.JobStorage{}
+				jobStorage.On("GetByName", TestJobName).Return(nil, job.ErrJobNotFound).Once()
+
+				return jobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage { return &mocks.ExecutionStorage{} },
+			status:           http.StatusNotFound,
+		},
+		{
+			name:             "invalid last",
+			query:            "?last=-1",
+			jobStorage:       func() *mocks.JobStorage { return &mocks.JobStorage{} },
+			executionStorage: func() *mocks.ExecutionStorage { return &mocks.ExecutionStorage{} },
+			status:           http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			jobStorage, executionStorage := testCase.jobStorage(), testCase.executionStorage()
+			testRouter := internal.NewTestRouter()
+			testRouter.GET("/job/:name/usage", restapi.NewJobHandler(jobStorage, executionStorage).UsageHandle)
+
+			writer := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/job/"+TestJobName+"/usage"+testCase.query, nil)
+			testRouter.ServeHTTP(writer, req)
+
+			assert.Equal(t, testCase.status, writer.Code, "%s", writer.Body.String())
+			if testCase.body != "" {
+				assert.JSONEq(t, testCase.body, writer.Body.String())
+			}
+			jobStorage.AssertExpectations(t)
+			executionStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJobStats(t *testing.T) {
+	t.Parallel()
+	finished := func(status job.ExecutionStatus, duration time.Duration) job.Execution {
+		exec := job.NewRunningExecution(TestJobName)
+		exec.SetStartedAt(time.Now().Add(-time.Hour))
+		exec.Finish(status, exec.StartedAt.Add(duration), "")
+
+		return *exec
+	}
+	rejection := func(age time.Duration) job.LockRejection {
+		return job.LockRejection{Job: TestJobName, At: time.Now().Add(-age)}
+	}
+
+	testCases := []struct {
+		name             string
+		query            string
+		jobStorage       func() *mocks.JobStorage
+		executionStorage func() *mocks.ExecutionStorage
+		rejectionStorage func() *mocks.RejectionStorage
+		status           int
+		windows          []string
+		// executions and rejections are expected in the last window.
+		executions int
+		rejections int
+	}{
+		{
+			name:  "windows",
+			query: "?window=24h&window=all",
+			jobStorage: func() *mocks.JobStorage {
+				jobStorage := &mocks.JobStorage{}
+				jobStorage.On("GetByName", TestJobName).Return(job.NewJob(TestJobName), nil).Once()
+
+				return jobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				executionStorage := &mocks.ExecutionStorage{}
+				executionStorage.On("GetByJobName", TestJobName).Return([]job.Execution{
+					finished(job.StatusSuccessed, time.Second),
+					finished(job.StatusFailed, 3*time.Second),
+				}, nil).Once()
+
+				return executionStorage
+			},
+			rejectionStorage: func() *mocks.RejectionStorage {
+				rejectionStorage := &mocks.RejectionStorage{}
+				rejectionStorage.On("Find", TestJobName, time.Time{}).
+					Return([]job.LockRejection{rejection(time.Minute), rejection(48 * time.Hour)}, nil).Once()
+
+				return rejectionStorage
+			},
+			status:     http.StatusOK,
+			windows:    []string{"24h0m0s", "0s"},
+			executions: 2,
+			rejections: 2,
+		},
+		{
+			name: "default windows",
+			jobStorage: func() *mocks.JobStorage {
+				jobStorage := &mocks.JobStorage{}
+				jobStorage.On("GetByName", TestJobName).Return(job.NewJob(TestJobName), nil).Once()
+
+				return jobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage {
+				executionStorage := &mocks.ExecutionStorage{}
+				executionStorage.On("GetByJobName", TestJobName).Return([]job.Execution{}, nil).Once()
+
+				return executionStorage
+			},
+			rejectionStorage: func() *mocks.RejectionStorage {
+				rejectionStorage := &mocks.RejectionStorage{}
+				rejectionStorage.On("Find", TestJobName, mock.MatchedBy(func(since time.Time) bool {
+					return time.Since(since) >= 30*24*time.Hour && time.Since(since) < 31*24*time.Hour
+				})).Return([]job.LockRejection{rejection(time.Minute)}, nil).Once()
+
+				return rejectionStorage
+			},
+			status:     http.StatusOK,
+			windows:    []string{"24h0m0s", "168h0m0s", "720h0m0s"},
+			rejections: 1,
+		},
+		{
+			name: "job not found",
+			jobStorage: func() *mocks.JobStorage {
+				jobStorage := &mocks.JobStorage{}
+				jobStorage.On("GetByName", TestJobName).Return(nil, job.ErrJobNotFound).Once()
+
+				return jobStorage
+			},
+			executionStorage: func() *mocks.ExecutionStorage { return &mocks.ExecutionStorage{} },
+			rejectionStorage: func() *mocks.RejectionStorage { return &mocks.RejectionStorage{} },
+			status:           http.StatusNotFound,
+		},
+		{
+			name:             "invalid window",
+			query:            "?window=week",
+			jobStorage:       func() *mocks.JobStorage { return &mocks.JobStorage{} },
+			executionStorage: func() *mocks.ExecutionStorage { return &mocks.ExecutionStorage{} },
+			rejectionStorage: func() *mocks.RejectionStorage { return &mocks.RejectionStorage{} },
+			status:           http.StatusBadRequest,
+		},
+		{
+			name:             "too many windows",
+			query:            "?" + strings.Repeat("window=1h&", 11),
+			jobStorage:       func() *mocks.JobStorage { return &mocks.JobStorage{} },
+			executionStorage: func() *mocks.ExecutionStorage { return &mocks.ExecutionStorage{} },
+			rejectionStorage: func() *mocks.RejectionStorage { return &mocks.RejectionStorage{} },
+			status:           http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			jobStorage, executionStorage := testCase.jobStorage(), testCase.executionStorage()
+			rejectionStorage := testCase.rejectionStorage()
+			handler := restapi.NewJobHandler(jobStorage, executionStorage)
+			handler.SetRejections(rejectionStorage)
+			testRouter := internal.NewTestRouter()
+			testRouter.GET("/job/:name/stats", handler.StatsHandle)
+
+			writer := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/job/"+TestJobName+"/stats"+testCase.query, nil)
+			testRouter.ServeHTTP(writer, req)
+
+			assert.Equal(t, testCase.status, writer.Code, "%s", writer.Body.String())
+			if testCase.status == http.StatusOK {
+				var stats job.RunStats
+				assert.NoError(t, json.Unmarshal(writer.Body.Bytes(), &stats))
+				assert.Equal(t, TestJobName, stats.Job)
+				windows := make([]string, 0, len(stats.Windows))
+				for _, window := range stats.Windows {
+					windows = append(windows, time.Duration(window.Window).String())
+				}
+				assert.Equal(t, testCase.windows, windows)
+				last := stats.Windows[len(stats.Windows)-1]
+				assert.Equal(t, testCase.executions, last.Executions)
+				assert.Equal(t, testCase.rejections, last.LockRejections)
+			}
+			jobStorage.AssertExpectations(t)
+			executionStorage.AssertExpectations(t)
+			rejectionStorage.AssertExpectations(t)
+		})
+	}
+}
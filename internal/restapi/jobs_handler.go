@@ -8,13 +8,19 @@ import (
 )
 
 type JobsHandler struct {
-	store job.Storage
+	store    job.Storage
+	watchdog *job.Watchdog
 }
 
 func NewJobsHandler(store job.Storage) *JobsHandler {
 	return &JobsHandler{store: store}
 }
 
+// SetWatchdog sets the watchdog which overdue jobs are flagged by in the list.
+func (jsh *JobsHandler) SetWatchdog(watchdog *job.Watchdog) {
+	jsh.watchdog = watchdog
+}
+
 func (jsh *JobsHandler) ListHandle(ctx *gin.Context) {
 	jobs, err := jsh.store.GetAll()
 	if err != nil {
@@ -22,6 +28,11 @@ func (jsh *JobsHandler) ListHandle(ctx *gin.Context) {
 
 		return
 	}
+	if jsh.watchdog != nil {
+		for i := range jobs {
+			jobs[i].Overdue = jsh.watchdog.Overdue(jobs[i].Name)
+		}
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{"jobs": jobs})
 }
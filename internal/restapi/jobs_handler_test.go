@@ -1,9 +1,11 @@
 package restapi_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/antgubarev/jobs/internal"
 	"github.com/antgubarev/jobs/internal/job"
@@ -11,6 +13,7 @@ import (
 	"github.com/antgubarev/jobs/internal/restapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJobsList(t *testing.T) {
@@ -45,6 +48,43 @@ func TestJobsList(t *testing.T) {
 	mockJobStorage.AssertExpectations(t)
 }
 
+func TestJobsListOverdue(t *testing.T) {
+	t.Parallel()
+	createdAt := time.Now().Add(-2 * time.Hour)
+	watched := job.Job{
+		Name:      "watched",
+		Status:    job.JobStatusActive,
+		CreatedAt: createdAt,
+		Cadence:   &job.Cadence{MaxInterval: job.Duration(time.Hour)},
+	}
+	mockJobStorage := &mocks.JobStorage{}
+	mockJobStorage.On("GetAll").Return([]job.Job{watched, {Name: "unwatched"}}, nil)
+	mockExecutionStorage := &mocks.ExecutionStorage{}
+	mockExecutionStorage.On("GetByJobName", "watched").Return([]job.Execution{}, nil).Once()
+	watchdog := job.NewWatchdog(mockJobStorage, mockExecutionStorage, func(string, *job.Overdue) {})
+	require.NoError(t, watchdog.Check(time.Now()))
+
+	testRouter := internal.NewTestRouter()
+	jobsHandler := restapi.NewJobsHandler(mockJobStorage)
+	jobsHandler.SetWatchdog(watchdog)
+	testRouter.GET("/jobs", jobsHandler.ListHandle)
+
+	testWriter := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jobs", nil)
+	testRouter.ServeHTTP(testWriter, req)
+
+	assert.Equal(t, http.StatusOK, testWriter.Code, "%s", testWriter.Body.Bytes())
+	var response struct {
+		Jobs []job.Job `json:"jobs"`
+	}
+	require.NoError(t, json.Unmarshal(testWriter.Body.Bytes(), &response))
+	require.Len(t, response.Jobs, 2)
+	require.NotNil(t, response.Jobs[0].Overdue)
+	assert.True(t, createdAt.Add(time.Hour).Equal(response.Jobs[0].Overdue.DueAt))
+	assert.Nil(t, response.Jobs[0].Overdue.LastRunAt)
+	assert.Nil(t, response.Jobs[1].Overdue)
+}
+
 func TestJobListByName(t *testing.T) {
 	t.Parallel()
 	mockJobStorage := &mocks.JobStorage{}
@@ -1,6 +1,7 @@
 package restapi
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/antgubarev/jobs/internal/job"
@@ -25,9 +26,11 @@ func (jsh *JobStatusHandler) Action(ctx *gin.Context) {
 		return
 	}
 
+	setAuditAction(ctx, "job."+action)
+
 	jobName := ctx.Param("name")
 	jobToAction, err := jsh.jobStorage.GetByName(jobName)
-	if err != nil {
+	if err != nil && !errors.Is(err, job.ErrJobNotFound) {
 		writeInternalServerErrorResponse(ctx, err)
 
 		return
@@ -39,6 +42,8 @@ func (jsh *JobStatusHandler) Action(ctx *gin.Context) {
 		return
 	}
 
+	setAuditBefore(ctx, jobToAction)
+
 	if action == "start" {
 		jsh.start(ctx, jobToAction)
 	}
@@ -61,6 +66,7 @@ func (jsh *JobStatusHandler) start(ctx *gin.Context, jobToStart *job.Job) {
 
 		return
 	}
+	setAuditAfter(ctx, jobToStart)
 
 	ctx.JSON(http.StatusOK, nil)
 }
@@ -78,6 +84,7 @@ func (jsh *JobStatusHandler) pause(ctx *gin.Context, jobToPause *job.Job) {
 
 		return
 	}
+	setAuditAfter(ctx, jobToPause)
 
 	ctx.JSON(http.StatusOK, nil)
 }
@@ -5,6 +5,10 @@ package mocks
 import (
 	context "context"
 
+	audit "github.com/antgubarev/jobs/internal/audit"
+
+	host "github.com/antgubarev/jobs/internal/host"
+
 	job "github.com/antgubarev/jobs/internal/job"
 	mock "github.com/stretchr/testify/mock"
 
@@ -18,6 +22,121 @@ type Client struct {
 	mock.Mock
 }
 
+// AuditList provides a mock function with given fields: ctx, filter
+func (_m *Client) AuditList(ctx context.Context, filter audit.Filter) ([]audit.Record, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []audit.Record
+	if rf, ok := ret.Get(0).(func(context.Context, audit.Filter) []audit.Record); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]audit.Record)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, audit.Filter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExecutionGet provides a mock function with given fields: ctx, id
+func (_m *Client) ExecutionGet(ctx context.Context, id uuid.UUID) (*job.Execution, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *job.Execution
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *job.Execution); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*job.Execution)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExecutionStop provides a mock function with given fields: ctx, id
+func (_m *Client) ExecutionStop(ctx context.Context, id uuid.UUID) (*job.Execution, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *job.Execution
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *job.Execution); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*job.Execution)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExecutionsList provides a mock function with given fields: ctx, filter
+func (_m *Client) ExecutionsList(ctx context.Context, filter job.ExecutionFilter) ([]job.Execution, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []job.Execution
+	if rf, ok := ret.Get(0).(func(context.Context, job.ExecutionFilter) []job.Execution); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]job.Execution)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, job.ExecutionFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Export provides a mock function with given fields: ctx, withHistory
+func (_m *Client) Export(ctx context.Context, withHistory bool) (*job.Dump, error) {
+	ret := _m.Called(ctx, withHistory)
+
+	var r0 *job.Dump
+	if rf, ok := ret.Get(0).(func(context.Context, bool) *job.Dump); ok {
+		r0 = rf(ctx, withHistory)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*job.Dump)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, withHistory)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetJobByName provides a mock function with given fields: ctx, name
 func (_m *Client) GetJobByName(ctx context.Context, name string) (*job.Job, error) {
 	ret := _m.Called(ctx, name)
@@ -41,6 +160,75 @@ func (_m *Client) GetJobByName(ctx context.Context, name string) (*job.Job, erro
 	return r0, r1
 }
 
+// HostRegister provides a mock function with given fields: ctx, in
+func (_m *Client) HostRegister(ctx context.Context, in *restapi.HostRegisterIn) (*host.Host, error) {
+	ret := _m.Called(ctx, in)
+
+	var r0 *host.Host
+	if rf, ok := ret.Get(0).(func(context.Context, *restapi.HostRegisterIn) *host.Host); ok {
+		r0 = rf(ctx, in)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*host.Host)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *restapi.HostRegisterIn) error); ok {
+		r1 = rf(ctx, in)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HostsList provides a mock function with given fields: ctx
+func (_m *Client) HostsList(ctx context.Context) ([]host.Host, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []host.Host
+	if rf, ok := ret.Get(0).(func(context.Context) []host.Host); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]host.Host)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: ctx, dump, strategy
+func (_m *Client) Import(ctx context.Context, dump *job.Dump, strategy job.ConflictStrategy) (*job.ImportResult, error) {
+	ret := _m.Called(ctx, dump, strategy)
+
+	var r0 *job.ImportResult
+	if rf, ok := ret.Get(0).(func(context.Context, *job.Dump, job.ConflictStrategy) *job.ImportResult); ok {
+		r0 = rf(ctx, dump, strategy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*job.ImportResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *job.Dump, job.ConflictStrategy) error); ok {
+		r1 = rf(ctx, dump, strategy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // JobCreate provides a mock function with given fields: ctx, in
 func (_m *Client) JobCreate(ctx context.Context, in *restapi.CreateJobIn) error {
 	ret := _m.Called(ctx, in)
@@ -55,8 +243,36 @@ func (_m *Client) JobCreate(ctx context.Context, in *restapi.CreateJobIn) error
 	return r0
 }
 
-// JobDelete provides a mock function with given fields: ctx, name
-func (_m *Client) JobDelete(ctx context.Context, name string) error {
+// JobDelete provides a mock function with given fields: ctx, name, force
+func (_m *Client) JobDelete(ctx context.Context, name string, force bool) error {
+	ret := _m.Called(ctx, name, force)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, name, force)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// JobFinish provides a mock function with given fields: ctx, id, in
+func (_m *Client) JobFinish(ctx context.Context, id uuid.UUID, in *restapi.JobFinishIn) error {
+	ret := _m.Called(ctx, id, in)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *restapi.JobFinishIn) error); ok {
+		r0 = rf(ctx, id, in)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// JobPause provides a mock function with given fields: ctx, name
+func (_m *Client) JobPause(ctx context.Context, name string) error {
 	ret := _m.Called(ctx, name)
 
 	var r0 error
@@ -69,13 +285,13 @@ func (_m *Client) JobDelete(ctx context.Context, name string) error {
 	return r0
 }
 
-// JobFinish provides a mock function with given fields: ctx, id
-func (_m *Client) JobFinish(ctx context.Context, id uuid.UUID) error {
-	ret := _m.Called(ctx, id)
+// JobResume provides a mock function with given fields: ctx, name
+func (_m *Client) JobResume(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -84,15 +300,15 @@ func (_m *Client) JobFinish(ctx context.Context, id uuid.UUID) error {
 }
 
 // JobStart provides a mock function with given fields: ctx, in
-func (_m *Client) JobStart(ctx context.Context, in *restapi.JobStartIn) (uuid.UUID, error) {
+func (_m *Client) JobStart(ctx context.Context, in *restapi.JobStartIn) (*restapi.JobStartOut, error) {
 	ret := _m.Called(ctx, in)
 
-	var r0 uuid.UUID
-	if rf, ok := ret.Get(0).(func(context.Context, *restapi.JobStartIn) uuid.UUID); ok {
+	var r0 *restapi.JobStartOut
+	if rf, ok := ret.Get(0).(func(context.Context, *restapi.JobStartIn) *restapi.JobStartOut); ok {
 		r0 = rf(ctx, in)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(uuid.UUID)
+			r0 = ret.Get(0).(*restapi.JobStartOut)
 		}
 	}
 
@@ -106,6 +322,29 @@ func (_m *Client) JobStart(ctx context.Context, in *restapi.JobStartIn) (uuid.UU
 	return r0, r1
 }
 
+// JobStats provides a mock function with given fields: ctx, name, windows
+func (_m *Client) JobStats(ctx context.Context, name string, windows []string) (*job.RunStats, error) {
+	ret := _m.Called(ctx, name, windows)
+
+	var r0 *job.RunStats
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) *job.RunStats); ok {
+		r0 = rf(ctx, name, windows)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*job.RunStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, name, windows)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // JobsList provides a mock function with given fields: ctx
 func (_m *Client) JobsList(ctx context.Context) ([]job.Job, error) {
 	ret := _m.Called(ctx)
@@ -128,3 +367,40 @@ func (_m *Client) JobsList(ctx context.Context) ([]job.Job, error) {
 
 	return r0, r1
 }
+
+// ResourcesList provides a mock function with given fields: ctx
+func (_m *Client) ResourcesList(ctx context.Context) ([]job.Resource, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []job.Resource
+	if rf, ok := ret.Get(0).(func(context.Context) []job.Resource); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]job.Resource)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WaiterLeave provides a mock function with given fields: ctx, id
+func (_m *Client) WaiterLeave(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
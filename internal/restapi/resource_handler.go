@@ -0,0 +1,28 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/gin-gonic/gin"
+)
+
+type ResourceHandler struct {
+	resources *job.Resources
+}
+
+func NewResourceHandler(resources *job.Resources) *ResourceHandler {
+	return &ResourceHandler{resources: resources}
+}
+
+// ListHandle returns shared resources with running executions which hold them.
+func (rh *ResourceHandler) ListHandle(ctx *gin.Context) {
+	resources, err := rh.resources.List()
+	if err != nil {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"resources": resources})
+}
@@ -0,0 +1,48 @@
+package restapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/memory"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourcesList(t *testing.T) {
+	t.Parallel()
+	jobStorage, executionStorage := memory.NewJobStorage(), memory.NewExecutionStorage()
+	for _, name := range []string{"db-backup", "db-vacuum"} {
+		jb := job.NewJob(name)
+		jb.LockMode = job.FreeLockMode
+		jb.Resources = []string{"db"}
+		assert.NoError(t, jobStorage.Store(jb))
+	}
+
+	handler := restapi.NewExecutionHandler(jobStorage, executionStorage)
+	testRouter := internal.NewTestRouter()
+	testRouter.POST("/executions", handler.StartHandle)
+	testRouter.GET("/resources",
+		restapi.NewResourceHandler(job.NewResources(jobStorage, executionStorage, nil)).ListHandle)
+
+	assert.Equal(t, http.StatusOK, startExecution(testRouter, `{"job":"db-backup","host":"host-1"}`).Code)
+	assert.Equal(t, http.StatusLocked, startExecution(testRouter, `{"job":"db-vacuum","host":"host-2"}`).Code)
+
+	testWriter := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/resources", nil)
+	testRouter.ServeHTTP(testWriter, req)
+
+	assert.Equal(t, http.StatusOK, testWriter.Code, "%s", testWriter.Body.Bytes())
+	var out struct {
+		Resources []job.Resource `json:"resources"`
+	}
+	assert.NoError(t, json.Unmarshal(testWriter.Body.Bytes(), &out))
+	assert.Len(t, out.Resources, 1)
+	assert.Equal(t, "db", out.Resources[0].Name)
+	assert.Len(t, out.Resources[0].Holders, 1)
+	assert.Equal(t, "db-backup", out.Resources[0].Holders[0].Job)
+}
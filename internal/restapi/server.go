@@ -1,39 +1,108 @@
 package restapi
 
 import (
-	"log"
 	"net/http"
 
-	"github.com/antgubarev/jobs/internal/boltdb"
+	"github.com/antgubarev/jobs/internal/audit"
+	"github.com/antgubarev/jobs/internal/host"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/metrics"
 	"github.com/gin-gonic/gin"
-	"go.etcd.io/bbolt"
 )
 
-func NewServer(addr string, boltDB *bbolt.DB) *http.Server {
-	router := gin.Default()
+type Storages struct {
+	Job       job.Storage
+	Execution job.ExecutionStorage
+	Audit     audit.Storage
+	// Rejections are refused starts of locked jobs, stats don't count them if it's nil.
+	Rejections job.RejectionStorage
+	// Hosts are registered by agents, a new registry is used if it's nil.
+	Hosts *host.Registry
+	// Resources shared by jobs, resources with default capacities are used if it's nil.
+	Resources *job.Resources
+	// Controller starts and finishes executions, a new one is created if it's nil.
+	Controller *job.Controller
+	// Watchdog flags overdue jobs in the list, jobs aren't flagged if it's nil.
+	Watchdog *job.Watchdog
+}
 
-	jobStorage, err := boltdb.NewJobStorage(boltDB)
-	if err != nil {
-		log.Fatal(err)
+type ServerOption func(router *gin.Engine)
+
+// WithAuth requires bearer tokens, tokens maps actor names to tokens.
+func WithAuth(tokens map[string]string) ServerOption {
+	return func(router *gin.Engine) {
+		if len(tokens) > 0 {
+			router.Use(TokenAuth(tokens))
+		}
 	}
-	executionStorage, err := boltdb.NewExecutionStorage(boltDB)
-	if err != nil {
-		log.Fatal(err)
+}
+
+func NewServer(addr string, storages Storages, opts ...ServerOption) *http.Server {
+	router := gin.Default()
+	for _, opt := range opts {
+		opt(router)
 	}
+	jobStorage, executionStorage := storages.Job, storages.Execution
 
 	jobsHandler := NewJobsHandler(jobStorage)
+	jobsHandler.SetWatchdog(storages.Watchdog)
 	router.GET("/jobs", jobsHandler.ListHandle)
 
 	jobHandler := NewJobHandler(jobStorage, executionStorage)
-	router.POST("/job", jobHandler.CreateHandle)
-	router.DELETE("/job/:name", jobHandler.DeleteHandle)
+	jobHandler.SetRejections(storages.Rejections)
+	router.POST("/job", AuditLog(storages.Audit, "job.create"), jobHandler.CreateHandle)
+	router.DELETE("/job/:name", AuditLog(storages.Audit, "job.delete"), jobHandler.DeleteHandle)
+	router.GET("/job/:name/usage", jobHandler.UsageHandle)
+	router.GET("/job/:name/stats", jobHandler.StatsHandle)
 
 	jobStatusHandler := NewJobStatusHandler(jobStorage)
-	router.POST("/job/:name/:action", jobStatusHandler.Action)
+	router.POST("/job/:name/:action", AuditLog(storages.Audit, "job.status"), jobStatusHandler.Action)
+
+	resources := storages.Resources
+	if resources == nil {
+		resources = job.NewResources(jobStorage, executionStorage, nil)
+	}
+	controller := storages.Controller
+	if controller == nil {
+		controller = job.NewController(executionStorage)
+		controller.SetResources(resources)
+	}
 
+	waitQueue := job.NewWaitQueue(job.DefaultWaiterGrace)
 	executionHandler := NewExecutionHandler(jobStorage, executionStorage)
-	router.POST("/executions", executionHandler.StartHandle)
-	router.DELETE("/execution/:id", executionHandler.FinishHandle)
+	executionHandler.SetWaitQueue(waitQueue)
+	executionHandler.SetController(controller)
+	executionHandler.SetRejections(storages.Rejections)
+	router.POST("/executions", AuditLog(storages.Audit, "execution.start"), executionHandler.StartHandle)
+	router.GET("/executions", executionHandler.ListHandle)
+	router.GET("/execution/:id", executionHandler.GetHandle)
+	router.DELETE("/execution/:id", AuditLog(storages.Audit, "execution.finish"), executionHandler.FinishHandle)
+	router.POST("/execution/:id/stop", AuditLog(storages.Audit, "execution.stop"), executionHandler.StopHandle)
+
+	waitersHandler := NewWaitersHandler(waitQueue)
+	waitersHandler.SetRejections(storages.Rejections)
+	router.GET("/waiters", waitersHandler.ListHandle)
+	router.DELETE("/waiter/:id", waitersHandler.LeaveHandle)
+
+	transferHandler := NewTransferHandler(jobStorage, executionStorage)
+	router.GET("/export", transferHandler.ExportHandle)
+	router.POST("/import", AuditLog(storages.Audit, "import"), transferHandler.ImportHandle)
+
+	hosts := storages.Hosts
+	if hosts == nil {
+		hosts = host.NewRegistry()
+	}
+	hostHandler := NewHostHandler(hosts)
+	router.POST("/host", hostHandler.RegisterHandle)
+	router.GET("/hosts", hostHandler.ListHandle)
+
+	resourceHandler := NewResourceHandler(resources)
+	router.GET("/resources", resourceHandler.ListHandle)
+
+	auditHandler := NewAuditHandler(storages.Audit)
+	router.GET("/audit", auditHandler.ListHandle)
+
+	router.GET("/debug/vars", gin.WrapH(metrics.Handler()))
 
 	srv := &http.Server{
 		Addr:    addr,
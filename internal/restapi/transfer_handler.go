@@ -0,0 +1,68 @@
+package restapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/gin-gonic/gin"
+)
+
+type TransferHandler struct {
+	transfer *job.Transfer
+}
+
+func NewTransferHandler(jobStorage job.Storage, executionStorage job.ExecutionStorage) *TransferHandler {
+	return &TransferHandler{
+		transfer: job.NewTransfer(jobStorage, executionStorage),
+	}
+}
+
+func (th *TransferHandler) ExportHandle(ctx *gin.Context) {
+	dump, err := th.transfer.Export(ctx.Query("history") == "true")
+	if err != nil {
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dump)
+}
+
+func (th *TransferHandler) ImportHandle(ctx *gin.Context) {
+	strategy, err := job.ParseConflictStrategy(ctx.DefaultQuery("strategy", string(job.ConflictFail)))
+	if err != nil {
+		writeBadRequestResponse(ctx, err.Error())
+
+		return
+	}
+
+	var dump job.Dump
+	if err := ctx.ShouldBindJSON(&dump); err != nil {
+		writeBadRequestResponse(ctx, err.Error())
+
+		return
+	}
+
+	result, err := th.transfer.Import(&dump, strategy)
+	if err != nil {
+		var conflictErr *job.ConflictError
+		if errors.As(err, &conflictErr) {
+			writeConflictResponse(ctx, conflictErr.Error())
+
+			return
+		}
+		if errors.Is(err, job.ErrInvalidDump) {
+			writeBadRequestResponse(ctx, err.Error())
+
+			return
+		}
+		writeInternalServerErrorResponse(ctx, err)
+
+		return
+	}
+
+	setAuditAfter(ctx, result)
+
+	ctx.JSON(http.StatusOK, gin.H{"result": result})
+}
@@ -0,0 +1,117 @@
+package restapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antgubarev/jobs/internal"
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/antgubarev/jobs/internal/job/mocks"
+	"github.com/antgubarev/jobs/internal/restapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportHandle(t *testing.T) {
+	t.Parallel()
+	mockJobStorage := &mocks.JobStorage{}
+	mockJobStorage.On("GetAll").Return([]job.Job{*job.NewJob(TestJobName)}, nil).Once()
+	mockExecutionStorage := &mocks.ExecutionStorage{}
+	mockExecutionStorage.On("GetByJobName", TestJobName).
+		Return([]job.Execution{*job.NewRunningExecution(TestJobName)}, nil).Once()
+
+	testRouter := internal.NewTestRouter()
+	handler := restapi.NewTransferHandler(mockJobStorage, mockExecutionStorage)
+	testRouter.GET("/export", handler.ExportHandle)
+
+	testWriter := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/export?history=true", nil)
+	testRouter.ServeHTTP(testWriter, req)
+
+	assert.Equal(t, http.StatusOK, testWriter.Code, "%s", testWriter.Body.Bytes())
+	mockJobStorage.AssertExpectations(t)
+	mockExecutionStorage.AssertExpectations(t)
+}
+
+func TestImportHandle(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name       string
+		request    string
+		body       string
+		jobStorage func() *mocks.JobStorage
+		status     int
+	}{
+		{
+			name:    "create",
+			request: "/import",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetAll").Return([]job.Job{}, nil).Once()
+				mockJobStorage.On("Store", mock.MatchedBy(func(j *job.Job) bool {
+					return j.Name == TestJobName
+				})).Return(nil).Once()
+
+				return mockJobStorage
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:    "conflict",
+			request: "/import?strategy=fail",
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetAll").Return([]job.Job{*job.NewJob(TestJobName)}, nil).Once()
+
+				return mockJobStorage
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:    "undefined strategy",
+			request: "/import?strategy=undefined",
+			jobStorage: func() *mocks.JobStorage {
+				return &mocks.JobStorage{}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:    "invalid job",
+			request: "/import",
+			body:    `{"jobs":[{"name":"job","lockMode":"unknown"}]}`,
+			jobStorage: func() *mocks.JobStorage {
+				mockJobStorage := &mocks.JobStorage{}
+				mockJobStorage.On("GetAll").Return([]job.Job{}, nil).Once()
+
+				return mockJobStorage
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			mockJobStorage := testCase.jobStorage()
+
+			testRouter := internal.NewTestRouter()
+			handler := restapi.NewTransferHandler(mockJobStorage, &mocks.ExecutionStorage{})
+			testRouter.POST("/import", handler.ImportHandle)
+
+			body := testCase.body
+			if body == "" {
+				body = `{"jobs":[{"name":"job"}]}`
+			}
+			testWriter := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", testCase.request, bytes.NewReader([]byte(body)))
+			req.Header.Set("Content-Type", "application/json")
+			testRouter.ServeHTTP(testWriter, req)
+
+			assert.Equal(t, testCase.status, testWriter.Code, "%s", testWriter.Body.Bytes())
+			mockJobStorage.AssertExpectations(t)
+		})
+	}
+}
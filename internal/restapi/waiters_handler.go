@@ -0,0 +1,49 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/antgubarev/jobs/internal/job"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WaitersHandler struct {
+	waitQueue        *job.WaitQueue
+	rejectionStorage job.RejectionStorage
+}
+
+func NewWaitersHandler(waitQueue *job.WaitQueue) *WaitersHandler {
+	return &WaitersHandler{waitQueue: waitQueue}
+}
+
+// SetRejections sets the storage which starts refused by leaving the queue are appended to.
+func (wh *WaitersHandler) SetRejections(storage job.RejectionStorage) {
+	wh.rejectionStorage = storage
+}
+
+// ListHandle returns starts waiting for the lock in the queue order, filtered by `job` query parameter.
+func (wh *WaitersHandler) ListHandle(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"waiters": wh.waitQueue.List(ctx.Query("job"))})
+}
+
+// LeaveHandle removes the waiter from the queue, e.g. when the client stops waiting,
+// so its start is finally refused.
+func (wh *WaitersHandler) LeaveHandle(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		writeBadRequestResponse(ctx, "invalid id")
+
+		return
+	}
+
+	waiter, ok := wh.waitQueue.LeaveByID(id)
+	if !ok {
+		writeNotFoundResponse(ctx, "waiter not found")
+
+		return
+	}
+	appendLockRejection(wh.rejectionStorage, waiter.Job)
+
+	ctx.JSON(http.StatusOK, nil)
+}